@@ -0,0 +1,339 @@
+package pg
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/blusewang/pg/v2/internal/codec"
+	"net"
+	"net/netip"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Record 复合类型或匿名记录解码后的值，复合类型及 record 列直接解码为 Record
+type Record = codec.Record
+
+// Composite 用于把复合类型或记录扫描到结构体，或把结构体作为复合类型的参数传入
+// v 为结构体的指针，或结构体切片的指针，后者对应复合类型的数组
+// 结构体只经 Composite 映射：直接作为参数传入的结构体会被拒绝，复合类型的列也只解码为 Record
+//
+// 属性按 pg 标签对应到字段，没有标签时按字段名对应且忽略大小写，标签为 "-" 的字段被忽略
+// 匿名记录没有属性名，按字段的顺序对应
+//
+//	type Address struct {
+//		Street string `pg:"street"`
+//		City   string `pg:"city"`
+//	}
+//	var addr Address
+//	err := db.QueryRow("select address from users where id=$1", id).Scan(pg.Composite(&addr))
+//	_, err = db.Exec("update users set address=$1 where id=$2", pg.Composite(&addr), id)
+func Composite(v interface{}) interface {
+	sql.Scanner
+	driver.Valuer
+} {
+	return compositeArg{v}
+}
+
+type compositeArg struct {
+	v interface{}
+}
+
+func (a compositeArg) Scan(src interface{}) error {
+	var rv = reflect.ValueOf(a.v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("pg: Composite requires a non-nil pointer, got %T", a.v)
+	}
+	return assignValue(rv.Elem(), src)
+}
+
+func (a compositeArg) Value() (driver.Value, error) {
+	raw, err := formatValue(reflect.ValueOf(a.v))
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	return string(raw), nil
+}
+
+type structField struct {
+	name  string
+	index int
+}
+
+// structFields 结构体中参与映射的字段
+func structFields(t reflect.Type) (fields []structField) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		var name = f.Tag.Get("pg")
+		if name == "-" {
+			continue
+		} else if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, structField{name: name, index: i})
+	}
+	return
+}
+
+// assignRecord 把记录的各个属性赋值到结构体的字段
+func assignRecord(dst reflect.Value, rec Record) error {
+	var fields = structFields(dst.Type())
+	if rec.Names == nil {
+		if len(fields) != len(rec.Values) {
+			return fmt.Errorf("pg: record has %d fields but %v has %d", len(rec.Values), dst.Type(), len(fields))
+		}
+		for i, f := range fields {
+			if err := assignValue(dst.Field(f.index), rec.Values[i]); err != nil {
+				return fmt.Errorf("pg: field %s: %w", dst.Type().Field(f.index).Name, err)
+			}
+		}
+		return nil
+	}
+	for i, name := range rec.Names {
+		var found = -1
+		for _, f := range fields {
+			if f.name == name {
+				found = f.index
+				break
+			} else if found < 0 && strings.EqualFold(f.name, name) {
+				found = f.index
+			}
+		}
+		if found < 0 {
+			return fmt.Errorf("pg: no field in %v for attribute %q", dst.Type(), name)
+		}
+		if err := assignValue(dst.Field(found), rec.Values[i]); err != nil {
+			return fmt.Errorf("pg: attribute %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// assignValue 把解码后的值赋值到 dst，规则与 database/sql 的 Scan 类似
+// 指针、切片、map 可接收 NULL；实现了 sql.Scanner 的类型交由其自行处理
+func assignValue(dst reflect.Value, src interface{}) error {
+	// 数组解码为元素指针的切片，元素赋值到非指针字段时取其指向的值
+	if sv := reflect.ValueOf(src); sv.Kind() == reflect.Ptr && !sv.Type().AssignableTo(dst.Type()) {
+		if sv.IsNil() {
+			src = nil
+		} else {
+			src = sv.Elem().Interface()
+		}
+	}
+	switch dst.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		if src == nil {
+			dst.Set(reflect.Zero(dst.Type()))
+			return nil
+		}
+	}
+	if scanner, ok := dst.Addr().Interface().(sql.Scanner); ok {
+		return scanner.Scan(src)
+	}
+	if src == nil {
+		return fmt.Errorf("pg: cannot assign NULL to %v", dst.Type())
+	}
+	if dst.Kind() == reflect.Ptr {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return assignValue(dst.Elem(), src)
+	}
+
+	var sv = reflect.ValueOf(src)
+	switch v := src.(type) {
+	case Record:
+		if dst.Kind() == reflect.Struct {
+			return assignRecord(dst, v)
+		}
+	case string:
+		if dst.Kind() != reflect.String && dst.Kind() != reflect.Interface {
+			return assignText(dst, v)
+		}
+	}
+	if sv.Type().AssignableTo(dst.Type()) {
+		dst.Set(sv)
+		return nil
+	}
+	if sv.Kind() == reflect.Slice && dst.Kind() == reflect.Slice {
+		var s = reflect.MakeSlice(dst.Type(), sv.Len(), sv.Len())
+		for i := 0; i < sv.Len(); i++ {
+			if err := assignValue(s.Index(i), sv.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		dst.Set(s)
+		return nil
+	}
+	if isScalarKind(sv.Kind()) && isScalarKind(dst.Kind()) && sv.Type().ConvertibleTo(dst.Type()) && (sv.Kind() == reflect.String) == (dst.Kind() == reflect.String) {
+		dst.Set(sv.Convert(dst.Type()))
+		return nil
+	}
+	return fmt.Errorf("pg: cannot assign %T to %v", src, dst.Type())
+}
+
+func isScalarKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// assignText 把文本格式的值解析到 dst，用于匿名记录中缺少类型信息的字段
+func assignText(dst reflect.Value, s string) error {
+	switch dst.Addr().Interface().(type) {
+	case *time.Time, *netip.Prefix, *netip.Addr, *net.HardwareAddr:
+		return scanElement(dst.Addr().Interface(), s)
+	}
+	switch dst.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		dst.SetBool(b)
+		return err
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, dst.Type().Bits())
+		dst.SetInt(n)
+		return err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, dst.Type().Bits())
+		dst.SetUint(n)
+		return err
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, dst.Type().Bits())
+		dst.SetFloat(f)
+		return err
+	case reflect.Struct:
+		var rec Record
+		if err := rec.Scan(s); err != nil {
+			return err
+		}
+		return assignRecord(dst, rec)
+	case reflect.Slice:
+		if dst.Type().Elem().Kind() == reflect.Uint8 {
+			b, err := codec.ParseBytea([]byte(s))
+			if err == nil {
+				dst.SetBytes(b)
+			}
+			return err
+		}
+		arr, err := codec.ParseArray([]byte(s), ',')
+		if err != nil {
+			return err
+		}
+		if len(arr.Dims) > 1 {
+			return fmt.Errorf("pg: cannot assign multidimensional array %q to %v", s, dst.Type())
+		}
+		var elems = reflect.MakeSlice(dst.Type(), len(arr.Elements), len(arr.Elements))
+		for i, e := range arr.Elements {
+			var v interface{}
+			if e != nil {
+				v = string(e)
+			}
+			if err = assignValue(elems.Index(i), v); err != nil {
+				return err
+			}
+		}
+		dst.Set(elems)
+		return nil
+	}
+	return fmt.Errorf("pg: cannot assign %q to %v", s, dst.Type())
+}
+
+// formatValue 输出 v 的文本格式，用于复合类型的属性及数组的元素，返回 nil 表示 NULL
+// 结构体按复合类型输出，切片按数组输出
+func formatValue(v reflect.Value) ([]byte, error) {
+	for v.Kind() == reflect.Interface || (v.Kind() == reflect.Ptr && !v.Type().Implements(valuerType)) {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		return nil, nil
+	}
+	var x = v.Interface()
+	if valuer, ok := x.(driver.Valuer); ok {
+		dv, err := valuer.Value()
+		if err != nil || dv == nil {
+			return nil, err
+		}
+		s, err := formatElement(dv)
+		return []byte(s), err
+	}
+	switch x.(type) {
+	case time.Time, netip.Prefix, netip.Addr, net.HardwareAddr, json.RawMessage:
+		s, err := formatElement(x)
+		return []byte(s), err
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return []byte(v.String()), nil
+	case reflect.Bool:
+		return strconv.AppendBool(nil, v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.AppendInt(nil, v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.AppendUint(nil, v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.AppendFloat(nil, v.Float(), 'f', -1, v.Type().Bits()), nil
+	case reflect.Struct:
+		var fields = structFields(v.Type())
+		var raws = make([][]byte, len(fields))
+		for i, f := range fields {
+			var err error
+			if raws[i], err = formatValue(v.Field(f.index)); err != nil {
+				return nil, fmt.Errorf("pg: field %s: %w", v.Type().Field(f.index).Name, err)
+			}
+		}
+		return codec.FormatRecord(raws), nil
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if v.Kind() == reflect.Slice && v.IsNil() {
+				return nil, nil
+			}
+			return []byte(codec.FormatBytea(v.Bytes())), nil
+		}
+		var arr codec.Array
+		if err := formatArrayLevel(v, 0, &arr); err != nil {
+			return nil, err
+		}
+		return codec.FormatArray(arr, ','), nil
+	}
+	return nil, fmt.Errorf("pg: unsupported type %T", x)
+}
+
+// formatArrayLevel 展开（可多维的）切片，各子切片的长度须一致
+func formatArrayLevel(v reflect.Value, depth int, arr *codec.Array) error {
+	if depth == len(arr.Dims) {
+		arr.Dims = append(arr.Dims, codec.ArrayDimension{Length: v.Len(), LowerBound: 1})
+	} else if arr.Dims[depth].Length != v.Len() {
+		return errors.New("pg: multidimensional array must have sub-arrays with matching dimensions")
+	}
+	for i := 0; i < v.Len(); i++ {
+		e := v.Index(i)
+		if k := e.Kind(); (k == reflect.Slice || k == reflect.Array) && e.Type().Elem().Kind() != reflect.Uint8 {
+			if err := formatArrayLevel(e, depth+1, arr); err != nil {
+				return err
+			}
+			continue
+		}
+		raw, err := formatValue(e)
+		if err != nil {
+			return err
+		}
+		arr.Elements = append(arr.Elements, raw)
+	}
+	return nil
+}
+
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
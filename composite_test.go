@@ -0,0 +1,77 @@
+package pg
+
+import (
+	"testing"
+)
+
+type testAddress struct {
+	Street string `pg:"street"`
+	City   string
+	Zip    *string
+	Note   string `pg:"-"`
+}
+
+type testPerson struct {
+	Name    string
+	Address testAddress
+	Tags    []string
+}
+
+func TestCompositeScan(t *testing.T) {
+	var p testPerson
+	var rec = Record{
+		Names: []string{"name", "address", "tags"},
+		Values: []interface{}{"Ann", Record{
+			Names:  []string{"street", "city", "zip"},
+			Values: []interface{}{"Main St", "Springfield", nil},
+		}, []*string{strPtr("a"), strPtr("b")}},
+	}
+	if err := Composite(&p).Scan(rec); err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "Ann" || p.Address.Street != "Main St" || p.Address.City != "Springfield" || p.Address.Zip != nil || len(p.Tags) != 2 || p.Tags[1] != "b" {
+		t.Errorf("Scan = %+v", p)
+	}
+
+	// 匿名记录按字段顺序对应，嵌套的记录为文本
+	var anon testPerson
+	if err := Composite(&anon).Scan(Record{Values: []interface{}{"Bob", `("1 Elm",Paris,75001)`, `{x,NULL}`}}); err == nil {
+		t.Error("a NULL array element should not scan into string")
+	}
+	if err := Composite(&anon).Scan(Record{Values: []interface{}{"Bob", `("1 Elm",Paris,75001)`, `{x,y}`}}); err != nil {
+		t.Fatal(err)
+	}
+	if anon.Address.Street != "1 Elm" || anon.Address.Zip == nil || *anon.Address.Zip != "75001" || anon.Tags[0] != "x" {
+		t.Errorf("Scan anonymous record = %+v", anon)
+	}
+
+	if err := Composite(&p).Scan(Record{Names: []string{"age"}, Values: []interface{}{"1"}}); err == nil {
+		t.Error("an unknown attribute should fail")
+	}
+	if err := Composite(&p).Scan(Record{Values: []interface{}{"1"}}); err == nil {
+		t.Error("an anonymous record with a different field count should fail")
+	}
+	if err := Composite(p).Scan(rec); err == nil {
+		t.Error("a non-pointer should fail")
+	}
+}
+
+func TestCompositeValue(t *testing.T) {
+	var p = testPerson{Name: "Ann", Address: testAddress{Street: "Main St", City: "A,B", Note: "ignored"}, Tags: []string{"x y"}}
+	v, err := Composite(&p).Value()
+	if err != nil || v != `(Ann,"(""Main St"",""A,B"",)","{""x y""}")` {
+		t.Errorf("Value() = %v, %v", v, err)
+	}
+	v, err = Composite((*testPerson)(nil)).Value()
+	if err != nil || v != nil {
+		t.Errorf("Value() of a nil pointer = %v, %v", v, err)
+	}
+	v, err = Composite(&[]testAddress{{Street: "s", City: "c"}}).Value()
+	if err != nil || v != `{"(s,c,)"}` {
+		t.Errorf("Value() of a slice = %v, %v", v, err)
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
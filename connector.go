@@ -0,0 +1,52 @@
+package pg
+
+import (
+	"github.com/blusewang/pg/v2/internal/app"
+	"github.com/blusewang/pg/v2/internal/client"
+)
+
+// Connector 配合 sql.OpenDB 使用，可以设置连接串无法表达的选项
+//
+//	c, err := pg.NewConnector("host=db.example.com dbname=app oauth_issuer=https://id.example.com oauth_client_id=app")
+//	c.SetOAuthTokenProvider(func(ctx context.Context, req pg.OAuthTokenRequest) (string, error) {
+//		return tokens.Get(ctx, req.Scope)
+//	})
+//	db := sql.OpenDB(c)
+//
+// 证书来自密钥管理服务等不便落盘的场合，可以直接提供 PEM：
+//
+//	c.SetClientCertificatePEM(certPEM, keyPEM)
+//	c.SetRootCertificatePEM(caPEM)
+type Connector struct {
+	app.Connector
+}
+
+// NewConnector 解析连接串并创建 Connector
+func NewConnector(dsn string) (*Connector, error) {
+	c, err := app.NewConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &Connector{c}, nil
+}
+
+// NewStrictConnector 同 NewConnector，但像 libpq 一样校验连接串：关键字区分大小写，
+// 未知的参数返回错误，而不是作为服务器参数发送；服务器参数需通过 options 传递
+//
+//	c, err := pg.NewStrictConnector("host=db.example.com dbname=app options='-c search_path=app'")
+func NewStrictConnector(dsn string) (*Connector, error) {
+	c, err := app.NewStrictConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &Connector{c}, nil
+}
+
+// OAUTHBEARER 认证（PostgreSQL 18 起）
+// 服务器要求 OAUTHBEARER 时向令牌提供方获取 Bearer 令牌；令牌被拒绝且服务器给出了 openid-configuration 时，
+// 会带上其中的地址及 scope 重新调用令牌提供方并重连一次，仍失败时返回 *OAuthError
+type (
+	OAuthTokenProvider = client.OAuthTokenProvider
+	OAuthTokenRequest  = client.OAuthTokenRequest
+	OAuthError         = client.OAuthError
+)
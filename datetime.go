@@ -0,0 +1,10 @@
+package pg
+
+import "github.com/blusewang/pg/v2/internal/codec"
+
+// InfinityTime、NegativeInfinityTime 对应 timestamp、timestamptz、date 的 infinity 和 -infinity
+// 扫描时得到这两个值，作为参数传入时也会被还原为 infinity 和 -infinity，比较时请使用 Equal
+var (
+	InfinityTime         = codec.PositiveInfinity
+	NegativeInfinityTime = codec.NegativeInfinity
+)
@@ -0,0 +1,24 @@
+package pg
+
+import "github.com/blusewang/pg/v2/internal/client"
+
+// DataSourceName 解析后的连接串，修改后可用 String 或 URI 转回连接串，用 Redacted 记录日志
+//
+//	dsn, err := pg.ParseDSN("host=db.example.com user=app password='s3cr3t'")
+//	dsn.Port = "6432"
+//	log.Println(dsn.Redacted()) // host=db.example.com port=6432 user=app dbname=postgres password=xxxxx
+//	c, err := pg.NewConnector(dsn.String())
+type DataSourceName = client.DataSourceName
+
+// ConnectError 拨号或 TLS 握手失败时返回，Target 中不含口令，可以通过 errors.Unwrap 取得原始错误；服务器拒绝连接时仍返回 pg.Error
+type ConnectError = client.ConnectError
+
+// ParseDSN 解析 keyword = value 或 postgresql:// 形式的连接串
+func ParseDSN(dsn string) (DataSourceName, error) {
+	return client.ParseDSN(dsn)
+}
+
+// ParseDSNStrict 同 ParseDSN，但像 libpq 一样拒绝未知的连接参数
+func ParseDSNStrict(dsn string) (DataSourceName, error) {
+	return client.ParseDSNStrict(dsn)
+}
@@ -0,0 +1,194 @@
+package pg
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/blusewang/pg/v2/internal/codec"
+	"math"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scanElement 把范围边界等复合值中的元素由文本格式解析到 dst，dst 须为指针
+func scanElement(dst interface{}, src string) (err error) {
+	switch d := dst.(type) {
+	case sql.Scanner:
+		return d.Scan(src)
+	case *string:
+		*d = src
+	case *int:
+		var n int64
+		n, err = strconv.ParseInt(src, 10, 0)
+		*d = int(n)
+	case *int16:
+		var n int64
+		n, err = strconv.ParseInt(src, 10, 16)
+		*d = int16(n)
+	case *int32:
+		var n int64
+		n, err = strconv.ParseInt(src, 10, 32)
+		*d = int32(n)
+	case *int64:
+		*d, err = strconv.ParseInt(src, 10, 64)
+	case *float32:
+		var f float64
+		f, err = strconv.ParseFloat(src, 32)
+		*d = float32(f)
+	case *float64:
+		*d, err = strconv.ParseFloat(src, 64)
+	case *netip.Prefix:
+		*d, err = codec.ParseInet([]byte(src))
+	case *netip.Addr:
+		*d, err = netip.ParseAddr(src)
+	case *net.HardwareAddr:
+		*d, err = codec.ParseMacaddr([]byte(src))
+	case *time.Time:
+		// date 不含时间部分；timestamp 不带时区偏移时按 UTC 解释
+		if strings.Contains(src, ":") || src == "infinity" || src == "-infinity" {
+			*d, err = codec.ParseTimestamp(src, time.UTC)
+		} else {
+			*d, err = codec.ParseDate(src, time.UTC)
+		}
+	default:
+		return fmt.Errorf("pg: cannot scan %q into %T", src, dst)
+	}
+	return
+}
+
+// formatElement 输出元素的文本格式
+func formatElement(v interface{}) (string, error) {
+	switch x := v.(type) {
+	case driver.Valuer:
+		dv, err := x.Value()
+		if err != nil {
+			return "", err
+		}
+		if dv == nil {
+			return "", fmt.Errorf("pg: %T is NULL and cannot be used as an element", v)
+		}
+		return formatElement(dv)
+	case string:
+		return x, nil
+	case []byte:
+		return codec.FormatBytea(x), nil
+	case json.RawMessage:
+		return string(x), nil
+	case bool:
+		return strconv.FormatBool(x), nil
+	case int:
+		return strconv.FormatInt(int64(x), 10), nil
+	case int16:
+		return strconv.FormatInt(int64(x), 10), nil
+	case int32:
+		return strconv.FormatInt(int64(x), 10), nil
+	case int64:
+		return strconv.FormatInt(x, 10), nil
+	case float32:
+		return strconv.FormatFloat(float64(x), 'f', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64), nil
+	case time.Time:
+		return codec.FormatTimestamp(x), nil
+	case netip.Prefix:
+		return codec.FormatInet(x), nil
+	case netip.Addr:
+		return x.String(), nil
+	case net.HardwareAddr:
+		return x.String(), nil
+	}
+	return "", fmt.Errorf("pg: unsupported element type %T", v)
+}
+
+// unmarshalElement 把元素由二进制格式解析到 dst，dst 须为指针
+// time.Time 按长度区分 date（4 字节）与 timestamp、timestamptz（8 字节）
+func unmarshalElement(dst interface{}, src []byte) (err error) {
+	// time.Time、netip 自身的 UnmarshalBinary 是 Go 的格式，须在 encoding.BinaryUnmarshaler 之前处理
+	switch d := dst.(type) {
+	case *time.Time:
+		if len(src) == 4 {
+			*d, err = codec.ParseDateBinary(src)
+		} else {
+			*d, err = codec.ParseTimestampBinary(src)
+		}
+		return
+	case *netip.Prefix:
+		*d, err = codec.ParseInetBinary(src)
+		return
+	case *netip.Addr:
+		var p netip.Prefix
+		if p, err = codec.ParseInetBinary(src); err != nil {
+			return
+		}
+		if !p.IsSingleIP() {
+			return fmt.Errorf("pg: cannot unmarshal network %s into netip.Addr", p)
+		}
+		*d = p.Addr()
+		return
+	case *net.HardwareAddr:
+		*d, err = codec.ParseMacaddrBinary(src)
+		return
+	case encoding.BinaryUnmarshaler:
+		return d.UnmarshalBinary(src)
+	case *string:
+		*d = string(src)
+		return nil
+	}
+	var n int64
+	switch len(src) {
+	case 2:
+		n = int64(int16(binary.BigEndian.Uint16(src)))
+	case 4:
+		n = int64(int32(binary.BigEndian.Uint32(src)))
+	case 8:
+		n = int64(binary.BigEndian.Uint64(src))
+	default:
+		return fmt.Errorf("pg: cannot unmarshal %d bytes into %T", len(src), dst)
+	}
+	switch d := dst.(type) {
+	case *int:
+		*d = int(n)
+	case *int16:
+		*d = int16(n)
+	case *int32:
+		*d = int32(n)
+	case *int64:
+		*d = n
+	case *float32:
+		*d = math.Float32frombits(uint32(n))
+	case *float64:
+		if len(src) == 4 {
+			*d = float64(math.Float32frombits(uint32(n)))
+		} else {
+			*d = math.Float64frombits(uint64(n))
+		}
+	default:
+		return fmt.Errorf("pg: cannot unmarshal binary value into %T", dst)
+	}
+	return nil
+}
+
+// assignElement 把驱动解码后的元素赋值给 dst，dst 须为指针
+// time.Time 直接赋值以保留其时区，其余的值先转为文本格式再由 scanElement 解析，整数越界时返回错误
+func assignElement(dst interface{}, src interface{}) error {
+	if s, ok := dst.(sql.Scanner); ok {
+		return s.Scan(src)
+	}
+	if d, ok := dst.(*time.Time); ok {
+		if t, ok := src.(time.Time); ok {
+			*d = t
+			return nil
+		}
+	}
+	s, err := formatElement(src)
+	if err != nil {
+		return err
+	}
+	return scanElement(dst, s)
+}
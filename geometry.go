@@ -0,0 +1,15 @@
+package pg
+
+import "github.com/blusewang/pg/v2/internal/codec"
+
+// 几何类型，均实现了 sql.Scanner、driver.Valuer 及二进制格式的编解码
+// 查询结果中的几何类型列直接解码为这些类型，数组列解码为对应的指针切片，如 []*pg.Point
+type (
+	Point   = codec.Point
+	Line    = codec.Line
+	Lseg    = codec.Lseg
+	Box     = codec.Box
+	Path    = codec.Path
+	Polygon = codec.Polygon
+	Circle  = codec.Circle
+)
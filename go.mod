@@ -1,3 +1,8 @@
 module github.com/blusewang/pg/v2
 
 go 1.20
+
+require (
+	golang.org/x/sys v0.15.0
+	golang.org/x/text v0.14.0
+)
@@ -0,0 +1,62 @@
+package pg
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"fmt"
+	"github.com/blusewang/pg/v2/internal/codec"
+)
+
+// Hstore 对应 hstore 扩展类型，值为 nil 表示 NULL；Hstore 本身为 nil 时表示 NULL
+// 连接建立后按 search_path 从 pg_type 查询 hstore 的 OID，之后 hstore 列以二进制格式接收并直接解码为 map[string]*string；
+// hstore 不在 search_path 中时，在 Parse 遇到该类型时才按名称识别；
+// 参数类型为 hstore 时，Hstore 及键为 string 的 map 均以二进制格式发送
+type Hstore map[string]*string
+
+func (h *Hstore) Scan(src interface{}) (err error) {
+	var m map[string]*string
+	switch v := src.(type) {
+	case nil:
+		*h = nil
+		return nil
+	case map[string]*string:
+		m = v
+	case string:
+		m, err = codec.ParseHstore([]byte(v))
+	case []byte:
+		m, err = codec.ParseHstore(v)
+	default:
+		return fmt.Errorf("pg: cannot scan %T into Hstore", src)
+	}
+	if err == nil {
+		*h = m
+	}
+	return
+}
+
+func (h Hstore) Value() (driver.Value, error) {
+	if h == nil {
+		return nil, nil
+	}
+	return string(codec.FormatHstore(h)), nil
+}
+
+// MarshalBinary 输出 hstore 的二进制格式
+func (h Hstore) MarshalBinary() ([]byte, error) {
+	return codec.FormatHstoreBinary(h), nil
+}
+
+// UnmarshalBinary 解析 hstore 的二进制格式
+func (h *Hstore) UnmarshalBinary(src []byte) error {
+	m, err := codec.ParseHstoreBinary(src)
+	if err == nil {
+		*h = m
+	}
+	return err
+}
+
+var _ sql.Scanner = new(Hstore)
+var _ driver.Valuer = new(Hstore)
+var _ encoding.BinaryMarshaler = new(Hstore)
+var _ encoding.BinaryUnmarshaler = new(Hstore)
@@ -0,0 +1,158 @@
+package app
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"github.com/blusewang/pg/v2/internal/client/frame"
+	"github.com/blusewang/pg/v2/internal/codec"
+	"reflect"
+	"strconv"
+)
+
+// array2Value 按元素类型逐个解码数组，多维数组解码为嵌套的切片
+// 任何元素都可能为 NULL，因此切片的元素类型总是指针，如 []*int64，与 ColumnTypeScanType 一致
+func (r *Rows) array2Value(raw []byte, elem frame.PgType) (interface{}, error) {
+	arr, err := codec.ParseArray(raw, frame.ArrayDelimiter(elem))
+	if err != nil {
+		return nil, err
+	}
+	var elemType = reflect.PtrTo(r.scanType(elem))
+	var values = make([]reflect.Value, len(arr.Elements))
+	for i, e := range arr.Elements {
+		values[i] = reflect.New(elemType).Elem()
+		if e == nil {
+			continue
+		}
+		v, err := r.data2Value(e, frame.Column{TypeOid: uint32(elem)})
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			// 注册的解码方法可能把非 NULL 的值解码为 nil，与单个值一样按 NULL 处理
+			continue
+		}
+		values[i].Set(reflect.New(elemType.Elem()))
+		var dst = values[i].Elem()
+		rv := reflect.ValueOf(v)
+		if !convertibleElement(rv.Type(), dst.Type()) {
+			return nil, fmt.Errorf("pg: cannot decode array element %T into %v", v, dst.Type())
+		}
+		dst.Set(rv.Convert(dst.Type()))
+	}
+
+	if len(arr.Dims) == 0 {
+		return reflect.MakeSlice(reflect.SliceOf(elemType), 0, 0).Interface(), nil
+	}
+	var sliceType = elemType
+	for range arr.Dims {
+		sliceType = reflect.SliceOf(sliceType)
+	}
+	var pos int
+	return buildArraySlice(sliceType, arr.Dims, values, &pos).Interface(), nil
+}
+
+// convertibleElement 与 reflect 的 ConvertibleTo 相同，但不允许整数转为字符串，以免 int32(65) 变成 "A"
+func convertibleElement(from, to reflect.Type) bool {
+	if to.Kind() == reflect.String && from.Kind() != reflect.String {
+		return false
+	}
+	return from.ConvertibleTo(to)
+}
+
+func buildArraySlice(typ reflect.Type, dims []codec.ArrayDimension, values []reflect.Value, pos *int) reflect.Value {
+	s := reflect.MakeSlice(typ, dims[0].Length, dims[0].Length)
+	for i := 0; i < dims[0].Length; i++ {
+		if len(dims) > 1 {
+			s.Index(i).Set(buildArraySlice(typ.Elem(), dims[1:], values, pos))
+		} else {
+			s.Index(i).Set(values[*pos])
+			*pos++
+		}
+	}
+	return s
+}
+
+// isArrayParam 切片及数组作为参数时按 PostgreSQL 数组传递，[]byte 与 json.RawMessage 除外
+func isArrayParam(t reflect.Type) bool {
+	if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+		return false
+	}
+	return t.Elem().Kind() != reflect.Uint8 && t != reflect.TypeOf(json.RawMessage(nil))
+}
+
+// array2Text 把切片（可多维）编码为数组的文本格式，元素按普通参数的规则转换，nil 指针为 NULL
+func (c Connect) array2Text(v reflect.Value) (string, error) {
+	var arr codec.Array
+	if err := c.arrayElements(v, 0, &arr); err != nil {
+		return "", err
+	}
+	// box 数组的元素以 ';' 分隔
+	var delim byte = ','
+	var elem = v.Type()
+	for isArrayParam(elem) || elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	if elem == reflect.TypeOf(codec.Box{}) {
+		delim = frame.ArrayDelimiter(frame.PgTypeBox)
+	}
+	return string(codec.FormatArray(arr, delim)), nil
+}
+
+func (c Connect) arrayElements(v reflect.Value, depth int, arr *codec.Array) error {
+	if depth == len(arr.Dims) {
+		arr.Dims = append(arr.Dims, codec.ArrayDimension{Length: v.Len(), LowerBound: 1})
+	} else if arr.Dims[depth].Length != v.Len() {
+		return fmt.Errorf("pg: multidimensional array must have sub-arrays with matching dimensions")
+	}
+	for i := 0; i < v.Len(); i++ {
+		e := v.Index(i)
+		if isArrayParam(e.Type()) {
+			if err := c.arrayElements(e, depth+1, arr); err != nil {
+				return err
+			}
+			continue
+		}
+		raw, err := c.arrayElement(e)
+		if err != nil {
+			return err
+		}
+		arr.Elements = append(arr.Elements, raw)
+	}
+	return nil
+}
+
+func (c Connect) arrayElement(e reflect.Value) ([]byte, error) {
+	// 非 Valuer 的指针先取值，以免被当作 NULL
+	for (e.Kind() == reflect.Ptr || e.Kind() == reflect.Interface) && !e.Type().Implements(valuerType) {
+		if e.IsNil() {
+			return nil, nil
+		}
+		e = e.Elem()
+	}
+	if e.Kind() == reflect.Ptr && e.IsNil() {
+		return nil, nil
+	}
+	var nv = driver.NamedValue{Value: e.Interface()}
+	if err := c.convertValue(&nv); err != nil {
+		return nil, err
+	}
+	switch v := nv.Value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []byte(v), nil
+	case int64:
+		return strconv.AppendInt(nil, v, 10), nil
+	case float64:
+		return strconv.AppendFloat(nil, v, 'f', -1, 64), nil
+	case bool:
+		return strconv.AppendBool(nil, v), nil
+	case []byte:
+		return v, nil
+	default:
+		return []byte(fmt.Sprintf("%v", v)), nil
+	}
+}
+
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
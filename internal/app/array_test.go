@@ -0,0 +1,69 @@
+package app
+
+import (
+	"github.com/blusewang/pg/v2/internal/client/frame"
+	"reflect"
+	"testing"
+)
+
+func TestArray2Value(t *testing.T) {
+	var r = new(Rows)
+	var one, two, three = int64(1), int64(2), int64(3)
+	var yes, no = true, false
+	var null, quoted, a = "NULL", `a"b\c`, "a"
+	var cases = []struct {
+		raw  string
+		elem frame.PgType
+		want interface{}
+	}{
+		{"{1,2,3}", frame.PgTypeInt4, []*int64{&one, &two, &three}},
+		{"{1,NULL,3}", frame.PgTypeInt8, []*int64{&one, nil, &three}},
+		{"{t,f,NULL}", frame.PgTypeBool, []*bool{&yes, &no, nil}},
+		{`{NULL,"NULL","a\"b\\c",a}`, frame.PgTypeText, []*string{nil, &null, &quoted, &a}},
+		{"{{1,2},{3,NULL}}", frame.PgTypeInt4, [][]*int64{{&one, &two}, {&three, nil}}},
+		{"[0:1]={1,2}", frame.PgTypeInt4, []*int64{&one, &two}},
+		{"{}", frame.PgTypeInt4, []*int64{}},
+	}
+	for _, c := range cases {
+		got, err := r.array2Value([]byte(c.raw), c.elem)
+		if err != nil {
+			t.Errorf("array2Value(%s): %v", c.raw, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("array2Value(%s) = %#v, want %#v", c.raw, got, c.want)
+		}
+	}
+
+	// 一维数组的类型与 ColumnTypeScanType 一致，与是否含 NULL 无关
+	for _, raw := range []string{"{1,2}", "{NULL,2}"} {
+		got, err := r.array2Value([]byte(raw), frame.PgTypeInt4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if typ := r.scanType(frame.PgTypeArrInt4); reflect.TypeOf(got) != typ {
+			t.Errorf("array2Value(%s) is %T, but scanType reports %v", raw, got, typ)
+		}
+	}
+}
+
+func TestConvertibleElement(t *testing.T) {
+	var cases = []struct {
+		from, to interface{}
+		ok       bool
+	}{
+		{int64(1), int64(0), true},
+		{int32(65), int64(0), true},
+		{float64(1), float64(0), true},
+		{int32(65), "", false},
+		{int64(65), "", false},
+		{uint8(65), "", false},
+		{"a", "", true},
+		{[]byte("a"), "", false},
+	}
+	for _, c := range cases {
+		if ok := convertibleElement(reflect.TypeOf(c.from), reflect.TypeOf(c.to)); ok != c.ok {
+			t.Errorf("convertibleElement(%T, %T) = %v, want %v", c.from, c.to, ok, c.ok)
+		}
+	}
+}
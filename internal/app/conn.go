@@ -9,23 +9,47 @@ import (
 	"errors"
 	"fmt"
 	"github.com/blusewang/pg/v2/internal/client"
+	"github.com/blusewang/pg/v2/internal/client/frame"
+	"github.com/blusewang/pg/v2/internal/codec"
+	"math"
+	"math/big"
+	"net"
+	"net/netip"
 	"reflect"
 	"strconv"
-	"strings"
 	"time"
 )
 
 func NewConnect(ctx context.Context, dsn client.DataSourceName) (c Connect, err error) {
+	c, err = newConnect(ctx, dsn, nil)
+	var oe *client.OAuthError
+	if errors.As(err, &oe) && oe.DiscoveryURI != "" {
+		// 令牌被拒绝时，带上服务器给出的 openid-configuration 及 scope 重新向令牌提供方获取一次
+		c, err = newConnect(ctx, dsn, oe)
+	}
+	return
+}
+
+func newConnect(ctx context.Context, dsn client.DataSourceName, hint *client.OAuthError) (c Connect, err error) {
 	c.client = client.NewClient()
+	c.client.OAuthHint = hint
+	// 只包装拨号与 TLS 握手的错误，服务器返回的 pg.Error 保持原样
 	if err = c.client.Connect(ctx, dsn); err != nil {
+		// Connect 失败时没有可关闭的连接
+		err = dsn.WrapConnectError(err)
+		return
+	}
+	if err = c.client.AutoSSL(ctx); err != nil {
 		_ = c.Close()
+		err = dsn.WrapConnectError(err)
 		return
 	}
-	if err = c.client.AutoSSL(); err != nil {
+	if err = c.client.Startup(ctx); err != nil {
 		_ = c.Close()
 		return
 	}
-	if err = c.client.Startup(); err != nil {
+	c.types = newConnTypes()
+	if err = c.types.loadExtensions(ctx, c.client); err != nil {
 		_ = c.Close()
 		return
 	}
@@ -36,6 +60,7 @@ func NewConnect(ctx context.Context, dsn client.DataSourceName) (c Connect, err
 type Connect struct {
 	client     *client.Client
 	statements map[string]*Statement
+	types      *connTypes
 }
 
 func (c Connect) IsValid() bool {
@@ -43,6 +68,9 @@ func (c Connect) IsValid() bool {
 }
 
 func (c Connect) ResetSession(_ context.Context) (err error) {
+	if !c.IsValid() {
+		return driver.ErrBadConn
+	}
 	return nil
 }
 
@@ -52,20 +80,35 @@ func (c Connect) query2Id(query string) string {
 }
 
 func (c Connect) parse(ctx context.Context, query string) (stmt *Statement, err error) {
-	select {
-	case <-ctx.Done():
-		return
-	default:
-		id := c.query2Id(query)
-		if c.statements[id] == nil {
-			res, err := c.client.Parse(id, query)
-			if err != nil {
-				return nil, err
+	id := c.query2Id(query)
+	if c.statements[id] == nil {
+		res, err := c.client.Parse(ctx, id, query)
+		if err != nil {
+			return nil, err
+		}
+		var oids []frame.PgType
+		if res.Parameters != nil {
+			for _, oid := range res.Parameters.TypeOIDs {
+				oids = append(oids, frame.PgType(oid))
+			}
+		}
+		if res.Rows != nil {
+			for _, col := range res.Rows.Columns {
+				oids = append(oids, frame.PgType(col.TypeOid))
+			}
+		}
+		if err = c.types.resolve(ctx, c.client, oids); err != nil {
+			// 服务器上已创建了该语句，不关闭的话再次 Parse 同名语句会报 already exists
+			if c.IsValid() {
+				closeCtx, cancel := c.client.BoundedContext()
+				_ = c.client.CloseParse(closeCtx, id)
+				cancel()
 			}
-			c.statements[id] = &Statement{cn: &c, Id: id, SQL: query, Response: res}
+			return nil, err
 		}
-		return c.statements[id], nil
+		c.statements[id] = &Statement{cn: &c, Id: id, SQL: query, Response: res}
 	}
+	return c.statements[id], nil
 }
 
 func (c Connect) Prepare(query string) (stmt driver.Stmt, err error) {
@@ -73,14 +116,7 @@ func (c Connect) Prepare(query string) (stmt driver.Stmt, err error) {
 }
 
 func (c Connect) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
-	for {
-		select {
-		case <-ctx.Done():
-			return nil, nil
-		default:
-			return c.parse(ctx, query)
-		}
-	}
+	return c.parse(ctx, query)
 }
 
 func (c Connect) Close() error {
@@ -92,12 +128,14 @@ func (c Connect) Begin() (tx driver.Tx, err error) {
 		err = errors.New("this connection is in transaction")
 		return
 	}
-	_, err = c.client.QueryNoArgs("begin")
+	ctx, cancel := c.client.BoundedContext()
+	defer cancel()
+	_, err = c.client.QueryNoArgs(ctx, "begin")
 	if err != nil {
 		return
 	}
 	if !c.client.IsInTransaction() {
-		err = errors.New("begin fail")
+		return nil, errors.New("begin fail")
 	}
 
 	return &Tx{client: c.client, ctx: context.Background()}, nil
@@ -108,7 +146,7 @@ func (c Connect) BeginTx(ctx context.Context, _ driver.TxOptions) (tx driver.Tx,
 		err = errors.New("this connection is in transaction")
 		return
 	}
-	_, err = c.client.QueryNoArgs("begin")
+	_, err = c.client.QueryNoArgs(ctx, "begin")
 	if err != nil {
 		return
 	}
@@ -119,126 +157,208 @@ func (c Connect) BeginTx(ctx context.Context, _ driver.TxOptions) (tx driver.Tx,
 	return &Tx{client: c.client, ctx: ctx}, nil
 }
 
+// CheckNamedValue 参数须知道其类型后才能确定编码方式，因此在 Bind 时才由 convertValue 转换；
+// 这里只提前拒绝任何参数类型下都无法转换的值，注册了 Encode 时交由其在 Bind 时判断
+// CheckNamedValue 提前拒绝无法转换的参数，Go 类型为注册了 Encode 的 ScanType 时除外
 func (c Connect) CheckNamedValue(nv *driver.NamedValue) error {
-	if nv.Value == nil {
+	var t = reflect.TypeOf(nv.Value)
+	if err := checkParam(t, 0); err != nil && !hasEncoderFor(t) {
+		return err
+	}
+	return nil
+}
+
+// checkParam 检查 convert 或以二进制格式发送的扩展类型能否处理该类型的参数
+func checkParam(t reflect.Type, depth int) error {
+	if t == nil || depth > 16 || t.Implements(valuerType) {
 		return nil
-	} else if fmt.Sprintf("%T", nv.Value)[0] == '*' {
-		nv.Value = nil
+	}
+	switch t {
+	case timeType, durationType, bigIntType, bigRatType, addrType, prefixType, ipNetType:
 		return nil
 	}
-	switch nv.Value.(type) {
-
-	// bool
-	case bool:
-		if nv.Value.(bool) {
-			nv.Value = "t"
-		} else {
-			nv.Value = "f"
-		}
-	case *bool:
-		if reflect.ValueOf(nv.Value).Elem().Bool() {
-			nv.Value = "t"
-		} else {
-			nv.Value = "f"
+	switch t.Kind() {
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return nil
+	case reflect.Ptr:
+		return checkParam(t.Elem(), depth+1)
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return nil
 		}
-	case []bool:
-		var as = fmt.Sprintf("%v", nv.Value)
-		nv.Value = "{" + strings.Replace(as[1:len(as)-1], " ", ",", -1) + "}"
-	case *[]bool:
-		var as = fmt.Sprintf("%v", nv.Value)
-		nv.Value = "{" + strings.Replace(as[2:len(as)-1], " ", ",", -1) + "}"
-
-	//	string
-	case string:
-	case *string:
-		nv.Value = reflect.ValueOf(nv.Value).Elem().String()
-	case []string:
-		if len(nv.Value.([]string)) > 0 {
-			var str = "{"
-			for _, s := range nv.Value.([]string) {
-				s = strings.Replace(s, `"`, `\"`, -1)
-				s = strings.Replace(s, `'`, `\'`, -1)
-				if strings.Contains(s, ",") {
-					str += `"` + s + `",`
-				} else {
-					str += s + ","
-				}
-			}
-			nv.Value = str[:len(str)-1] + "}"
-		} else {
-			nv.Value = "{}"
+		return checkParam(t.Elem(), depth+1)
+	case reflect.Map:
+		// hstore 参数接受键为 string、值为 string 或 *string 的 map
+		var elem = t.Elem()
+		if elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
 		}
-	case *[]string:
-		if nv.Value == nil {
+		if t.Key().Kind() == reflect.String && elem.Kind() == reflect.String {
 			return nil
 		}
-		if len(*nv.Value.(*[]string)) > 0 {
-			var str = "{"
-			for _, s := range *nv.Value.(*[]string) {
-				s = strings.Replace(s, `"`, `\"`, -1)
-				s = strings.Replace(s, `'`, `\'`, -1)
-				if strings.Contains(s, ",") {
-					str += `"` + s + `",`
-				} else {
-					str += s + ","
-				}
-			}
-			nv.Value = str[:len(str)-1] + "}"
-		} else {
-			nv.Value = "{}"
-		}
+	}
+	return unsupportedParam(t)
+}
 
-	//	int
-	case int, int8, int16, int32, int64, uint, uint8, uint16, uint64:
-		nv.Value, _ = strconv.ParseInt(fmt.Sprintf("%d", nv.Value), 0, 64)
-	case *int, *int8, *int16, *int32, *int64, *uint, *uint8, *uint16, *uint64:
-		nv.Value, _ = strconv.ParseInt(fmt.Sprintf("%d", reflect.ValueOf(nv.Value).Elem().Int()), 0, 64)
-	case []int, []int8, []int16, []int64, []uint, []uint16, []uint64:
-		var as = fmt.Sprintf("%v", nv.Value)
-		nv.Value = "{" + strings.Replace(as[1:len(as)-1], " ", ",", -1) + "}"
-	case *[]int, *[]int8, *[]int16, *[]int64, *[]uint, *[]uint16, *[]uint64:
-		var as = fmt.Sprintf("%v", nv.Value)
-		nv.Value = "{" + strings.Replace(as[2:len(as)-1], " ", ",", -1) + "}"
+func unsupportedParam(t reflect.Type) error {
+	if t.Kind() == reflect.Struct {
+		// 结构体不会被自动当作复合类型，须显式地由 pg.Composite 包装
+		return fmt.Errorf("pg: unsupported parameter type %v, wrap it with pg.Composite to pass it as a composite type", t)
+	}
+	return fmt.Errorf("pg: unsupported parameter type %v, implement driver.Valuer to convert it", t)
+}
 
-	//	float
-	case float32, float64:
-		nv.Value, _ = strconv.ParseFloat(fmt.Sprintf("%v", nv.Value), 64)
-	case *float32, *float64:
-		nv.Value, _ = strconv.ParseFloat(fmt.Sprintf("%v", reflect.ValueOf(nv.Value).Elem().Float()), 64)
-	case []float32, []float64:
-		raw, _ := json.Marshal(nv.Value)
-		var as = string(raw)
-		as = strings.ReplaceAll(as, "[", "{")
-		nv.Value = strings.ReplaceAll(as, "]", "}")
-	case *[]float32, *[]float64:
-		raw, _ := json.Marshal(nv.Value)
-		var as = string(raw)
-		as = strings.ReplaceAll(as, "[", "{")
-		nv.Value = strings.ReplaceAll(as, "]", "}")
-	case [][]float32, [][]float64:
-		raw, _ := json.Marshal(nv.Value)
-		var as = string(raw)
-		as = strings.ReplaceAll(as, "[", "{")
-		nv.Value = strings.ReplaceAll(as, "]", "}")
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	bigIntType   = reflect.TypeOf((*big.Int)(nil))
+	bigRatType   = reflect.TypeOf((*big.Rat)(nil))
+	addrType     = reflect.TypeOf(netip.Addr{})
+	prefixType   = reflect.TypeOf(netip.Prefix{})
+	ipNetType    = reflect.TypeOf(net.IPNet{})
+)
 
-	//	byte
-	case []byte:
-		nv.Value = fmt.Sprintf("\\x%x", nv.Value)
-	case *[]byte:
-		var as = fmt.Sprintf("\\x%x", nv.Value)
-		nv.Value = "\\x" + as[1:]
+// convertValue 把参数转为 nil、string、int64、float64 等可以直接发送的值
+func (c Connect) convertValue(nv *driver.NamedValue) (err error) {
+	nv.Value, err = c.convert(nv.Value, 0)
+	return
+}
 
-	case json.RawMessage:
-		nv.Value = string(nv.Value.(json.RawMessage))
+// convert 依次处理 driver.Valuer、特定类型、指针及各基础类型（含以其为底层类型的自定义类型），无法转换时返回错误
+// depth 用于避免 Value 方法互相返回对方而无限递归
+func (c Connect) convert(v interface{}, depth int) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if depth > 16 {
+		return nil, fmt.Errorf("pg: too many nested driver.Valuer or pointers for %T", v)
+	}
+	if vr, ok := v.(driver.Valuer); ok {
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return nil, nil
+		}
+		value, err := vr.Value()
+		if err != nil {
+			return nil, err
+		}
+		return c.convert(value, depth+1)
+	}
 
-	// time
+	switch x := v.(type) {
+	case string, int64, float64:
+		return x, nil
+	case bool:
+		if x {
+			return "t", nil
+		}
+		return "f", nil
+	case []byte:
+		if x == nil {
+			return nil, nil
+		}
+		return codec.FormatBytea(x), nil
+	case json.RawMessage:
+		if x == nil {
+			return nil, nil
+		}
+		return string(x), nil
 	case time.Time:
-	case *time.Time:
+		// timestamp、date 参数取的是墙上时间，先转到连接所约定的时区
+		loc, err := c.client.TimestampLocation()
+		if err != nil {
+			return nil, err
+		}
+		return codec.FormatTimestamp(x.In(loc)), nil
+	case time.Duration:
+		if x%time.Microsecond != 0 {
+			// interval 的精度为微秒，不静默地丢弃纳秒
+			return nil, fmt.Errorf("pg: duration %s is finer than the microsecond precision of interval, round it first", x)
+		}
+		return codec.FormatInterval(0, 0, x.Microseconds()), nil
+	case *big.Int:
+		if x == nil {
+			return nil, nil
+		}
+		return x.String(), nil
+	case *big.Rat:
+		if x == nil {
+			return nil, nil
+		}
+		i, scale, err := codec.RatToDecimal(x)
+		if err != nil {
+			return nil, err
+		}
+		return codec.FormatDecimal(i, scale), nil
+	case netip.Addr:
+		if !x.IsValid() {
+			return nil, nil
+		}
+		return x.String(), nil
+	case netip.Prefix:
+		if !x.IsValid() {
+			return nil, nil
+		}
+		return x.String(), nil
+	case net.IP:
+		if x == nil {
+			return nil, nil
+		}
+		return x.String(), nil
+	case net.IPNet:
+		return x.String(), nil
+	case *net.IPNet:
+		if x == nil {
+			return nil, nil
+		}
+		return x.String(), nil
+	case net.HardwareAddr:
+		if x == nil {
+			return nil, nil
+		}
+		return x.String(), nil
+	}
 
-	default:
-		nv.Value = fmt.Sprintf("%v", nv.Value)
+	var rv = reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return c.convert(rv.Elem().Interface(), depth+1)
+	case reflect.Bool:
+		return c.convert(rv.Bool(), depth)
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var u = rv.Uint()
+		if u > math.MaxInt64 {
+			// 超出 int64 的范围时以十进制文本发送，由服务器按参数类型判断是否越界
+			return strconv.FormatUint(u, 10), nil
+		}
+		return int64(u), nil
+	case reflect.Float32:
+		// 按 float32 的最短表示转换，避免 0.1 变成 0.10000000149011612
+		f, _ := strconv.ParseFloat(strconv.FormatFloat(rv.Float(), 'g', -1, 32), 64)
+		return f, nil
+	case reflect.Float64:
+		return rv.Float(), nil
+	case reflect.Slice, reflect.Array:
+		if isArrayParam(rv.Type()) {
+			return c.array2Text(rv)
+		}
+		// 元素为 uint8 的自定义类型及 [N]byte 按 bytea 发送
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return nil, nil
+		}
+		var b = make([]byte, rv.Len())
+		reflect.Copy(reflect.ValueOf(b), rv)
+		return codec.FormatBytea(b), nil
 	}
-	return nil
+	return nil, unsupportedParam(rv.Type())
 }
 
 func (c Connect) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (rows driver.Rows, err error) {
@@ -0,0 +1,247 @@
+package app
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/blusewang/pg/v2/internal/client"
+	"github.com/blusewang/pg/v2/internal/client/frame"
+	"io"
+	"math"
+	"math/big"
+	"net"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testStruct struct{ A int }
+
+type testValuer struct{}
+
+func (testValuer) Value() (driver.Value, error) { return "v", nil }
+
+func TestCheckNamedValue(t *testing.T) {
+	var c Connect
+	var n = 1
+	for _, v := range []interface{}{
+		nil, "s", 1, uint64(1), 1.5, true, []byte("b"), &n, (*int)(nil),
+		time.Now(), time.Second, big.NewInt(1), big.NewRat(1, 2), netip.MustParseAddr("::1"),
+		netip.MustParsePrefix("10.0.0.0/8"), net.ParseIP("::1"), &net.IPNet{}, net.HardwareAddr{1},
+		json.RawMessage("{}"), []int{1}, [][]string{{"a"}}, []float32{1}, [4]byte{}, []*int{&n},
+		map[string]*string{}, map[string]string{}, testValuer{}, []testValuer{},
+	} {
+		if err := c.CheckNamedValue(&driver.NamedValue{Value: v}); err != nil {
+			t.Errorf("CheckNamedValue(%T): %v", v, err)
+		}
+	}
+	for _, v := range []interface{}{
+		testStruct{}, &testStruct{}, []testStruct{}, map[string]int{}, map[int]string{}, make(chan int), func() {}, complex(1, 2),
+	} {
+		if err := c.CheckNamedValue(&driver.NamedValue{Value: v}); err == nil {
+			t.Errorf("CheckNamedValue(%T) should fail", v)
+		}
+	}
+}
+
+type testLoopValuer struct{}
+
+func (v testLoopValuer) Value() (driver.Value, error) { return v, nil }
+
+type testErrValuer struct{}
+
+func (testErrValuer) Value() (driver.Value, error) { return nil, errors.New("boom") }
+
+func TestConvert(t *testing.T) {
+	type myString string
+	type myInt int8
+	type myUint uint64
+	type myBytes []byte
+	var c = Connect{client: client.NewClient()}
+	var n = 7
+	var pn = &n
+	var s = "x"
+	var cases = []struct {
+		in   interface{}
+		want interface{}
+		err  string
+	}{
+		{in: nil, want: nil},
+		{in: "s", want: "s"},
+		{in: true, want: "t"},
+		{in: false, want: "f"},
+		{in: myString("m"), want: "m"},
+		{in: myInt(-3), want: int64(-3)},
+		{in: uint32(5), want: int64(5)},
+		{in: myUint(math.MaxInt64), want: int64(math.MaxInt64)},
+		{in: uint64(math.MaxUint64), want: "18446744073709551615"},
+		{in: float32(0.1), want: 0.1},
+		{in: 2.5, want: 2.5},
+		{in: []byte{0xde, 0xad}, want: `\xdead`},
+		{in: []byte{}, want: `\x`},
+		{in: []byte(nil), want: nil},
+		{in: myBytes(nil), want: nil},
+		{in: myBytes{1}, want: `\x01`},
+		{in: [2]byte{1, 2}, want: `\x0102`},
+		{in: json.RawMessage(nil), want: nil},
+		{in: json.RawMessage(`{"a":1}`), want: `{"a":1}`},
+		{in: &n, want: int64(7)},
+		{in: &pn, want: int64(7)},
+		{in: (*int)(nil), want: nil},
+		{in: &s, want: "x"},
+		{in: testValuer{}, want: "v"},
+		{in: (*testValuer)(nil), want: nil},
+		{in: time.Date(2024, 1, 2, 3, 4, 5, 6000, time.FixedZone("", 3600)), want: "2024-01-02 02:04:05.000006+00:00"},
+		{in: 90 * time.Minute, want: "PT1H30M"},
+		{in: 1500 * time.Nanosecond, err: "finer than the microsecond"},
+		{in: big.NewInt(-12), want: "-12"},
+		{in: (*big.Int)(nil), want: nil},
+		{in: big.NewRat(1, 8), want: "0.125"},
+		{in: big.NewRat(1, 3), err: "no finite decimal representation"},
+		{in: netip.Addr{}, want: nil},
+		{in: netip.MustParsePrefix("10.0.0.0/8"), want: "10.0.0.0/8"},
+		{in: net.IP(nil), want: nil},
+		{in: []int{1, 2}, want: "{1,2}"},
+		{in: []*int{&n, nil}, want: "{7,NULL}"},
+		{in: testStruct{}, err: "pg.Composite"},
+		{in: map[string]int{}, err: "unsupported parameter type"},
+		{in: testLoopValuer{}, err: "too many nested"},
+		{in: testErrValuer{}, err: "boom"},
+	}
+	for _, tc := range cases {
+		got, err := c.convert(tc.in, 0)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("convert(%T %v) = %v, %v, want an error containing %q", tc.in, tc.in, got, err, tc.err)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("convert(%T %v) = %#v, %v, want %#v", tc.in, tc.in, got, err, tc.want)
+		}
+	}
+}
+
+// fakeServer 在本地端口上模拟只处理一个连接的服务器，handle 在读取启动消息后调用
+func fakeServer(t *testing.T, handle func(cn net.Conn)) client.DataSourceName {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	go func() {
+		defer ln.Close()
+		cn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer cn.Close()
+		var head [4]byte
+		if _, err = io.ReadFull(cn, head[:]); err != nil {
+			return
+		}
+		if _, err = io.CopyN(io.Discard, cn, int64(binary.BigEndian.Uint32(head[:]))-4); err != nil {
+			return
+		}
+		handle(cn)
+	}()
+	dsn, err := client.ParseDSN(fmt.Sprintf("host=127.0.0.1 port=%d user=app password=pw sslmode=disable", ln.Addr().(*net.TCPAddr).Port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dsn
+}
+
+func writeMessage(cn net.Conn, typ byte, body []byte) error {
+	var msg = append([]byte{typ}, binary.BigEndian.AppendUint32(nil, uint32(len(body)+4))...)
+	_, err := cn.Write(append(msg, body...))
+	return err
+}
+
+func readMessage(cn net.Conn) (typ byte, body []byte, err error) {
+	var head [5]byte
+	if _, err = io.ReadFull(cn, head[:]); err != nil {
+		return
+	}
+	body = make([]byte, binary.BigEndian.Uint32(head[1:])-4)
+	_, err = io.ReadFull(cn, body)
+	return head[0], body, err
+}
+
+// serveQueries 认证通过后对每条简单查询返回 rows(query) 给出的各行
+func serveQueries(rows func(query string) [][]string) func(cn net.Conn) {
+	return func(cn net.Conn) {
+		if writeMessage(cn, 'R', make([]byte, 4)) != nil || writeMessage(cn, 'Z', []byte{'I'}) != nil {
+			return
+		}
+		for {
+			typ, body, err := readMessage(cn)
+			if err != nil || typ != 'Q' {
+				return
+			}
+			for _, row := range rows(strings.TrimSuffix(string(body), "\x00")) {
+				var d = binary.BigEndian.AppendUint16(nil, uint16(len(row)))
+				for _, col := range row {
+					d = binary.BigEndian.AppendUint32(d, uint32(len(col)))
+					d = append(d, col...)
+				}
+				if writeMessage(cn, 'D', d) != nil {
+					return
+				}
+			}
+			if writeMessage(cn, 'C', []byte("SELECT\x00")) != nil || writeMessage(cn, 'Z', []byte{'I'}) != nil {
+				return
+			}
+		}
+	}
+}
+
+func TestNewConnectErrors(t *testing.T) {
+	var dsn = fakeServer(t, func(cn net.Conn) {
+		_ = writeMessage(cn, 'E', []byte("SFATAL\x00VFATAL\x00C28P01\x00Mpassword authentication failed\x00\x00"))
+	})
+	_, err := NewConnect(context.Background(), dsn)
+	var pe frame.PgError
+	var ce *client.ConnectError
+	if !errors.As(err, &pe) || pe.Code != "28P01" || errors.As(err, &ce) {
+		t.Fatalf("expected the server error as is, got %T %v", err, err)
+	}
+
+	// 拨号失败时包装为 *ConnectError
+	_, err = NewConnect(context.Background(), dsn)
+	if !errors.As(err, &ce) || !strings.Contains(ce.Target, "host=127.0.0.1") {
+		t.Fatalf("expected a *ConnectError, got %T %v", err, err)
+	}
+}
+
+func TestNewConnectLoadsExtensions(t *testing.T) {
+	var queries = make(chan string, 1)
+	var dsn = fakeServer(t, serveQueries(func(query string) [][]string {
+		queries <- query
+		return [][]string{{"16385", "hstore", "16390"}, {"16400", "vector", "16405"}, {"16410", "halfvec", "16415"}, {"16420", "sparsevec", "16425"}}
+	}))
+	c, err := NewConnect(context.Background(), dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if q := <-queries; !strings.Contains(q, "to_regtype('hstore')") || !strings.Contains(q, "to_regtype('sparsevec')") {
+		t.Errorf("unexpected query %q", q)
+	}
+	if !c.types.isHstore(16385) || !c.types.binaryResult(16385) {
+		t.Error("hstore should be known right after connecting")
+	}
+	if elem, ok := c.types.elemType(16390); !ok || elem != 16385 || c.types.typeName(16390) != "PgTypeArrHstore" {
+		t.Errorf("elemType(16390) = %d, %v, %q", elem, ok, c.types.typeName(16390))
+	}
+	if !c.types.isVector(16400) || !c.types.isVector(16410) || !c.types.isVector(16420) || c.types.typeName(16425) != "PgTypeArrSparsevec" {
+		t.Error("pgvector types should be known right after connecting")
+	}
+	if !c.types.known(16385) || !c.types.known(16390) {
+		t.Error("extension types should not be queried again at Parse")
+	}
+}
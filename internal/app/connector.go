@@ -11,6 +11,35 @@ type Connector struct {
 	driver Driver
 }
 
+// NewConnector 解析连接串并创建 Connector
+func NewConnector(name string) (Connector, error) {
+	dsn, err := client.ParseDSN(name)
+	return Connector{dsn: dsn}, err
+}
+
+// NewStrictConnector 同 NewConnector，但连接串中有 libpq 不认识的参数时返回错误
+func NewStrictConnector(name string) (Connector, error) {
+	dsn, err := client.ParseDSNStrict(name)
+	return Connector{dsn: dsn}, err
+}
+
+// SetOAuthTokenProvider 设置 OAUTHBEARER 认证所用的令牌提供方
+func (c *Connector) SetOAuthTokenProvider(p client.OAuthTokenProvider) {
+	c.dsn.OAuth.TokenProvider = p
+}
+
+// SetClientCertificatePEM 使用内存中的 PEM 作为客户端证书及私钥，优先于 sslcert、sslkey
+// 私钥加密时用 sslpassword 解密
+func (c *Connector) SetClientCertificatePEM(cert, key []byte) {
+	c.dsn.SSL.CertPEM = cert
+	c.dsn.SSL.KeyPEM = key
+}
+
+// SetRootCertificatePEM 使用内存中的 PEM 作为根证书，优先于 sslrootcert
+func (c *Connector) SetRootCertificatePEM(ca []byte) {
+	c.dsn.SSL.RootCertPEM = ca
+}
+
 func (c Connector) Connect(ctx context.Context) (conn driver.Conn, err error) {
 	return NewConnect(ctx, c.dsn)
 }
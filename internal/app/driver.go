@@ -1,7 +1,6 @@
 package app
 
 import (
-	"context"
 	"database/sql/driver"
 	"github.com/blusewang/pg/v2/internal/client"
 )
@@ -14,7 +13,9 @@ func (d Driver) Open(name string) (c driver.Conn, err error) {
 	if err != nil {
 		return
 	}
-	return NewConnect(context.Background(), dsn)
+	ctx, cancel := dsn.BoundedContext()
+	defer cancel()
+	return NewConnect(ctx, dsn)
 }
 
 func (d Driver) OpenConnector(name string) (driver.Connector, error) {
@@ -0,0 +1,41 @@
+package app
+
+import (
+	"database/sql"
+	"encoding"
+	"github.com/blusewang/pg/v2/internal/client/frame"
+	"github.com/blusewang/pg/v2/internal/codec"
+	"reflect"
+)
+
+// geometry 几何类型均可由文本格式 Scan，也可由二进制格式 UnmarshalBinary
+type geometry interface {
+	sql.Scanner
+	encoding.BinaryUnmarshaler
+}
+
+// geometryTypes 内置几何类型，这些类型以二进制格式接收
+var geometryTypes = map[frame.PgType]func() geometry{
+	frame.PgTypePoint:   func() geometry { return new(codec.Point) },
+	frame.PgTypeLine:    func() geometry { return new(codec.Line) },
+	frame.PgTypeLseg:    func() geometry { return new(codec.Lseg) },
+	frame.PgTypeBox:     func() geometry { return new(codec.Box) },
+	frame.PgTypePath:    func() geometry { return new(codec.Path) },
+	frame.PgTypePolygon: func() geometry { return new(codec.Polygon) },
+	frame.PgTypeCircle:  func() geometry { return new(codec.Circle) },
+}
+
+// geometry2Value 解码几何类型，结果为 codec.Point 等值类型
+func geometry2Value(raw []byte, newValue func() geometry, binaryFormat bool) (interface{}, error) {
+	var g = newValue()
+	var err error
+	if binaryFormat {
+		err = g.UnmarshalBinary(raw)
+	} else {
+		err = g.Scan(raw)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return reflect.ValueOf(g).Elem().Interface(), nil
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"github.com/blusewang/pg/v2/internal/client/frame"
+	"github.com/blusewang/pg/v2/internal/codec"
+	"reflect"
+	"testing"
+)
+
+func TestGeometry2Value(t *testing.T) {
+	var cases = []struct {
+		oid  frame.PgType
+		text string
+		want interface{}
+	}{
+		{frame.PgTypePoint, "(1,2)", codec.Point{X: 1, Y: 2}},
+		{frame.PgTypeLine, "{1,-1,0}", codec.Line{A: 1, B: -1}},
+		{frame.PgTypeLseg, "[(0,0),(1,1)]", codec.Lseg{P2: codec.Point{X: 1, Y: 1}}},
+		{frame.PgTypeBox, "(1,1),(0,0)", codec.Box{High: codec.Point{X: 1, Y: 1}}},
+		{frame.PgTypePath, "[(0,0),(1,1)]", codec.Path{Points: []codec.Point{{}, {X: 1, Y: 1}}}},
+		{frame.PgTypePolygon, "((0,0),(1,1),(1,0))", codec.Polygon{Points: []codec.Point{{}, {X: 1, Y: 1}, {X: 1}}}},
+		{frame.PgTypeCircle, "<(1,2),3>", codec.Circle{Center: codec.Point{X: 1, Y: 2}, Radius: 3}},
+	}
+	var r = new(Rows)
+	for _, c := range cases {
+		got, err := r.data2Value([]byte(c.text), frame.Column{TypeOid: uint32(c.oid)})
+		if err != nil || !reflect.DeepEqual(got, c.want) {
+			t.Errorf("text %s = %#v, %v, want %#v", c.text, got, err, c.want)
+		}
+		if typ := r.scanType(c.oid); typ != reflect.TypeOf(c.want) {
+			t.Errorf("scanType(%d) = %v, want %T", c.oid, typ, c.want)
+		}
+		if !r.types.binaryResult(c.oid) {
+			t.Errorf("type %d should be received in binary format", c.oid)
+		}
+
+		raw, err := c.want.(interface{ MarshalBinary() ([]byte, error) }).MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err = r.data2Value(raw, frame.Column{TypeOid: uint32(c.oid), Format: 1})
+		if err != nil || !reflect.DeepEqual(got, c.want) {
+			t.Errorf("binary %s = %#v, %v, want %#v", c.text, got, err, c.want)
+		}
+	}
+}
+
+func TestBoxArrayValue(t *testing.T) {
+	var r = new(Rows)
+	var box1, box2 = codec.Box{High: codec.Point{X: 1, Y: 1}}, codec.Box{High: codec.Point{X: 3, Y: 3}, Low: codec.Point{X: 2, Y: 2}}
+	got, err := r.data2Value([]byte("{(1,1),(0,0);(3,3),(2,2)}"), frame.Column{TypeOid: uint32(frame.PgTypeArrBox)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []*codec.Box{&box1, &box2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("box[] = %#v, want %#v", got, want)
+	}
+
+	text, err := Connect{}.array2Text(reflect.ValueOf([]codec.Box{box1, box2}))
+	if err != nil || text != "{(1,1),(0,0);(3,3),(2,2)}" {
+		t.Errorf("array2Text([]Box) = %s, %v", text, err)
+	}
+	text, err = Connect{}.array2Text(reflect.ValueOf([]codec.Point{{X: 1, Y: 2}, {X: 3, Y: 4}}))
+	if err != nil || text != `{"(1,2)","(3,4)"}` {
+		t.Errorf("array2Text([]Point) = %s, %v", text, err)
+	}
+}
@@ -0,0 +1,43 @@
+package app
+
+import (
+	"github.com/blusewang/pg/v2/internal/codec"
+	"reflect"
+)
+
+// hstore2Raw 把 hstore 参数编码为二进制格式，raw 为 nil 表示 NULL
+// 接受键为 string、值为 string 或 *string 的 map（如 pg.Hstore），其余类型返回 ok 为 false，按普通参数转换
+func hstore2Raw(v interface{}) (raw []byte, ok bool) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, true
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+	var elem = rv.Type().Elem()
+	var pointer = elem.Kind() == reflect.Ptr && elem.Elem().Kind() == reflect.String
+	if !pointer && elem.Kind() != reflect.String {
+		return nil, false
+	}
+	if rv.IsNil() {
+		return nil, true
+	}
+	var m = make(map[string]*string, rv.Len())
+	for it := rv.MapRange(); it.Next(); {
+		value := it.Value()
+		if pointer {
+			if value.IsNil() {
+				m[it.Key().String()] = nil
+				continue
+			}
+			value = value.Elem()
+		}
+		s := value.String()
+		m[it.Key().String()] = &s
+	}
+	return codec.FormatHstoreBinary(m), true
+}
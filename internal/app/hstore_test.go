@@ -0,0 +1,60 @@
+package app
+
+import (
+	"github.com/blusewang/pg/v2/internal/client/frame"
+	"github.com/blusewang/pg/v2/internal/codec"
+	"reflect"
+	"testing"
+)
+
+func TestHstore2Raw(t *testing.T) {
+	type named map[string]*string
+	var one = "1"
+	var want = codec.FormatHstoreBinary(map[string]*string{"a": &one, "b": nil})
+	for _, v := range []interface{}{named{"a": &one, "b": nil}, &map[string]*string{"a": &one, "b": nil}} {
+		if raw, ok := hstore2Raw(v); !ok || !reflect.DeepEqual(raw, want) {
+			t.Errorf("hstore2Raw(%T) = %v, %v", v, raw, ok)
+		}
+	}
+	if raw, ok := hstore2Raw(map[string]string{"a": "1"}); !ok || !reflect.DeepEqual(raw, codec.FormatHstoreBinary(map[string]*string{"a": &one})) {
+		t.Errorf("hstore2Raw(map[string]string) = %v, %v", raw, ok)
+	}
+	for _, v := range []interface{}{named(nil), (*named)(nil)} {
+		if raw, ok := hstore2Raw(v); !ok || raw != nil {
+			t.Errorf("hstore2Raw(%#v) = %v, %v, want NULL", v, raw, ok)
+		}
+	}
+	for _, v := range []interface{}{`"a"=>"1"`, map[string]int{"a": 1}, map[int]string{1: "a"}} {
+		if _, ok := hstore2Raw(v); ok {
+			t.Errorf("hstore2Raw(%T) should not be handled", v)
+		}
+	}
+}
+
+func TestHstore2Value(t *testing.T) {
+	var types = newConnTypes()
+	types.setExtension(16400, "hstore")
+	types.arrays[16405] = 16400
+	if !types.binaryResult(16400) || types.binaryResult(16405) {
+		t.Error("hstore should be received in binary format, its array in text")
+	}
+	if types.typeName(16400) != "PgTypeHstore" || types.typeName(16405) != "PgTypeArrHstore" {
+		t.Errorf("type names = %s, %s", types.typeName(16400), types.typeName(16405))
+	}
+
+	var r = &Rows{types: types}
+	var one = "1"
+	var want = map[string]*string{"a": &one, "b": nil}
+	got, err := r.data2Value(codec.FormatHstoreBinary(want), frame.Column{TypeOid: 16400, Format: 1})
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("binary hstore = %v, %v", got, err)
+	}
+	got, err = r.data2Value([]byte(`"a"=>"1", "b"=>NULL`), frame.Column{TypeOid: 16400})
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("text hstore = %v, %v", got, err)
+	}
+	got, err = r.data2Value([]byte(`{"\"a\"=>\"1\", \"b\"=>NULL",NULL}`), frame.Column{TypeOid: 16405})
+	if err != nil || !reflect.DeepEqual(got, []*map[string]*string{&want, nil}) {
+		t.Errorf("hstore array = %v, %v", got, err)
+	}
+}
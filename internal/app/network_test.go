@@ -0,0 +1,34 @@
+package app
+
+import (
+	"github.com/blusewang/pg/v2/internal/client/frame"
+	"github.com/blusewang/pg/v2/internal/codec"
+	"net/netip"
+	"testing"
+)
+
+func TestInet2Value(t *testing.T) {
+	var r = new(Rows)
+	for _, oid := range []frame.PgType{frame.PgTypeInet, frame.PgTypeCidr, frame.PgTypeMacaddr, frame.PgTypeMacaddr8} {
+		if !r.types.binaryResult(oid) {
+			t.Errorf("type %d should be received in binary format", oid)
+		}
+	}
+	for _, s := range []string{"192.168.1.5/24", "10.0.0.1/32", "2001:db8::/32"} {
+		var p = netip.MustParsePrefix(s)
+		for _, oid := range []frame.PgType{frame.PgTypeInet, frame.PgTypeCidr} {
+			got, err := r.data2Value(codec.FormatInetBinary(p, oid == frame.PgTypeCidr), frame.Column{TypeOid: uint32(oid), Format: 1})
+			if err != nil || got != p {
+				t.Errorf("binary %s = %v, %v", s, got, err)
+			}
+			got, err = r.data2Value([]byte(s), frame.Column{TypeOid: uint32(oid)})
+			if err != nil || got != p {
+				t.Errorf("text %s = %v, %v", s, got, err)
+			}
+		}
+	}
+	got, err := r.data2Value([]byte{8, 0, 0x2b, 1, 2, 3}, frame.Column{TypeOid: uint32(frame.PgTypeMacaddr), Format: 1})
+	if err != nil || got.(interface{ String() string }).String() != "08:00:2b:01:02:03" {
+		t.Errorf("binary macaddr = %v, %v", got, err)
+	}
+}
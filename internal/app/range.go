@@ -0,0 +1,125 @@
+package app
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/blusewang/pg/v2/internal/client/frame"
+	"github.com/blusewang/pg/v2/internal/codec"
+	"time"
+)
+
+// rangeSubtypes 内置范围类型的子类型，这些类型以二进制格式接收
+var rangeSubtypes = map[frame.PgType]frame.PgType{
+	frame.PgTypeInt4range: frame.PgTypeInt4,
+	frame.PgTypeInt8range: frame.PgTypeInt8,
+	frame.PgTypeNumrange:  frame.PgTypeNumeric,
+	frame.PgTypeTsrange:   frame.PgTypeTimestamp,
+	frame.PgTypeTstzrange: frame.PgTypeTimestamptz,
+	frame.PgTypeDaterange: frame.PgTypeDate,
+}
+
+// multirangeRanges 内置多范围类型对应的范围类型
+var multirangeRanges = map[frame.PgType]frame.PgType{
+	frame.PgTypeInt4multirange: frame.PgTypeInt4range,
+	frame.PgTypeInt8multirange: frame.PgTypeInt8range,
+	frame.PgTypeNummultirange:  frame.PgTypeNumrange,
+	frame.PgTypeTsmultirange:   frame.PgTypeTsrange,
+	frame.PgTypeTstzmultirange: frame.PgTypeTstzrange,
+	frame.PgTypeDatemultirange: frame.PgTypeDaterange,
+}
+
+// range2Value 解码范围，上下界按子类型解码，结果为 codec.RangeValue
+func (r *Rows) range2Value(raw []byte, subtype frame.PgType, binaryFormat bool) (interface{}, error) {
+	var cr codec.Range
+	var err error
+	if binaryFormat {
+		cr, err = codec.ParseRangeBinary(raw)
+	} else {
+		cr, err = codec.ParseRange(raw)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.rangeValue(cr, subtype, binaryFormat)
+}
+
+// multirange2Value 解码多范围，结果为 []codec.RangeValue
+func (r *Rows) multirange2Value(raw []byte, subtype frame.PgType, binaryFormat bool) (interface{}, error) {
+	var ranges []codec.Range
+	var err error
+	if binaryFormat {
+		ranges, err = codec.ParseMultirangeBinary(raw)
+	} else {
+		ranges, err = codec.ParseMultirange(raw)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var values = make([]codec.RangeValue, len(ranges))
+	for i, cr := range ranges {
+		if values[i], err = r.rangeValue(cr, subtype, binaryFormat); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+func (r *Rows) rangeValue(cr codec.Range, subtype frame.PgType, binaryFormat bool) (v codec.RangeValue, err error) {
+	v = codec.RangeValue{LowerInclusive: cr.LowerInclusive, UpperInclusive: cr.UpperInclusive, Empty: cr.Empty}
+	var decode = func(bound []byte) (interface{}, error) {
+		if binaryFormat {
+			return r.binary2Value(bound, subtype)
+		}
+		return r.data2Value(bound, frame.Column{TypeOid: uint32(subtype)})
+	}
+	if cr.Lower != nil {
+		if v.Lower, err = decode(cr.Lower); err != nil {
+			return
+		}
+	}
+	if cr.Upper != nil {
+		v.Upper, err = decode(cr.Upper)
+	}
+	return
+}
+
+// binary2Value 解码范围子类型的二进制格式，结果与文本格式经 data2Value 解码后的类型相同
+func (r *Rows) binary2Value(raw []byte, oid frame.PgType) (interface{}, error) {
+	switch oid {
+	case frame.PgTypeInt4:
+		if len(raw) != 4 {
+			return nil, fmt.Errorf("pg: invalid binary int4 length %d", len(raw))
+		}
+		return int64(int32(binary.BigEndian.Uint32(raw))), nil
+	case frame.PgTypeInt8:
+		if len(raw) != 8 {
+			return nil, fmt.Errorf("pg: invalid binary int8 length %d", len(raw))
+		}
+		return int64(binary.BigEndian.Uint64(raw)), nil
+	case frame.PgTypeNumeric:
+		return codec.ParseNumericBinary(raw)
+	case frame.PgTypeTimestamptz:
+		t, err := codec.ParseTimestampBinary(raw)
+		if err == nil && r.location != nil {
+			t = t.In(r.location)
+		}
+		return t, err
+	case frame.PgTypeTimestamp, frame.PgTypeDate:
+		var t time.Time
+		var err error
+		if oid == frame.PgTypeDate {
+			t, err = codec.ParseDateBinary(raw)
+		} else {
+			t, err = codec.ParseTimestampBinary(raw)
+		}
+		if err != nil || t.Equal(codec.PositiveInfinity) || t.Equal(codec.NegativeInfinity) {
+			return t, err
+		}
+		if r.tsLocation == nil {
+			return nil, r.tsErr
+		}
+		// 二进制格式是 UTC 下的墙上时间，按 timestamp_location 重新解释
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), r.tsLocation), nil
+	}
+	return nil, fmt.Errorf("pg: binary format of type %d is not supported", oid)
+}
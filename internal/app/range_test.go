@@ -0,0 +1,134 @@
+package app
+
+import (
+	"github.com/blusewang/pg/v2/internal/client/frame"
+	"github.com/blusewang/pg/v2/internal/codec"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRange2Value(t *testing.T) {
+	var shanghai = time.FixedZone("CST", 8*3600)
+	var r = &Rows{location: time.UTC, tsLocation: shanghai}
+	var day = func(d int) time.Time { return time.Date(2024, time.January, d, 0, 0, 0, 0, shanghai) }
+
+	var cases = []struct {
+		name    string
+		raw     []byte
+		subtype frame.PgType
+		binary  bool
+		want    codec.RangeValue
+	}{
+		{
+			name: "int4range text", raw: []byte("[1,10)"), subtype: frame.PgTypeInt4,
+			want: codec.RangeValue{Lower: int64(1), Upper: int64(10), LowerInclusive: true},
+		},
+		{
+			name: "int8range binary", subtype: frame.PgTypeInt8, binary: true,
+			raw:  codec.FormatRangeBinary(codec.Range{Lower: []byte{0, 0, 0, 0, 0, 0, 0, 1}, LowerInclusive: true}),
+			want: codec.RangeValue{Lower: int64(1), LowerInclusive: true},
+		},
+		{
+			name: "numrange binary", subtype: frame.PgTypeNumeric, binary: true,
+			raw:  codec.FormatRangeBinary(codec.Range{Lower: codec.FormatNumericBinary(bigInt(125), 2), Upper: codec.FormatNumericSpecialBinary(1)}),
+			want: codec.RangeValue{Lower: "1.25", Upper: "Infinity"},
+		},
+		{
+			name: "daterange text", raw: []byte("[2024-01-02,2024-01-05)"), subtype: frame.PgTypeDate,
+			want: codec.RangeValue{Lower: day(2), Upper: day(5), LowerInclusive: true},
+		},
+		{
+			name: "daterange binary", subtype: frame.PgTypeDate, binary: true,
+			raw:  codec.FormatRangeBinary(codec.Range{Lower: codec.FormatDateBinary(day(2)), Upper: codec.FormatDateBinary(day(5)), LowerInclusive: true}),
+			want: codec.RangeValue{Lower: day(2), Upper: day(5), LowerInclusive: true},
+		},
+		{
+			name: "tsrange text", raw: []byte(`["2024-01-02 08:30:00",infinity)`), subtype: frame.PgTypeTimestamp,
+			want: codec.RangeValue{Lower: time.Date(2024, 1, 2, 8, 30, 0, 0, shanghai), Upper: codec.PositiveInfinity, LowerInclusive: true},
+		},
+		{
+			name: "tsrange binary", subtype: frame.PgTypeTimestamp, binary: true,
+			raw:  codec.FormatRangeBinary(codec.Range{Lower: codec.FormatTimestampBinary(time.Date(2024, 1, 2, 8, 30, 0, 0, time.UTC)), LowerInclusive: true}),
+			want: codec.RangeValue{Lower: time.Date(2024, 1, 2, 8, 30, 0, 0, shanghai), LowerInclusive: true},
+		},
+		{
+			name: "tstzrange binary", subtype: frame.PgTypeTimestamptz, binary: true,
+			raw:  codec.FormatRangeBinary(codec.Range{Lower: codec.FormatTimestampBinary(time.Date(2024, 1, 2, 8, 30, 0, 0, shanghai)), LowerInclusive: true}),
+			want: codec.RangeValue{Lower: time.Date(2024, 1, 2, 0, 30, 0, 0, time.UTC), LowerInclusive: true},
+		},
+		{
+			name: "empty binary", subtype: frame.PgTypeInt4, binary: true,
+			raw:  codec.FormatRangeBinary(codec.Range{Empty: true}),
+			want: codec.RangeValue{Empty: true},
+		},
+	}
+	for _, c := range cases {
+		got, err := r.range2Value(c.raw, c.subtype, c.binary)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if !rangeValueEqual(got.(codec.RangeValue), c.want) {
+			t.Errorf("%s = %+v, want %+v", c.name, got, c.want)
+		}
+	}
+
+	// date 的二进制格式只有 4 字节，不能当作 timestamp 解码
+	if _, err := r.range2Value(codec.FormatRangeBinary(codec.Range{Lower: codec.FormatDateBinary(day(2))}), frame.PgTypeTimestamp, true); err == nil {
+		t.Error("a 4-byte bound should not decode as timestamp")
+	}
+}
+
+func TestMultirange2Value(t *testing.T) {
+	var r = &Rows{location: time.UTC, tsLocation: time.UTC}
+	var ranges = []codec.Range{
+		{Lower: []byte{0, 0, 0, 1}, Upper: []byte{0, 0, 0, 3}, LowerInclusive: true},
+		{Lower: []byte{0, 0, 0, 5}, Upper: []byte{0, 0, 0, 7}, LowerInclusive: true},
+	}
+	var want = []codec.RangeValue{
+		{Lower: int64(1), Upper: int64(3), LowerInclusive: true},
+		{Lower: int64(5), Upper: int64(7), LowerInclusive: true},
+	}
+	for _, binary := range []bool{true, false} {
+		var raw = []byte("{[1,3),[5,7)}")
+		if binary {
+			raw = codec.FormatMultirangeBinary(ranges)
+		}
+		got, err := r.multirange2Value(raw, frame.PgTypeInt4, binary)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("multirange2Value(binary=%v) = %+v, want %+v", binary, got, want)
+		}
+	}
+}
+
+func TestRangeBinaryResult(t *testing.T) {
+	var types *connTypes
+	for _, oid := range []frame.PgType{frame.PgTypeInt4range, frame.PgTypeDaterange, frame.PgTypeTstzmultirange} {
+		if !types.binaryResult(oid) {
+			t.Errorf("range type %d should be received in binary format", oid)
+		}
+	}
+	if types.binaryResult(frame.PgTypeArrInt4range) {
+		t.Error("arrays of ranges are received in text format")
+	}
+}
+
+func rangeValueEqual(a, b codec.RangeValue) bool {
+	var eq = func(x, y interface{}) bool {
+		if tx, ok := x.(time.Time); ok {
+			ty, ok := y.(time.Time)
+			return ok && tx.Equal(ty) && tx.Location().String() == ty.Location().String()
+		}
+		return reflect.DeepEqual(x, y)
+	}
+	return eq(a.Lower, b.Lower) && eq(a.Upper, b.Upper) && a.LowerInclusive == b.LowerInclusive && a.UpperInclusive == b.UpperInclusive && a.Empty == b.Empty
+}
+
+func bigInt(n int64) *big.Int {
+	return big.NewInt(n)
+}
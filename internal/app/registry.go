@@ -0,0 +1,63 @@
+package app
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// TypeCodec 应用为非内置类型注册的编解码方法，各字段均可为空
+type TypeCodec struct {
+	// ScanType 解码后的 Go 类型，用于 ColumnTypeScanType 及数组的元素类型；为空时为 interface{}
+	ScanType reflect.Type
+	// DecodeText 解码文本格式的值；为空时按字符串返回
+	DecodeText func(src []byte) (interface{}, error)
+	// DecodeBinary 解码二进制格式的值；不为空时该类型的列以二进制格式接收
+	DecodeBinary func(src []byte) (interface{}, error)
+	// Encode 把参数编码为文本格式，返回 nil 表示 NULL；返回 driver.ErrSkip 时按普通参数的规则转换
+	// 参数的 Go 类型不是驱动本身支持的类型时，需把 ScanType 设为该类型，或先 Prepare 语句，否则会被 CheckNamedValue 拒绝
+	Encode func(v interface{}) ([]byte, error)
+}
+
+var registry = struct {
+	sync.RWMutex
+	codecs map[string]TypeCodec
+}{codecs: make(map[string]TypeCodec)}
+
+// RegisterType 为类型名注册编解码方法，name 与 pg_type.typname 一致，区分大小写，可以带模式名，如 public.mood
+// 同名类型存在于多个模式时，带模式名的注册优先；对已建立的连接同样生效
+func RegisterType(name string, tc TypeCodec) error {
+	if name == "" {
+		return errors.New("pg: type name must not be empty")
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	registry.codecs[name] = tc
+	return nil
+}
+
+// lookupType 按模式名及类型名查找已注册的编解码方法
+func lookupType(schema, name string) (tc TypeCodec, ok bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	if len(registry.codecs) == 0 {
+		return
+	}
+	if tc, ok = registry.codecs[schema+"."+name]; ok {
+		return
+	}
+	tc, ok = registry.codecs[name]
+	return
+}
+
+// hasEncoderFor 是否有注册了 Encode 且 ScanType 为 t 或 *t 的类型
+func hasEncoderFor(t reflect.Type) bool {
+	registry.RLock()
+	defer registry.RUnlock()
+	for _, tc := range registry.codecs {
+		if tc.Encode != nil && tc.ScanType != nil && (t == tc.ScanType || t == reflect.PtrTo(tc.ScanType)) {
+			return true
+		}
+	}
+	return false
+}
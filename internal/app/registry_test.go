@@ -0,0 +1,137 @@
+package app
+
+import (
+	"database/sql/driver"
+	"github.com/blusewang/pg/v2/internal/client"
+	"github.com/blusewang/pg/v2/internal/client/frame"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func registerForTest(t *testing.T, name string, tc TypeCodec) {
+	if err := RegisterType(name, tc); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		registry.Lock()
+		delete(registry.codecs, name)
+		registry.Unlock()
+	})
+}
+
+func TestRegisterType(t *testing.T) {
+	if err := RegisterType("", TypeCodec{}); err == nil {
+		t.Error("an empty name should fail")
+	}
+	registerForTest(t, "mood", TypeCodec{ScanType: reflect.TypeOf("")})
+	registerForTest(t, "audit.mood", TypeCodec{ScanType: reflect.TypeOf(0)})
+
+	if tc, ok := lookupType("public", "mood"); !ok || tc.ScanType != reflect.TypeOf("") {
+		t.Errorf("lookupType(public, mood) = %v, %v", tc, ok)
+	}
+	// 带模式名的注册优先
+	if tc, ok := lookupType("audit", "mood"); !ok || tc.ScanType != reflect.TypeOf(0) {
+		t.Errorf("lookupType(audit, mood) = %v, %v", tc, ok)
+	}
+	if _, ok := lookupType("public", "Mood"); ok {
+		t.Error("type names are case sensitive")
+	}
+}
+
+func TestRegisteredCodec(t *testing.T) {
+	registerForTest(t, "mood", TypeCodec{
+		ScanType: reflect.TypeOf(""),
+		DecodeText: func(src []byte) (interface{}, error) {
+			if string(src) == "none" {
+				return nil, nil
+			}
+			return strings.ToUpper(string(src)), nil
+		},
+	})
+	registerForTest(t, "point3", TypeCodec{
+		DecodeBinary: func(src []byte) (interface{}, error) { return len(src), nil },
+	})
+
+	var types = newConnTypes()
+	types.resolved[16500] = typeInfo{schema: "public", name: "mood", typtype: "e"}
+	types.resolved[16501] = typeInfo{schema: "public", name: "point3", typtype: "b"}
+	types.resolved[16502] = typeInfo{schema: "public", name: "happy", typtype: "d"}
+	types.domains[16502] = 16500
+	types.resolved[16503] = typeInfo{schema: "public", name: "_mood", typtype: "b"}
+	types.arrays[16503] = 16500
+	types.resolved[16504] = typeInfo{schema: "public", name: "color", typtype: "e"}
+
+	var r = &Rows{types: types}
+	var cases = []struct {
+		oid    frame.PgType
+		binary bool
+		name   string
+		scan   reflect.Type
+		raw    []byte
+		want   interface{}
+	}{
+		{16500, false, "mood", reflect.TypeOf(""), []byte("ok"), "OK"},
+		{16501, true, "point3", reflect.TypeOf((*interface{})(nil)).Elem(), []byte{1, 2, 3}, 3},
+		{16502, false, "happy", reflect.TypeOf(""), []byte("yes"), "YES"}, // 域使用基础类型的注册
+		{16503, false, "_mood", reflect.TypeOf([]*string{}), []byte("{a,NULL}"), []*string{strPtr("A"), nil}},
+		{16503, false, "_mood", reflect.TypeOf([]*string{}), []byte("{a,none}"), []*string{strPtr("A"), nil}}, // 解码为 nil 的元素按 NULL 处理
+		{16504, false, "color", reflect.TypeOf(""), []byte("red"), "red"},                                     // 未注册的枚举按字符串返回
+		{frame.PgTypeText, false, "PgTypeText", reflect.TypeOf(""), []byte("x"), "x"},
+	}
+	for _, c := range cases {
+		if got := types.binaryResult(c.oid); got != c.binary {
+			t.Errorf("binaryResult(%d) = %v", c.oid, got)
+		}
+		if got := types.typeName(c.oid); got != c.name {
+			t.Errorf("typeName(%d) = %s, want %s", c.oid, got, c.name)
+		}
+		if got := r.scanType(c.oid); got != c.scan {
+			t.Errorf("scanType(%d) = %v, want %v", c.oid, got, c.scan)
+		}
+		var format uint16
+		if c.binary {
+			format = 1
+		}
+		got, err := r.data2Value(c.raw, frame.Column{TypeOid: uint32(c.oid), Format: format})
+		if err != nil || !reflect.DeepEqual(got, c.want) {
+			t.Errorf("data2Value(%d) = %#v, %v, want %#v", c.oid, got, err, c.want)
+		}
+	}
+	if name := types.typeName(99999); name != "" {
+		t.Errorf("typeName of an unresolved OID = %q, want empty", name)
+	}
+}
+
+type testPoint3 struct{ X, Y, Z float64 }
+
+func TestCheckNamedValueEncoder(t *testing.T) {
+	var encode = func(v interface{}) ([]byte, error) { return nil, driver.ErrSkip }
+	registerForTest(t, "mood2", TypeCodec{Encode: encode})
+	var c = Connect{types: newConnTypes()}
+	// 与参数无关的 Encode 不影响提前拒绝
+	if err := c.CheckNamedValue(&driver.NamedValue{Ordinal: 1, Value: testPoint3{}}); err == nil {
+		t.Error("an unrelated encoder should not disable the check")
+	}
+
+	registerForTest(t, "point3", TypeCodec{ScanType: reflect.TypeOf(testPoint3{}), Encode: encode})
+	for _, v := range []interface{}{testPoint3{}, &testPoint3{}} {
+		if err := c.CheckNamedValue(&driver.NamedValue{Ordinal: 1, Value: v}); err != nil {
+			t.Errorf("CheckNamedValue(%T): %v", v, err)
+		}
+	}
+
+	// 预备语句按参数的 OID 查找 Encode
+	c.types.resolved[16510] = typeInfo{schema: "public", name: "mood2", typtype: "e"}
+	var s = Statement{cn: &c, Response: client.ParseResponse{Parameters: &frame.ParameterDescription{TypeOIDs: []uint32{16510, uint32(frame.PgTypeText)}}}}
+	if err := s.CheckNamedValue(&driver.NamedValue{Ordinal: 1, Value: testStruct{}}); err != nil {
+		t.Errorf("CheckNamedValue for a parameter with an encoder: %v", err)
+	}
+	if err := s.CheckNamedValue(&driver.NamedValue{Ordinal: 2, Value: testStruct{}}); err == nil {
+		t.Error("a text parameter should still reject a struct")
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
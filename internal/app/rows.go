@@ -1,23 +1,28 @@
 package app
 
 import (
-	"bytes"
 	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
 	"github.com/blusewang/pg/v2/internal/client/frame"
+	"github.com/blusewang/pg/v2/internal/codec"
+	"net"
+	"net/netip"
 	"reflect"
 	"strconv"
-	"strings"
 	"time"
 )
 
 type Rows struct {
-	location *time.Location
-	columns  *frame.RowDescription
-	rows     []*frame.DataRow
-	position int
+	location   *time.Location // 服务器的时区，用于 timestamptz，为 nil 时保留服务器给出的偏移
+	tsLocation *time.Location // timestamp、date、time 对应的时区
+	tsErr      error          // 无法确定 tsLocation 时的错误
+	types      *connTypes     // 连接上的扩展类型
+	columns    *frame.RowDescription
+	formats    []uint16 // 各列实际请求的格式，为空时全部为文本格式
+	rows       []*frame.DataRow
+	position   int
 }
 
 func (r *Rows) HasNextResultSet() bool {
@@ -29,46 +34,86 @@ func (r *Rows) NextResultSet() error {
 }
 
 func (r *Rows) ColumnTypeScanType(index int) reflect.Type {
-	switch frame.PgType(r.columns.Columns[index].TypeOid) {
+	return r.scanType(frame.PgType(r.columns.Columns[index].TypeOid))
+}
+
+// scanType 各类型解码后的 Go 类型，数组为元素指针的切片，如 []*int64；多维数组实际解码为嵌套的切片
+func (r *Rows) scanType(oid frame.PgType) reflect.Type {
+	if tc, ok := r.types.codec(oid); ok {
+		if tc.ScanType == nil {
+			return reflect.TypeOf((*interface{})(nil)).Elem()
+		}
+		return tc.ScanType
+	}
+	oid = r.types.base(oid)
+	if elem, ok := r.types.elemType(oid); ok {
+		return reflect.SliceOf(reflect.PtrTo(r.scanType(elem)))
+	}
+	if _, ok := rangeSubtypes[oid]; ok {
+		return reflect.TypeOf((*codec.RangeValue)(nil)).Elem()
+	}
+	if _, ok := multirangeRanges[oid]; ok {
+		return reflect.TypeOf((*[]codec.RangeValue)(nil)).Elem()
+	}
+	if r.types.isHstore(oid) {
+		return reflect.TypeOf((*map[string]*string)(nil)).Elem()
+	}
+	if r.types.isVector(oid) {
+		if oid == r.types.sparsevec {
+			return reflect.TypeOf((*codec.SparseVector)(nil)).Elem()
+		}
+		return reflect.TypeOf((*[]float32)(nil)).Elem()
+	}
+	if r.types.composite(oid) != nil {
+		return reflect.TypeOf((*codec.Record)(nil)).Elem()
+	}
+	switch oid {
 	case frame.PgTypeBool:
 		return reflect.TypeOf((*bool)(nil)).Elem()
 	case frame.PgTypeDate, frame.PgTypeTime, frame.PgTypeTimestamp, frame.PgTypeTimestamptz, frame.PgTypeTimetz:
 		return reflect.TypeOf((*time.Time)(nil)).Elem()
 	case frame.PgTypeInt2, frame.PgTypeInt4, frame.PgTypeInt8:
 		return reflect.TypeOf((*int64)(nil)).Elem()
-	case frame.PgTypeFloat4, frame.PgTypeFloat8, frame.PgTypeNumeric:
+	case frame.PgTypeFloat4, frame.PgTypeFloat8:
 		return reflect.TypeOf((*float64)(nil)).Elem()
-	case frame.PgTypeText, frame.PgTypeVarchar, frame.PgTypeChar, frame.PgTypeUuid:
+	case frame.PgTypeText, frame.PgTypeVarchar, frame.PgTypeChar, frame.PgTypeUuid, frame.PgTypeNumeric:
 		return reflect.TypeOf((*string)(nil)).Elem()
 	case frame.PgTypePoint:
-		//return reflect.TypeOf("")
-		return reflect.TypeOf((*[]float64)(nil)).Elem()
+		return reflect.TypeOf((*codec.Point)(nil)).Elem()
+	case frame.PgTypeLine:
+		return reflect.TypeOf((*codec.Line)(nil)).Elem()
+	case frame.PgTypeLseg:
+		return reflect.TypeOf((*codec.Lseg)(nil)).Elem()
+	case frame.PgTypeBox:
+		return reflect.TypeOf((*codec.Box)(nil)).Elem()
+	case frame.PgTypePath:
+		return reflect.TypeOf((*codec.Path)(nil)).Elem()
+	case frame.PgTypePolygon:
+		return reflect.TypeOf((*codec.Polygon)(nil)).Elem()
+	case frame.PgTypeCircle:
+		return reflect.TypeOf((*codec.Circle)(nil)).Elem()
 	case frame.PgTypeJson, frame.PgTypeJsonb:
 		return reflect.TypeOf((*json.RawMessage)(nil)).Elem()
 	case frame.PgTypeBytea:
 		return reflect.TypeOf((*[]byte)(nil)).Elem()
-
-	case frame.PgTypeArrBool:
-		return reflect.TypeOf((*[]bool)(nil)).Elem()
-	case frame.PgTypeArrDate, frame.PgTypeArrTime, frame.PgTypeArrTimestamp, frame.PgTypeArrTimestamptz, frame.PgTypeArrTimetz:
-		return reflect.TypeOf((*[]time.Time)(nil)).Elem()
-	case frame.PgTypeArrInt2, frame.PgTypeArrInt4, frame.PgTypeArrInt8:
-		return reflect.TypeOf((*[]int64)(nil)).Elem()
-	case frame.PgTypeArrFloat4, frame.PgTypeArrFloat8, frame.PgTypeArrNumeric:
-		return reflect.TypeOf((*[]float64)(nil)).Elem()
-	case frame.PgTypeArrVarchar, frame.PgTypeArrChar, frame.PgTypeArrText, frame.PgTypeArrUuid, frame.PgTypeArrJson, frame.PgTypeArrJsonb:
-		return reflect.TypeOf((*[]string)(nil)).Elem()
-	case frame.PgTypeArrBytea:
-		return reflect.TypeOf((*[][]byte)(nil)).Elem()
+	case frame.PgTypeInet, frame.PgTypeCidr:
+		return reflect.TypeOf((*netip.Prefix)(nil)).Elem()
+	case frame.PgTypeMacaddr, frame.PgTypeMacaddr8:
+		return reflect.TypeOf((*net.HardwareAddr)(nil)).Elem()
 	case frame.PgTypeRecord:
-		return reflect.TypeOf((*[]string)(nil)).Elem()
+		return reflect.TypeOf((*codec.Record)(nil)).Elem()
 
 	default:
 		return reflect.TypeOf((*string)(nil)).Elem()
 	}
 }
 
-func (r *Rows) ColumnTypePrecisionScale(_ int) (precision, scale int64, ok bool) {
+func (r *Rows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	col := r.columns.Columns[index]
+	switch col.TypeOid {
+	case frame.PgTypeNumeric, frame.PgTypeArrNumeric:
+		return codec.NumericTypmod(int32(col.TypeModifier))
+	}
 	return 0, 0, false
 }
 
@@ -81,7 +126,7 @@ func (r *Rows) ColumnTypeLength(index int) (length int64, ok bool) {
 }
 
 func (r *Rows) ColumnTypeDatabaseTypeName(index int) string {
-	return frame.PgTypeMap[frame.PgType(r.columns.Columns[index].TypeOid)]
+	return r.types.typeName(frame.PgType(r.columns.Columns[index].TypeOid))
 }
 
 func (r *Rows) Columns() (cols []string) {
@@ -110,152 +155,147 @@ func (r *Rows) Next(dest []driver.Value) error {
 	} else if r.position == rowsLen {
 		return fmt.Errorf("pg_rows rows length is %v but position is %v", rowsLen, r.position)
 	}
+	var err error
 	for i, v := range r.rows[r.position].DataArr {
-		dest[i] = r.data2Value(v, r.columns.Columns[i])
+		var col = r.columns.Columns[i]
+		if i < len(r.formats) {
+			col.Format = r.formats[i]
+		}
+		if dest[i], err = r.data2Value(v, col); err != nil {
+			return fmt.Errorf("pg_rows column %q: %w", r.columns.Columns[i].Name, err)
+		}
 	}
 	r.position += 1
 	return nil
 }
 
-func (r *Rows) data2Value(raw []byte, col frame.Column) interface{} {
+// data2Value 解码一列的值，col.Format 为 1 时是按注册的 DecodeBinary 请求的二进制格式
+func (r *Rows) data2Value(raw []byte, col frame.Column) (interface{}, error) {
 	if raw == nil {
-		return nil
+		return nil, nil
 	}
-	switch col.TypeOid {
+	if tc, ok := r.types.codec(frame.PgType(col.TypeOid)); ok {
+		switch {
+		case col.Format == 1 && tc.DecodeBinary != nil:
+			return tc.DecodeBinary(raw)
+		case tc.DecodeText != nil:
+			return tc.DecodeText(raw)
+		}
+		return string(raw), nil
+	}
+	var oid = r.types.base(frame.PgType(col.TypeOid))
+	if elem, ok := r.types.elemType(oid); ok {
+		return r.array2Value(raw, elem)
+	}
+	if newValue, ok := geometryTypes[oid]; ok {
+		return geometry2Value(raw, newValue, col.Format == 1)
+	}
+	if subtype, ok := rangeSubtypes[oid]; ok {
+		return r.range2Value(raw, subtype, col.Format == 1)
+	}
+	if rng, ok := multirangeRanges[oid]; ok {
+		return r.multirange2Value(raw, rangeSubtypes[rng], col.Format == 1)
+	}
+	if r.types.isHstore(oid) {
+		if col.Format == 1 {
+			return codec.ParseHstoreBinary(raw)
+		}
+		return codec.ParseHstore(raw)
+	}
+	if r.types.isVector(oid) {
+		return r.vector2Value(raw, oid, col.Format == 1)
+	}
+	if ct := r.types.composite(oid); ct != nil {
+		return r.composite2Value(raw, ct)
+	}
+	switch oid {
 	case frame.PgTypeBool:
-		return string(raw)[0] == 't'
+		return string(raw)[0] == 't', nil
 	case frame.PgTypeText, frame.PgTypeChar, frame.PgTypeVarchar:
-		return string(raw)
+		return string(raw), nil
 
 	case frame.PgTypeTimestamptz:
-		t, _ := time.Parse("2006-01-02 15:04:05-07", string(raw))
-		return t.In(r.location)
-	case frame.PgTypeTimestamp:
-		t, _ := time.Parse("2006-01-02 15:04:05", string(raw))
-		return t.In(r.location).Add(-8 * time.Hour)
-	case frame.PgTypeDate:
-		t, _ := time.Parse(time.DateOnly, string(raw))
-		return t.In(r.location).Add(-8 * time.Hour)
-	case frame.PgTypeTime:
-		t, _ := time.Parse(time.TimeOnly, string(raw))
-		return t.In(r.location).Add(-8 * time.Hour)
+		t, err := codec.ParseTimestamp(string(raw), time.UTC)
+		if r.location != nil {
+			t = t.In(r.location)
+		}
+		return t, err
+	case frame.PgTypeTimestamp, frame.PgTypeDate, frame.PgTypeTime:
+		if r.tsLocation == nil {
+			return nil, r.tsErr
+		}
+		switch oid {
+		case frame.PgTypeTimestamp:
+			return codec.ParseTimestamp(string(raw), r.tsLocation)
+		case frame.PgTypeDate:
+			return codec.ParseDate(string(raw), r.tsLocation)
+		}
+		return codec.ParseTime(string(raw), r.tsLocation)
 	case frame.PgTypeTimetz:
-		t, _ := time.Parse("15:04:05-07", string(raw))
-		return t.In(r.location)
+		// timetz 总是带有时区偏移
+		return codec.ParseTime(string(raw), time.UTC)
 
 	case frame.PgTypeInt2, frame.PgTypeInt4, frame.PgTypeInt8:
-		var i, _ = strconv.Atoi(string(raw))
-		return i
-	case frame.PgTypeFloat4, frame.PgTypeFloat8, frame.PgTypeNumeric:
+		return strconv.ParseInt(string(raw), 10, 64)
+	case frame.PgTypeFloat4, frame.PgTypeFloat8:
 		var f, _ = strconv.ParseFloat(string(raw), 64)
-		return f
+		return f, nil
+	case frame.PgTypeNumeric:
+		// 以字符串返回，避免精度丢失
+		return string(raw), nil
 	case frame.PgTypeUuid:
-		return string(raw)
-	case frame.PgTypePoint:
-		raw = bytes.ReplaceAll(raw, []byte("("), []byte("["))
-		raw = bytes.ReplaceAll(raw, []byte(")"), []byte("]"))
-		var arr []float64
-		_ = json.Unmarshal(raw, &arr)
-		return arr
-		//return string(raw)
-	case frame.PgTypeJson, frame.PgTypeJsonb:
-		return json.RawMessage(raw)
-	case frame.PgTypeArrBool:
-		raw = bytes.ReplaceAll(raw, []byte("{"), []byte("["))
-		raw = bytes.ReplaceAll(raw, []byte("}"), []byte("]"))
-		raw = bytes.ReplaceAll(raw, []byte("t"), []byte("true"))
-		raw = bytes.ReplaceAll(raw, []byte("f"), []byte("false"))
-		var arr []int64
-		_ = json.Unmarshal(raw, &arr)
-		return arr
-	case frame.PgTypeArrInt4, frame.PgTypeArrInt8:
-		var str = string(raw)
-		var arr []int64
-		if strings.HasPrefix(str, "{") && len(str) > 2 {
-			str = str[1 : len(str)-1]
-			for _, v := range strings.Split(str, ",") {
-				if v != "" {
-					n, _ := strconv.ParseInt(v, 0, 64)
-					arr = append(arr, n)
-				}
-			}
+		return string(raw), nil
+	case frame.PgTypeInet, frame.PgTypeCidr:
+		if col.Format == 1 {
+			return codec.ParseInetBinary(raw)
 		}
-		return arr
-	case frame.PgTypeArrFloat4, frame.PgTypeArrFloat8, frame.PgTypeArrNumeric:
-		raw = bytes.ReplaceAll(raw, []byte("{"), []byte("["))
-		raw = bytes.ReplaceAll(raw, []byte("}"), []byte("]"))
-		if bytes.HasPrefix(raw, []byte("[[[")) {
-			var arr [][][]float64
-			_ = json.Unmarshal(raw, &arr)
-			return arr
-		} else if bytes.HasPrefix(raw, []byte("[[")) {
-			var arr [][]float64
-			_ = json.Unmarshal(raw, &arr)
-			return arr
-		} else if bytes.HasPrefix(raw, []byte("[")) {
-			var arr []float64
-			_ = json.Unmarshal(raw, &arr)
-			return arr
+		return codec.ParseInet(raw)
+	case frame.PgTypeMacaddr, frame.PgTypeMacaddr8:
+		if col.Format == 1 {
+			return codec.ParseMacaddrBinary(raw)
 		}
-		return []float64{}
-	case frame.PgTypeArrText, frame.PgTypeArrChar, frame.PgTypeArrVarchar, frame.PgTypeArrUuid:
-		var ss = pgStringArr{Raw: bytes.Runes(raw)}
-		ss.parse()
-		return ss.rs
+		return codec.ParseMacaddr(raw)
+	case frame.PgTypeJson, frame.PgTypeJsonb:
+		return json.RawMessage(raw), nil
+	case frame.PgTypeBytea:
+		return codec.ParseBytea(raw)
 	case frame.PgTypeRecord:
-		return strings.Split(string(raw[1:len(raw)-1]), ",")
+		return r.composite2Value(raw, nil)
 	default:
-		return string(raw)
+		return string(raw), nil
 	}
 }
 
-type pgStringArr struct {
-	Raw      []rune
-	position int
-	buf      []rune
-	rs       []string
-}
-
-func (sa *pgStringArr) parse() {
-	var length = len(sa.Raw)
-	if length < 3 {
-		return
+// composite2Value 按属性类型解码复合类型；ct 为 nil 时是匿名记录，各字段以字符串返回
+func (r *Rows) composite2Value(raw []byte, ct *compositeType) (interface{}, error) {
+	fields, err := codec.ParseRecord(raw)
+	if err != nil {
+		return nil, err
 	}
-	if sa.Raw[0] != '{' {
-		return
-	}
-	sa.position = 1
-	for {
-		if sa.position >= length-1 {
-			break
-		}
-		if sa.Raw[sa.position] == '"' {
-			sa.position += 1
-			sa.readUtil('"')
-			var str = string(sa.buf)
-			str = strings.Replace(str, `\'`, `'`, -1)
-			str = strings.Replace(str, `\"`, `"`, -1)
-			sa.rs = append(sa.rs, str)
-		} else {
-			sa.readUtil(',')
-			sa.rs = append(sa.rs, string(sa.buf))
+	var rec = codec.Record{Values: make([]interface{}, len(fields))}
+	if ct == nil {
+		for i, f := range fields {
+			if f != nil {
+				rec.Values[i] = string(f)
+			}
 		}
+		return rec, nil
 	}
-}
-
-func (sa *pgStringArr) readUtil(r rune) {
-	sa.buf = []rune{}
-	for {
-		var rr = sa.Raw[sa.position]
-		sa.position++
-		if rr == r && sa.Raw[sa.position-2] != '\\' {
-			break
-		} else if rr == '}' && sa.Raw[sa.position-2] != '\\' {
-			break
-		} else {
-			sa.buf = append(sa.buf, rr)
+	if len(ct.fields) == 0 && len(fields) == 1 && fields[0] == nil {
+		// 没有属性的复合类型同样输出为 ()
+		fields, rec.Values = nil, nil
+	}
+	if len(fields) != len(ct.fields) {
+		return nil, fmt.Errorf("pg: composite type %s has %d attributes but got %d", ct.name, len(ct.fields), len(fields))
+	}
+	rec.Names = make([]string, len(fields))
+	for i, f := range fields {
+		rec.Names[i] = ct.fields[i].name
+		if rec.Values[i], err = r.data2Value(f, frame.Column{TypeOid: uint32(ct.fields[i].oid)}); err != nil {
+			return nil, fmt.Errorf("attribute %q: %w", ct.fields[i].name, err)
 		}
 	}
+	return rec, nil
 }
 
 var _ driver.Rows = new(Rows)
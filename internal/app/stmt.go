@@ -2,10 +2,11 @@ package app
 
 import (
 	"context"
-	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
+	"fmt"
 	"github.com/blusewang/pg/v2/internal/client"
+	"github.com/blusewang/pg/v2/internal/client/frame"
 	"go/types"
 	"strconv"
 	"time"
@@ -30,6 +31,16 @@ func (s Statement) NumInput() int {
 	return len(s.Response.Parameters.TypeOIDs)
 }
 
+// CheckNamedValue 同 Connect.CheckNamedValue，但参数的类型注册了 Encode 时不提前拒绝，交由 Encode 处理
+func (s Statement) CheckNamedValue(nv *driver.NamedValue) error {
+	if s.Response.Parameters != nil && nv.Ordinal >= 1 && nv.Ordinal <= len(s.Response.Parameters.TypeOIDs) {
+		if tc, ok := s.cn.types.codec(frame.PgType(s.Response.Parameters.TypeOIDs[nv.Ordinal-1])); ok && tc.Encode != nil {
+			return nil
+		}
+	}
+	return s.cn.CheckNamedValue(nv)
+}
+
 func (s Statement) Exec(args []driver.Value) (driver.Result, error) {
 	nvs := make([]driver.NamedValue, 0)
 	for i, arg := range args {
@@ -55,33 +66,104 @@ func (s Statement) Query(args []driver.Value) (driver.Rows, error) {
 }
 
 func (s Statement) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
-	select {
-	case <-ctx.Done():
-		return nil, nil
-	default:
-		response, err := s.cn.client.BindExec(s.Id, s.nameValue2Raw(args))
-		return Result{response}, err
+	vs, paramFormats, err := s.nameValue2Raw(args)
+	if err != nil {
+		return nil, err
 	}
+	response, err := s.cn.client.BindExec(ctx, s.Id, vs, paramFormats, nil)
+	return Result{response}, err
 }
 
 func (s Statement) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
-	select {
-	case <-ctx.Done():
-		return nil, sql.ErrConnDone
-	default:
-		response, err := s.cn.client.BindExec(s.Id, s.nameValue2Raw(args))
-		return &Rows{s.cn.client.Location, s.Response.Rows, response.DataRows, 0}, err
+	vs, paramFormats, err := s.nameValue2Raw(args)
+	if err != nil {
+		return nil, err
 	}
+	formats := s.resultFormats()
+	response, err := s.cn.client.BindExec(ctx, s.Id, vs, paramFormats, formats)
+	tsLocation, tsErr := s.cn.client.TimestampLocation()
+	return &Rows{
+		location:   s.cn.client.Location,
+		tsLocation: tsLocation,
+		tsErr:      tsErr,
+		types:      s.cn.types,
+		columns:    s.Response.Rows,
+		formats:    formats,
+		rows:       response.DataRows,
+	}, err
 }
 
-func (s Statement) nameValue2Raw(args []driver.NamedValue) (vs []driver.Value) {
+// nameValue2Raw 转换各参数，参数类型注册了 Encode 时优先使用；pgvector 的类型及 hstore 以二进制格式发送
+// formats 为各参数的格式，全为文本格式时为 nil
+func (s Statement) nameValue2Raw(args []driver.NamedValue) (vs []driver.Value, formats []uint16, err error) {
 	vs = make([]driver.Value, 0)
-	for _, arg := range args {
+	for i, arg := range args {
+		var oid frame.PgType
+		if s.Response.Parameters != nil && i < len(s.Response.Parameters.TypeOIDs) {
+			oid = frame.PgType(s.Response.Parameters.TypeOIDs[i])
+		}
+		if arg.Value != nil {
+			if tc, ok := s.cn.types.codec(oid); ok && tc.Encode != nil {
+				raw, err := tc.Encode(arg.Value)
+				if err == nil {
+					if raw == nil {
+						vs = append(vs, nil)
+					} else {
+						vs = append(vs, raw)
+					}
+					continue
+				} else if err != driver.ErrSkip {
+					return nil, nil, fmt.Errorf("pg: parameter $%d: %w", arg.Ordinal, err)
+				}
+			} else if base := s.cn.types.base(oid); s.cn.types.isVector(base) || s.cn.types.isHstore(base) {
+				var raw []byte
+				var ok bool
+				if s.cn.types.isHstore(base) {
+					raw, ok = hstore2Raw(arg.Value)
+				} else {
+					raw, ok, err = s.cn.types.vector2Raw(base, arg.Value)
+				}
+				if err != nil {
+					return nil, nil, fmt.Errorf("pg: parameter $%d: %w", arg.Ordinal, err)
+				}
+				if ok {
+					if raw == nil {
+						vs = append(vs, nil)
+						continue
+					}
+					if formats == nil {
+						formats = make([]uint16, len(args))
+					}
+					formats[i] = 1
+					vs = append(vs, raw)
+					continue
+				}
+			}
+		}
+		if err = s.cn.convertValue(&arg); err != nil {
+			return nil, nil, err
+		}
 		vs = append(vs, arg.Value)
 	}
 	return
 }
 
+// resultFormats 各列请求的格式，内置的网络地址、几何、范围类型，hstore、pgvector 的类型及注册了 DecodeBinary 的类型以二进制格式接收；全为文本格式时返回 nil
+func (s Statement) resultFormats() (formats []uint16) {
+	if s.Response.Rows == nil {
+		return nil
+	}
+	for i, col := range s.Response.Rows.Columns {
+		if s.cn.types.binaryResult(frame.PgType(col.TypeOid)) {
+			if formats == nil {
+				formats = make([]uint16, len(s.Response.Rows.Columns))
+			}
+			formats[i] = 1
+		}
+	}
+	return
+}
+
 func (s Statement) value2Row(value driver.Value) []byte {
 	switch value.(type) {
 	case types.Nil:
@@ -110,3 +192,4 @@ func (s Statement) value2Row(value driver.Value) []byte {
 var _ driver.Stmt = new(Statement)
 var _ driver.StmtQueryContext = new(Statement)
 var _ driver.StmtExecContext = new(Statement)
+var _ driver.NamedValueChecker = new(Statement)
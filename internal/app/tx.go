@@ -13,43 +13,37 @@ type Tx struct {
 }
 
 func (t Tx) Commit() (err error) {
-	select {
-	case <-t.ctx.Done():
+	if t.client.IsInTransaction() == false {
+		err = errors.New("this connection is out of transaction")
 		return
-	default:
-		if t.client.IsInTransaction() == false {
-			err = errors.New("this connection is out of transaction")
-			return
-		}
-		_, err = t.client.QueryNoArgs("commit")
-		if err != nil {
-			return
-		}
-		if t.client.IsInTransaction() {
-			err = errors.New("commit fail")
-		}
+	}
+	_, err = t.client.QueryNoArgs(t.ctx, "commit")
+	if err != nil {
 		return
 	}
+	if t.client.IsInTransaction() {
+		err = errors.New("commit fail")
+	}
+	return
 }
 
 func (t Tx) Rollback() (err error) {
-	select {
-	case <-t.ctx.Done():
+	if t.client.IsInTransaction() == false {
+		err = errors.New("this connection is out of transaction")
 		return
-	default:
-		if t.client.IsInTransaction() == false {
-			err = errors.New("this connection is out of transaction")
-			return
-		}
-		_, err = t.client.QueryNoArgs("rollback")
-		if err != nil {
-			return
-		}
-		if t.client.IsInTransaction() {
-			err = errors.New("rollback fail")
-		}
+	}
+	// 上下文被取消后 database/sql 仍会调用 Rollback，此时不能再受该上下文限制，
+	// 改用以 connect_timeout 为期限的上下文，连接已失效时不至于永远阻塞
+	ctx, cancel := t.client.BoundedContext()
+	defer cancel()
+	_, err = t.client.QueryNoArgs(ctx, "rollback")
+	if err != nil {
 		return
 	}
+	if t.client.IsInTransaction() {
+		err = errors.New("rollback fail")
+	}
+	return
 }
 
 var _ driver.Tx = new(Tx)
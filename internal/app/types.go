@@ -0,0 +1,317 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"github.com/blusewang/pg/v2/internal/client"
+	"github.com/blusewang/pg/v2/internal/client/frame"
+	"strconv"
+	"strings"
+)
+
+// connTypes 连接上 OID 由服务器动态分配的类型，如扩展类型、用户定义的复合类型
+// 扩展类型在连接建立后按 search_path 查询一次；其余类型在 Parse 时遇到未知的 OID 再从 pg_type 中查询并缓存，
+// 不在 search_path 中的扩展类型此时也按名称识别
+type connTypes struct {
+	hstore frame.PgType // 未安装 hstore 时为 0
+
+	vector    frame.PgType // 未安装 pgvector 时以下均为 0
+	halfvec   frame.PgType
+	sparsevec frame.PgType
+
+	resolved   map[frame.PgType]typeInfo // 已查询过的 OID，pg_type 中不存在时为零值
+	composites map[frame.PgType]*compositeType
+	arrays     map[frame.PgType]frame.PgType // 动态类型的数组类型对应的元素类型
+	domains    map[frame.PgType]frame.PgType // 域对应的基础类型，基础类型也可能是域
+}
+
+// typeInfo pg_type 中的类型名称及分类，typtype 为 b、c、d、e、p、r、m 之一
+type typeInfo struct {
+	schema  string
+	name    string
+	typtype string
+}
+
+// compositeType 复合类型的各个属性，按 attnum 排列
+type compositeType struct {
+	name   string
+	fields []compositeField
+}
+
+type compositeField struct {
+	name string
+	oid  frame.PgType
+}
+
+func newConnTypes() *connTypes {
+	return &connTypes{
+		resolved:   make(map[frame.PgType]typeInfo),
+		composites: make(map[frame.PgType]*compositeType),
+		arrays:     make(map[frame.PgType]frame.PgType),
+		domains:    make(map[frame.PgType]frame.PgType),
+	}
+}
+
+// extensionTypes 连接建立后即查询 OID 的扩展类型
+var extensionTypes = []string{"hstore", "vector", "halfvec", "sparsevec"}
+
+// loadExtensions 按 search_path 查询扩展类型及其数组类型的 OID，未安装的扩展不报错
+func (t *connTypes) loadExtensions(ctx context.Context, c *client.Client) error {
+	var regtypes = make([]string, len(extensionTypes))
+	for i, name := range extensionTypes {
+		regtypes[i] = "to_regtype('" + name + "')"
+	}
+	res, err := c.QueryNoArgs(ctx, "select oid, typname, typarray from pg_type where oid in ("+strings.Join(regtypes, ", ")+")")
+	if err != nil {
+		return fmt.Errorf("pg: failed to load extension types: %w", err)
+	}
+	for _, row := range res.DataRows {
+		if len(row.DataArr) != 3 {
+			continue
+		}
+		var oid, arr = parseOid(row.DataArr[0]), parseOid(row.DataArr[2])
+		t.resolved[oid] = typeInfo{name: string(row.DataArr[1]), typtype: "b"}
+		t.setExtension(oid, string(row.DataArr[1]))
+		if arr != 0 {
+			t.arrays[arr] = oid
+		}
+	}
+	return nil
+}
+
+func parseOid(raw []byte) frame.PgType {
+	oid, _ := strconv.ParseUint(string(raw), 10, 32)
+	return frame.PgType(oid)
+}
+
+// known 是否无需再查询 pg_type
+func (t *connTypes) known(oid frame.PgType) bool {
+	if _, ok := frame.PgTypeMap[oid]; ok {
+		return true
+	}
+	if oid == 0 || t.extensionName(oid) != "" {
+		return true
+	}
+	_, ok := t.resolved[oid]
+	return ok
+}
+
+// resolve 查询 oids 中未知的类型，复合类型的属性、数组的元素及域的基础类型若也未知则一并查询
+// 枚举等其余类型只记录名称，按字符串解码
+func (t *connTypes) resolve(ctx context.Context, c *client.Client, oids []frame.PgType) error {
+	var pending = t.unknown(oids)
+	for len(pending) > 0 {
+		res, err := c.QueryNoArgs(ctx, "select t.oid, t.typname, t.typtype, t.typrelid, t.typelem, t.typcategory, t.typbasetype, n.nspname "+
+			"from pg_type t join pg_namespace n on n.oid = t.typnamespace where t.oid in ("+joinOids(pending)+")")
+		if err != nil {
+			return fmt.Errorf("pg: failed to resolve types: %w", err)
+		}
+		var relations = make(map[frame.PgType]frame.PgType) // typrelid => oid
+		var next []frame.PgType
+		for _, oid := range pending {
+			t.resolved[oid] = typeInfo{}
+		}
+		for _, row := range res.DataRows {
+			if len(row.DataArr) != 8 {
+				continue
+			}
+			var oid, elem = parseOid(row.DataArr[0]), parseOid(row.DataArr[4])
+			var info = typeInfo{schema: string(row.DataArr[7]), name: string(row.DataArr[1]), typtype: string(row.DataArr[2])}
+			t.resolved[oid] = info
+			if info.typtype == "b" {
+				t.setExtension(oid, info.name)
+			}
+			switch {
+			case info.typtype == "c":
+				relations[parseOid(row.DataArr[3])] = oid
+				t.composites[oid] = &compositeType{name: info.name}
+			case info.typtype == "d":
+				t.domains[oid] = parseOid(row.DataArr[6])
+				next = append(next, t.domains[oid])
+			case string(row.DataArr[5]) == "A" && elem != 0:
+				t.arrays[oid] = elem
+				next = append(next, elem)
+			}
+		}
+		if len(relations) > 0 {
+			var rels = make([]frame.PgType, 0, len(relations))
+			for rel := range relations {
+				rels = append(rels, rel)
+			}
+			res, err = c.QueryNoArgs(ctx, "select attrelid, attname, atttypid from pg_attribute where attrelid in ("+joinOids(rels)+") and attnum > 0 and not attisdropped order by attrelid, attnum")
+			if err != nil {
+				return fmt.Errorf("pg: failed to resolve types: %w", err)
+			}
+			for _, row := range res.DataRows {
+				if len(row.DataArr) != 3 {
+					continue
+				}
+				ct := t.composites[relations[parseOid(row.DataArr[0])]]
+				if ct == nil {
+					continue
+				}
+				field := compositeField{name: string(row.DataArr[1]), oid: parseOid(row.DataArr[2])}
+				ct.fields = append(ct.fields, field)
+				next = append(next, field.oid)
+			}
+		}
+		pending = t.unknown(next)
+	}
+	return nil
+}
+
+func (t *connTypes) unknown(oids []frame.PgType) (pending []frame.PgType) {
+	var seen = make(map[frame.PgType]bool)
+	for _, oid := range oids {
+		if !t.known(oid) && !seen[oid] {
+			seen[oid] = true
+			pending = append(pending, oid)
+		}
+	}
+	return
+}
+
+func joinOids(oids []frame.PgType) string {
+	var ss = make([]string, len(oids))
+	for i, oid := range oids {
+		ss[i] = strconv.FormatUint(uint64(oid), 10)
+	}
+	return strings.Join(ss, ",")
+}
+
+// elemType 数组类型对应的元素类型，包括扩展类型及复合类型的数组
+func (t *connTypes) elemType(oid frame.PgType) (elem frame.PgType, ok bool) {
+	if elem, ok = frame.PgArrayElemType[oid]; ok {
+		return
+	}
+	if t == nil {
+		return 0, false
+	}
+	elem, ok = t.arrays[oid]
+	return
+}
+
+// composite 复合类型的属性信息，不是复合类型时返回 nil
+func (t *connTypes) composite(oid frame.PgType) *compositeType {
+	if t == nil {
+		return nil
+	}
+	return t.composites[oid]
+}
+
+// base 域对应的最终基础类型，不是域时返回 oid 本身
+func (t *connTypes) base(oid frame.PgType) frame.PgType {
+	if t == nil {
+		return oid
+	}
+	for i := 0; i < len(t.domains); i++ {
+		b, ok := t.domains[oid]
+		if !ok {
+			break
+		}
+		oid = b
+	}
+	return oid
+}
+
+// codec 应用为该类型注册的编解码方法，域未注册时使用其基础类型的注册；内置类型不使用注册的方法
+func (t *connTypes) codec(oid frame.PgType) (tc TypeCodec, ok bool) {
+	if t == nil {
+		return
+	}
+	for i := 0; i <= len(t.domains); i++ {
+		info := t.resolved[oid]
+		if info.name == "" {
+			return
+		}
+		if tc, ok = lookupType(info.schema, info.name); ok || info.typtype != "d" {
+			return
+		}
+		oid = t.domains[oid]
+	}
+	return
+}
+
+// typeName 类型的名称，用于 ColumnTypeDatabaseTypeName
+// 内置类型为 frame.PgTypeMap 中的名称，动态类型为 pg_type.typname，无从得知时为空串
+func (t *connTypes) typeName(oid frame.PgType) string {
+	if name, ok := frame.PgTypeMap[oid]; ok {
+		return name
+	}
+	if t != nil {
+		if name := t.extensionName(oid); name != "" {
+			return name
+		}
+		return t.resolved[oid].name
+	}
+	return ""
+}
+
+// setExtension 按类型名称记录扩展类型的 OID
+func (t *connTypes) setExtension(oid frame.PgType, name string) {
+	switch name {
+	case "hstore":
+		t.hstore = oid
+	case "vector":
+		t.vector = oid
+	case "halfvec":
+		t.halfvec = oid
+	case "sparsevec":
+		t.sparsevec = oid
+	}
+}
+
+// extensionName 已识别的扩展类型及其数组类型的名称，不是扩展类型时返回空串
+func (t *connTypes) extensionName(oid frame.PgType) string {
+	if t == nil || oid == 0 {
+		return ""
+	}
+	switch oid {
+	case t.hstore:
+		return "PgTypeHstore"
+	case t.vector:
+		return "PgTypeVector"
+	case t.halfvec:
+		return "PgTypeHalfvec"
+	case t.sparsevec:
+		return "PgTypeSparsevec"
+	}
+	if elem, ok := t.arrays[oid]; ok {
+		if name := t.extensionName(elem); name != "" {
+			return "PgTypeArr" + strings.TrimPrefix(name, "PgType")
+		}
+	}
+	return ""
+}
+
+// isHstore 是否为 hstore，hstore 列以二进制格式接收
+func (t *connTypes) isHstore(oid frame.PgType) bool {
+	return t != nil && oid != 0 && oid == t.hstore
+}
+
+// isVector 是否为 pgvector 的 vector、halfvec、sparsevec，这些类型的列以二进制格式接收
+func (t *connTypes) isVector(oid frame.PgType) bool {
+	return t != nil && oid != 0 && (oid == t.vector || oid == t.halfvec || oid == t.sparsevec)
+}
+
+// binaryResult 该类型的列是否以二进制格式接收
+func (t *connTypes) binaryResult(oid frame.PgType) bool {
+	if tc, ok := t.codec(oid); ok {
+		return tc.DecodeBinary != nil
+	}
+	oid = t.base(oid)
+	switch oid {
+	case frame.PgTypeInet, frame.PgTypeCidr, frame.PgTypeMacaddr, frame.PgTypeMacaddr8:
+		return true
+	}
+	if _, ok := geometryTypes[oid]; ok {
+		return true
+	}
+	if _, ok := rangeSubtypes[oid]; ok {
+		return true
+	}
+	if _, ok := multirangeRanges[oid]; ok {
+		return true
+	}
+	return t.isVector(oid) || t.isHstore(oid)
+}
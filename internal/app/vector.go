@@ -0,0 +1,80 @@
+package app
+
+import (
+	"fmt"
+	"github.com/blusewang/pg/v2/internal/client/frame"
+	"github.com/blusewang/pg/v2/internal/codec"
+	"math"
+	"reflect"
+)
+
+// vector2Value 解码 pgvector 的类型，vector、halfvec 解码为 []float32，sparsevec 解码为 codec.SparseVector
+func (r *Rows) vector2Value(raw []byte, oid frame.PgType, binary bool) (interface{}, error) {
+	switch {
+	case oid == r.types.sparsevec && binary:
+		var s codec.SparseVector
+		err := s.UnmarshalBinary(raw)
+		return s, err
+	case oid == r.types.sparsevec:
+		return codec.ParseSparseVector(raw)
+	case oid == r.types.halfvec && binary:
+		return codec.ParseHalfvecBinary(raw)
+	case binary:
+		return codec.ParseVectorBinary(raw)
+	}
+	return codec.ParseVector(raw)
+}
+
+// vector2Raw 把 vector、halfvec、sparsevec 参数编码为二进制格式，raw 为 nil 表示 NULL
+// 接受 float32、float64 的切片（如 pg.Vector）及 codec.SparseVector，其余类型返回 ok 为 false，按普通参数转换
+func (t *connTypes) vector2Raw(oid frame.PgType, v interface{}) (raw []byte, ok bool, err error) {
+	var dense []float32
+	var sparse *codec.SparseVector
+	switch x := v.(type) {
+	case codec.SparseVector:
+		sparse = &x
+	case *codec.SparseVector:
+		if x == nil {
+			return nil, true, nil
+		}
+		sparse = x
+	default:
+		rv := reflect.ValueOf(v)
+		for rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				return nil, true, nil
+			}
+			rv = rv.Elem()
+		}
+		if rv.Kind() != reflect.Slice || (rv.Type().Elem().Kind() != reflect.Float32 && rv.Type().Elem().Kind() != reflect.Float64) {
+			return nil, false, nil
+		}
+		if rv.IsNil() {
+			return nil, true, nil
+		}
+		dense = make([]float32, rv.Len())
+		for i := range dense {
+			dense[i] = float32(rv.Index(i).Float())
+		}
+	}
+
+	if oid == t.sparsevec {
+		if sparse == nil {
+			s := codec.NewSparseVector(dense)
+			sparse = &s
+		}
+		raw, err = sparse.MarshalBinary()
+		return raw, true, err
+	}
+	if sparse != nil {
+		dense = sparse.Dense()
+	}
+	// 二进制格式中维数只占 2 字节
+	if len(dense) > math.MaxUint16 {
+		return nil, true, fmt.Errorf("pg: vector has %d dimensions, more than the %d the binary format allows", len(dense), math.MaxUint16)
+	}
+	if oid == t.halfvec {
+		return codec.FormatHalfvecBinary(dense), true, nil
+	}
+	return codec.FormatVectorBinary(dense), true, nil
+}
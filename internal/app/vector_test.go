@@ -0,0 +1,109 @@
+package app
+
+import (
+	"github.com/blusewang/pg/v2/internal/client/frame"
+	"github.com/blusewang/pg/v2/internal/codec"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func vectorTypes() *connTypes {
+	var types = newConnTypes()
+	types.setExtension(16600, "vector")
+	types.setExtension(16601, "halfvec")
+	types.setExtension(16602, "sparsevec")
+	types.arrays[16605] = 16600
+	return types
+}
+
+func TestVector2Value(t *testing.T) {
+	var types = vectorTypes()
+	for oid, name := range map[frame.PgType]string{16600: "PgTypeVector", 16601: "PgTypeHalfvec", 16602: "PgTypeSparsevec", 16605: "PgTypeArrVector"} {
+		if types.typeName(oid) != name {
+			t.Errorf("typeName(%d) = %s, want %s", oid, types.typeName(oid), name)
+		}
+		if got := types.binaryResult(oid); got != (oid != 16605) {
+			t.Errorf("binaryResult(%d) = %v", oid, got)
+		}
+	}
+
+	var r = &Rows{types: types}
+	var sparse = codec.SparseVector{Dim: 4, Indices: []int32{1, 3}, Values: []float32{1.5, -2}}
+	sparseRaw, _ := sparse.MarshalBinary()
+	var cases = []struct {
+		oid    frame.PgType
+		format uint16
+		raw    []byte
+		want   interface{}
+	}{
+		{16600, 1, codec.FormatVectorBinary([]float32{1, -2.5}), []float32{1, -2.5}},
+		{16600, 0, []byte("[1,-2.5]"), []float32{1, -2.5}},
+		{16601, 1, codec.FormatHalfvecBinary([]float32{1, -2.5}), []float32{1, -2.5}},
+		{16601, 0, []byte("[1,-2.5]"), []float32{1, -2.5}},
+		{16602, 1, sparseRaw, sparse},
+		{16602, 0, []byte("{2:1.5,4:-2}/4"), sparse},
+		{16605, 0, []byte("{\"[1,2]\",NULL}"), []*[]float32{{1, 2}, nil}},
+	}
+	for _, c := range cases {
+		got, err := r.data2Value(c.raw, frame.Column{TypeOid: uint32(c.oid), Format: c.format})
+		if err != nil || !reflect.DeepEqual(got, c.want) {
+			t.Errorf("data2Value(%d, format %d) = %#v, %v, want %#v", c.oid, c.format, got, err, c.want)
+		}
+	}
+	for _, c := range []struct {
+		oid frame.PgType
+		raw []byte
+	}{
+		{16600, []byte{0, 2, 0, 0, 0, 0}},
+		{16601, []byte{0, 1}},
+		{16602, []byte{0, 0, 0, 2, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 5, 0, 0, 0, 0}}, // 下标越界
+	} {
+		if _, err := r.data2Value(c.raw, frame.Column{TypeOid: uint32(c.oid), Format: 1}); err == nil {
+			t.Errorf("data2Value(%d, %v) should fail", c.oid, c.raw)
+		}
+	}
+}
+
+func TestVector2Raw(t *testing.T) {
+	var types = vectorTypes()
+	type embedding []float32
+	var dense = []float32{0, 1.5, 0, -2}
+	var sparse = codec.NewSparseVector(dense)
+	sparseRaw, _ := sparse.MarshalBinary()
+	var cases = []struct {
+		oid  frame.PgType
+		v    interface{}
+		want []byte
+	}{
+		{16600, dense, codec.FormatVectorBinary(dense)},
+		{16600, embedding(dense), codec.FormatVectorBinary(dense)},
+		{16600, &dense, codec.FormatVectorBinary(dense)},
+		{16600, []float64{0, 1.5, 0, -2}, codec.FormatVectorBinary(dense)},
+		{16600, sparse, codec.FormatVectorBinary(dense)},
+		{16601, dense, codec.FormatHalfvecBinary(dense)},
+		{16602, dense, sparseRaw},
+		{16602, &sparse, sparseRaw},
+		{16600, []float32(nil), nil},
+		{16600, (*[]float32)(nil), nil},
+		{16602, (*codec.SparseVector)(nil), nil},
+	}
+	for _, c := range cases {
+		raw, ok, err := types.vector2Raw(c.oid, c.v)
+		if err != nil || !ok || !reflect.DeepEqual(raw, c.want) {
+			t.Errorf("vector2Raw(%d, %T) = %v, %v, %v", c.oid, c.v, raw, ok, err)
+		}
+	}
+	for _, v := range []interface{}{"[1,2]", []int{1, 2}, 1.5} {
+		if _, ok, _ := types.vector2Raw(16600, v); ok {
+			t.Errorf("vector2Raw(%T) should not be handled", v)
+		}
+	}
+	if _, _, err := types.vector2Raw(16600, make([]float32, math.MaxUint16+1)); err == nil || !strings.Contains(err.Error(), "dimensions") {
+		t.Errorf("expected a dimension error, got %v", err)
+	}
+	if _, _, err := types.vector2Raw(16602, codec.SparseVector{Dim: 2, Indices: []int32{3}, Values: []float32{1}}); err == nil {
+		t.Error("an out of bounds sparsevec should be rejected")
+	}
+}
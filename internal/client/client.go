@@ -4,14 +4,14 @@ import (
 	"context"
 	"crypto/sha256"
 	"crypto/tls"
-	"crypto/x509"
 	"database/sql/driver"
 	"errors"
 	"fmt"
 	"github.com/blusewang/pg/v2/internal/client/frame"
 	"github.com/blusewang/pg/v2/internal/client/scram"
 	"net"
-	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -20,6 +20,7 @@ func NewClient() (c *Client) {
 	c.ConnectStatus = ConnectStatusConnecting
 	c.status = frame.TransactionStatusNoReady
 	c.parameterMaps = make(map[string]string)
+	c.Location = time.UTC
 	return
 }
 
@@ -50,39 +51,54 @@ const (
 
 type Client struct {
 	cn                  net.Conn                // TCP 连接
-	ctx                 context.Context         // 初始上下文
 	Dsn                 DataSourceName          // 数据源
 	writer              *frame.Encoder          // 流式编码器
 	reader              *frame.Decoder          // 流式解码器
 	backendPid          uint32                  // 业务过程中 后端PID 取消操作时需要
 	backendKey          uint32                  // 业务过程中 后端口令 取消操作时需要
 	parameterMaps       map[string]string       // 服务器提供的属性参数
-	Location            *time.Location          // 服务器端的时区
+	Location            *time.Location          // 服务器端的时区，无法识别时为 nil
+	timeZoneErr         error                   // 无法识别服务器的时区时的错误
 	status              frame.TransactionStatus // 业务状态
 	ConnectStatus       ConnectStatus           // 连接状态
 	notificationHandler NotificationHandler     // Listen 消息
+	OAuthHint           *OAuthError             // 上一次 OAUTHBEARER 认证失败时服务器给出的信息，重试时传给令牌提供方
 }
 
 func (c *Client) Connect(ctx context.Context, dsn DataSourceName) (err error) {
-	c.ctx = ctx
 	c.Dsn = dsn
 	nw, addr, timeout := dsn.Address()
 	c.ConnectStatus = ConnectStatusConnecting
 	d := net.Dialer{Timeout: timeout}
+	if !dsn.KeepAlive.Enable {
+		d.KeepAlive = -1
+	} else if dsn.KeepAlive.Idle > 0 {
+		d.KeepAlive = dsn.KeepAlive.Idle
+	}
 	c.cn, err = d.DialContext(ctx, nw, addr)
 	if err != nil {
 		return
 	}
+	if tc, ok := c.cn.(*net.TCPConn); ok {
+		if err = setSocketOptions(tc, dsn); err != nil {
+			_ = c.cn.Close()
+			return
+		}
+	}
 	c.writer = frame.NewEncoder(c.cn)
 	c.reader = frame.NewDecoder(c.cn)
 	return
 }
 
 // AutoSSL 按配置中的严格程度开始TLS握手
-func (c *Client) AutoSSL() (err error) {
+func (c *Client) AutoSSL(ctx context.Context) (err error) {
 	if c.Dsn.SSL.Mode == "disable" || c.Dsn.SSL.Mode == "allow" {
 		return
 	}
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	defer c.watch(ctx)(&err)
 	var tlsConfig tls.Config
 	if err = c.writer.Send(frame.NewSSLRequest()); err != nil {
 		return
@@ -93,69 +109,72 @@ func (c *Client) AutoSSL() (err error) {
 		return
 	}
 
-	switch c.Dsn.SSL.Mode {
-	case "prefer":
-		if code == 'N' {
+	if code == 'N' {
+		if c.Dsn.SSL.Mode == "prefer" {
 			// 服务器不支持，转用明文传输
 			return nil
-		} else if err = c.Dsn.SSLCheck(); err != nil {
-			// 服务器支持，但本地证书检查不通过，也转用明文传输
-			return nil
 		}
+		return errors.New("pq: SSL is not enabled on the server")
+	}
+	// 服务器已同意 TLS，此后任何错误都不能再退回明文传输
+	if err = c.Dsn.SSLCheck(); err != nil {
+		return
+	}
+	tlsConfig.Renegotiation = tls.RenegotiateFreelyAsClient
+	if tlsConfig.RootCAs, err = c.Dsn.rootCertPool(); err != nil {
+		return
+	}
+	switch c.Dsn.SSL.Mode {
+	case "prefer":
 		tlsConfig.InsecureSkipVerify = true
 	case "require":
-		if code == 'N' {
-			return errors.New("pq: SSL is not enabled on the server")
-		}
-		// 如果没有根证书，标记为只验证证书
-		if _, err = os.Stat(c.Dsn.SSL.RootCert); err == nil {
-			tlsConfig.InsecureSkipVerify = true
+		// 与 libpq 一致：提供了根证书时按 verify-ca 校验，否则不校验证书
+		tlsConfig.InsecureSkipVerify = true
+		if tlsConfig.RootCAs != nil {
+			tlsConfig.VerifyPeerCertificate = verifyChain(tlsConfig.RootCAs)
 		}
 	case "verify-ca":
-		if code == 'N' {
-			return errors.New("pq: SSL is not enabled on the server")
-		}
-		tlsConfig.InsecureSkipVerify = false
+		// 只校验证书链，不校验主机名
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyPeerCertificate = verifyChain(tlsConfig.RootCAs)
 	case "verify-full":
-		if code == 'N' {
-			return errors.New("pq: SSL is not enabled on the server")
-		}
 		tlsConfig.ServerName = c.Dsn.Host
 	default:
 		return errors.New("pq: SSL unknown type")
 	}
 
-	if err = c.Dsn.SSLCheck(); err != nil {
-		return
-	}
-	tlsConfig.Renegotiation = tls.RenegotiateFreelyAsClient
-	cert, err := tls.LoadX509KeyPair(c.Dsn.SSL.Cert, c.Dsn.SSL.Key)
+	cert, err := c.Dsn.clientCertificate()
 	if err != nil {
 		return err
 	}
-	tlsConfig.Certificates = []tls.Certificate{cert}
-
-	if _, err = os.Stat(c.Dsn.SSL.RootCert); err == nil {
-		tlsConfig.RootCAs = x509.NewCertPool()
-		var raw []byte
-		raw, err = os.ReadFile(c.Dsn.SSL.RootCert)
-		if err != nil {
-			return err
-		}
-		if !tlsConfig.RootCAs.AppendCertsFromPEM(raw) {
-			return errors.New("pg: can't parse root cert")
+	var certRequested bool
+	if cert != nil {
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			certRequested = true
+			return cert, nil
 		}
 	}
 
 	// 升级至TLS
-	c.cn = tls.Client(c.cn, &tlsConfig)
+	tc := tls.Client(c.cn, &tlsConfig)
+	if err = tc.HandshakeContext(ctx); err != nil {
+		return c.handleIOError(err)
+	}
+	if c.Dsn.SSL.CertMode == "require" && !certRequested {
+		return errors.New("pg: sslcertmode=require but the server did not request a client certificate")
+	}
+	c.cn = tc
 	c.writer = frame.NewEncoder(c.cn)
 	c.reader = frame.NewDecoder(c.cn)
 	return
 }
 
 // Startup 启动
-func (c *Client) Startup() (err error) {
+func (c *Client) Startup(ctx context.Context) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	defer c.watch(ctx)(&err)
 	// 发启动指令
 	su := frame.NewStartup()
 	for k, v := range c.Dsn.Parameter {
@@ -169,6 +188,12 @@ func (c *Client) Startup() (err error) {
 
 	// 认证
 	sc := scram.NewClient(sha256.New, c.Dsn.Parameter["user"], c.Dsn.Password)
+	// 服务器是否要求过认证，用于 require_auth=none 的校验
+	var authRequested bool
+	// SCRAM 认证的进度：0 未开始，1 已发送初始消息，2 已回应 SASLContinue，3 已校验服务器签名
+	var saslStep int
+	// 是否正在进行 OAUTHBEARER 认证
+	var oauth bool
 	for {
 		d, ioErr := c.reader.Receive()
 		if ioErr != nil {
@@ -179,7 +204,14 @@ func (c *Client) Startup() (err error) {
 			return c.handlePgError(d)
 		case frame.TypeAuthRequest:
 			auth := frame.AuthRequest{Data: d}
-			switch auth.GetType() {
+			at := auth.GetType()
+			if err = c.checkAuthRequest(at, authRequested); err != nil {
+				return
+			}
+			if at != frame.AuthTypeOk {
+				authRequested = true
+			}
+			switch at {
 			case frame.AuthTypePwd:
 				ar := frame.NewAuthResponse()
 				ar.Password(c.Dsn.Password)
@@ -193,14 +225,39 @@ func (c *Client) Startup() (err error) {
 					return
 				}
 			case frame.AuthTypeSASL:
-				am := auth.GetSASLAuthMechanism()
-				if am != frame.AuthSASLSCRAMSHA256 && am != frame.AuthSASLSCRAMSHA256PLUS {
+				if saslStep != 0 || oauth {
+					return errors.New("pg: server restarted SASL authentication")
+				}
+				var scram, oauthBearer bool
+				for _, am := range auth.GetSASLAuthMechanisms() {
+					switch am {
+					case frame.AuthSASLSCRAMSHA256, frame.AuthSASLSCRAMSHA256PLUS:
+						scram = true
+					case frame.AuthSASLOAuthBearer:
+						oauthBearer = true
+					}
+				}
+				if !scram && oauthBearer {
+					if err = c.Dsn.RequireAuth.Check(AuthMethodOAuth, "server requested OAuth authentication"); err != nil {
+						return
+					}
+					if err = c.startOAuth(ctx); err != nil {
+						return
+					}
+					oauth = true
+					continue
+				}
+				if !scram {
 					return errors.New("不支持的SASL认证")
 				}
+				if err = c.Dsn.RequireAuth.Check(AuthMethodScramSHA256, "server requested SASL authentication"); err != nil {
+					return
+				}
 				sc.Step(nil)
 				if sc.Err() != nil {
 					return errors.New(fmt.Sprintf("SCRAM-SHA-256 error: %s", sc.Err().Error()))
 				}
+				saslStep = 1
 
 				ar := frame.NewAuthSASLInitialResponse()
 				ar.Mechanism(frame.AuthSASLSCRAMSHA256)
@@ -209,27 +266,44 @@ func (c *Client) Startup() (err error) {
 					return err
 				}
 			case frame.AuthTypeSASLContinue:
+				if oauth {
+					// 令牌被拒绝，内容为 RFC 7628 的错误信息
+					return c.oauthFailure(auth.GetSASLAuthData())
+				}
+				if saslStep != 1 {
+					return errors.New("pg: unexpected SASL continue message from server")
+				}
 				sc.Step(auth.GetSASLAuthData())
 				if sc.Err() != nil {
 					return errors.New(fmt.Sprintf("SCRAM-SHA-256 error: %s", sc.Err().Error()))
 				}
+				saslStep = 2
 				ar := frame.NewAuthSASLResponse()
 				ar.AuthResponse(sc.Out())
 				if err = c.writer.Send(ar.Data); err != nil {
 					return err
 				}
+			case frame.AuthTypeSASLFinal:
+				if oauth {
+					// OAUTHBEARER 成功时没有额外数据
+					continue
+				}
+				// 校验服务器签名，确认对方确实知道口令，而不仅是接受了任意的客户端证明
+				if saslStep != 2 {
+					return errors.New("pg: unexpected SASL final message from server")
+				}
+				sc.Step(auth.GetSASLAuthData())
+				if sc.Err() != nil {
+					return errors.New(fmt.Sprintf("SCRAM-SHA-256 error: %s", sc.Err().Error()))
+				}
+				saslStep = 3
 			case frame.AuthTypeOk:
-			}
-		case frame.TypeParameterStatus:
-			p := frame.ParameterStatus{Data: d}
-			p.Decode()
-			c.parameterMaps[p.Name] = p.Value
-			if p.Name == "TimeZone" {
-				c.Location, err = time.LoadLocation(p.Value)
-				if err != nil {
-					c.Location = nil
+				if saslStep != 0 && saslStep != 3 {
+					return errors.New("pg: server accepted the connection before SCRAM-SHA-256 authentication completed")
 				}
 			}
+		case frame.TypeParameterStatus:
+			c.handleParameterStatus(d)
 		case frame.TypeBackendKeyData:
 			bkd := frame.BackendKeyData{Data: d}
 			bkd.Decode()
@@ -243,7 +317,33 @@ func (c *Client) Startup() (err error) {
 	}
 }
 
-func (c *Client) QueryNoArgs(query string) (res SimpleQueryResponse, err error) {
+// checkAuthRequest 按 require_auth 校验服务器发来的认证请求，不允许时在发送凭据之前中止
+func (c *Client) checkAuthRequest(at uint32, authRequested bool) error {
+	ra := c.Dsn.RequireAuth
+	switch at {
+	case frame.AuthTypeOk:
+		if !authRequested {
+			return ra.Check(AuthMethodNone, "server did not complete authentication")
+		}
+	case frame.AuthTypePwd:
+		return ra.Check(AuthMethodPassword, "server requested a cleartext password")
+	case frame.AuthTypeMd5:
+		return ra.Check(AuthMethodMd5, "server requested a hashed password")
+	case frame.AuthTypeGSS, frame.AuthTypeGSSContinue:
+		return ra.Check(AuthMethodGSS, "server requested GSSAPI authentication")
+	case frame.AuthTypeSSPI:
+		return ra.Check(AuthMethodSSPI, "server requested SSPI authentication")
+	case frame.AuthTypeKerberosV5:
+		return ra.Check("", "server requested Kerberos V5 authentication")
+	}
+	return nil
+}
+
+func (c *Client) QueryNoArgs(ctx context.Context, query string) (res SimpleQueryResponse, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	defer c.watch(ctx)(&err)
 	if err = c.writer.Send(frame.NewSimpleQuery(query)); err != nil {
 		return res, c.handleIOError(err)
 	}
@@ -265,6 +365,8 @@ func (c *Client) QueryNoArgs(query string) (res SimpleQueryResponse, err error)
 		case frame.TypeCommandCompletion:
 			d := frame.CommandCompletion{Data: f}
 			res.Completion = &d
+		case frame.TypeParameterStatus:
+			c.handleParameterStatus(f)
 		case frame.TypeReadyForQuery:
 			c.status = frame.TransactionStatus(f.Payload()[0])
 			return
@@ -274,7 +376,11 @@ func (c *Client) QueryNoArgs(query string) (res SimpleQueryResponse, err error)
 	}
 }
 
-func (c *Client) Parse(name, query string) (res ParseResponse, err error) {
+func (c *Client) Parse(ctx context.Context, name, query string) (res ParseResponse, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	defer c.watch(ctx)(&err)
 	if err = c.writer.Buff(frame.NewParse(name, query)); err != nil {
 		return res, c.handleIOError(err)
 	}
@@ -304,6 +410,8 @@ func (c *Client) Parse(name, query string) (res ParseResponse, err error) {
 			d := frame.RowDescription{Data: f}
 			d.Decode()
 			res.Rows = &d
+		case frame.TypeParameterStatus:
+			c.handleParameterStatus(f)
 		case frame.TypeReadyForQuery:
 			c.status = frame.TransactionStatus(f.Payload()[0])
 			return
@@ -313,8 +421,13 @@ func (c *Client) Parse(name, query string) (res ParseResponse, err error) {
 	}
 }
 
-func (c *Client) BindExec(name string, args []driver.Value) (res BindExecResponse, err error) {
-	if err = c.writer.Buff(frame.NewBind(name, args)); err != nil {
+// BindExec 绑定参数并执行，paramFormats、resultFormats 的含义见 frame.NewBind
+func (c *Client) BindExec(ctx context.Context, name string, args []driver.Value, paramFormats, resultFormats []uint16) (res BindExecResponse, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	defer c.watch(ctx)(&err)
+	if err = c.writer.Buff(frame.NewBind(name, args, paramFormats, resultFormats)); err != nil {
 		return res, c.handleIOError(err)
 	}
 	if err = c.writer.Buff(frame.NewExecute()); err != nil {
@@ -344,6 +457,8 @@ func (c *Client) BindExec(name string, args []driver.Value) (res BindExecRespons
 		case frame.TypeCommandCompletion:
 			d := frame.CommandCompletion{Data: f}
 			res.Completion = &d
+		case frame.TypeParameterStatus:
+			c.handleParameterStatus(f)
 		case frame.TypeReadyForQuery:
 			c.status = frame.TransactionStatus(f.Payload()[0])
 			return
@@ -353,7 +468,11 @@ func (c *Client) BindExec(name string, args []driver.Value) (res BindExecRespons
 	}
 }
 
-func (c *Client) CloseParse(name string) (err error) {
+func (c *Client) CloseParse(ctx context.Context, name string) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	defer c.watch(ctx)(&err)
 	if err = c.writer.Buff(frame.NewCloseStat(name)); err != nil {
 		return c.handleIOError(err)
 	}
@@ -379,7 +498,9 @@ func (c *Client) CloseParse(name string) (err error) {
 }
 
 func (c *Client) Listen(channel string) (err error) {
-	_, err = c.QueryNoArgs("listen " + channel)
+	ctx, cancel := c.BoundedContext()
+	defer cancel()
+	_, err = c.QueryNoArgs(ctx, "listen "+channel)
 	return
 }
 
@@ -421,6 +542,18 @@ func (c *Client) CloseConn() (err error) {
 	return c.cn.Close()
 }
 
+// TimestampLocation timestamp、date、time 等不带时区的值在 Go 中对应的时区
+// 由连接参数 timestamp_location 指定，默认与服务器的 TimeZone 一致；服务器的 TimeZone 无法识别时返回错误
+func (c *Client) TimestampLocation() (*time.Location, error) {
+	if c.Dsn.TimestampLocation != nil {
+		return c.Dsn.TimestampLocation, nil
+	}
+	if c.Location == nil {
+		return nil, c.timeZoneErr
+	}
+	return c.Location, nil
+}
+
 func (c *Client) IsInTransaction() bool {
 	return c.status == frame.TransactionStatusIdleInTransaction || c.status == frame.TransactionStatusInFailedTransaction
 }
@@ -431,6 +564,69 @@ func (c *Client) handleIOError(err error) error {
 	return err
 }
 
+// handleParameterStatus 记录服务器提供的属性参数，启动时及执行 SET 之后都会收到
+func (c *Client) handleParameterStatus(d *frame.Data) {
+	p := frame.ParameterStatus{Data: d}
+	p.Decode()
+	c.parameterMaps[p.Name] = p.Value
+	if p.Name == "TimeZone" {
+		c.Location, c.timeZoneErr = parseTimeZone(p.Value)
+	}
+}
+
+// parseTimeZone 解析服务器的 TimeZone，除 IANA 时区名外，也接受 SET TIME ZONE 8 等产生的 POSIX 固定偏移，如 <+08>-08、UTC+8
+// 带夏令时规则的 POSIX 时区无法在 Go 中表示，返回错误而不是猜测一个时区
+func parseTimeZone(name string) (*time.Location, error) {
+	if loc, err := time.LoadLocation(name); err == nil {
+		return loc, nil
+	}
+	var s, abbr = name, ""
+	if strings.HasPrefix(s, "<") {
+		if p := strings.IndexByte(s, '>'); p > 1 {
+			abbr, s = s[1:p], s[p+1:]
+		}
+	} else {
+		var p = strings.IndexAny(s, "+-0123456789")
+		if p >= 3 && strings.IndexFunc(s[:p], func(r rune) bool { return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z') }) < 0 {
+			abbr, s = s[:p], s[p:]
+		}
+	}
+	if abbr != "" && s != "" {
+		if s[0] != '+' && s[0] != '-' {
+			s = "+" + s
+		}
+		if offset, err := parsePosixOffset(s); err == nil {
+			// POSIX 的偏移与 ISO 8601 相反，正数表示在 UTC 以西
+			return time.FixedZone(abbr, -offset), nil
+		}
+	}
+	return nil, fmt.Errorf("pg: server TimeZone %q is not supported, set timestamp_location to decode timestamp, date and time values", name)
+}
+
+// parsePosixOffset 解析 +h[h][:mm[:ss]] 形式的偏移，返回秒数
+func parsePosixOffset(s string) (int, error) {
+	var parts = strings.Split(s[1:], ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid offset %q", s)
+	}
+	var offset, unit = 0, 3600
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || len(part) > 2 || i > 0 && (len(part) != 2 || n > 59) {
+			return 0, fmt.Errorf("invalid offset %q", s)
+		}
+		offset += n * unit
+		unit /= 60
+	}
+	if offset > 24*3600 {
+		return 0, fmt.Errorf("invalid offset %q", s)
+	}
+	if s[0] == '-' {
+		offset = -offset
+	}
+	return offset, nil
+}
+
 func (c *Client) handlePgError(d *frame.Data) error {
 	e := frame.Error{Data: d}
 	e.Decode()
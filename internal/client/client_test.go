@@ -0,0 +1,339 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/blusewang/pg/v2/internal/client/frame"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeServer 在 net.Pipe 的另一端模拟 PostgreSQL 的 SCRAM-SHA-256 认证过程
+type fakeServer struct {
+	cn       net.Conn
+	password string
+	// final 根据正确的服务器签名生成 SASLFinal 的内容，返回 nil 时不发送 SASLFinal
+	final func(signature string) []byte
+}
+
+func (s *fakeServer) readStartup() error {
+	var l = make([]byte, 4)
+	if _, err := io.ReadFull(s.cn, l); err != nil {
+		return err
+	}
+	_, err := io.ReadFull(s.cn, make([]byte, binary.BigEndian.Uint32(l)-4))
+	return err
+}
+
+func (s *fakeServer) readMessage() (typ byte, payload []byte, err error) {
+	var head = make([]byte, 5)
+	if _, err = io.ReadFull(s.cn, head); err != nil {
+		return
+	}
+	payload = make([]byte, binary.BigEndian.Uint32(head[1:])-4)
+	_, err = io.ReadFull(s.cn, payload)
+	return head[0], payload, err
+}
+
+func (s *fakeServer) writeMessage(typ byte, payload []byte) error {
+	var b = []byte{typ}
+	b = binary.BigEndian.AppendUint32(b, uint32(len(payload)+4))
+	_, err := s.cn.Write(append(b, payload...))
+	return err
+}
+
+func (s *fakeServer) writeAuth(at uint32, data []byte) error {
+	return s.writeMessage('R', append(binary.BigEndian.AppendUint32(nil, at), data...))
+}
+
+// serve 返回服务器一侧发现的错误，如客户端证明不正确
+func (s *fakeServer) serve() error {
+	if err := s.readStartup(); err != nil {
+		return err
+	}
+	if err := s.writeAuth(frame.AuthTypeSASL, []byte(frame.AuthSASLSCRAMSHA256+"\x00\x00")); err != nil {
+		return err
+	}
+
+	// SASLInitialResponse：机制名、4 字节长度、client-first-message
+	_, payload, err := s.readMessage()
+	if err != nil {
+		return err
+	}
+	var p = bytes.IndexByte(payload, 0)
+	var clientFirst = string(payload[p+5:])
+	if !strings.HasPrefix(clientFirst, "n,,") {
+		return fmt.Errorf("unexpected client-first-message %q", clientFirst)
+	}
+	var clientFirstBare = clientFirst[3:]
+	var clientNonce = clientFirstBare[strings.Index(clientFirstBare, ",r=")+3:]
+
+	var salt = []byte("0123456789abcdef")
+	var serverFirst = "r=" + clientNonce + "server-nonce,s=" + base64.StdEncoding.EncodeToString(salt) + ",i=4096"
+	if err = s.writeAuth(frame.AuthTypeSASLContinue, []byte(serverFirst)); err != nil {
+		return err
+	}
+
+	// SASLResponse：client-final-message
+	_, payload, err = s.readMessage()
+	if err != nil {
+		return err
+	}
+	var clientFinal = string(payload)
+	var withoutProof = clientFinal[:strings.LastIndex(clientFinal, ",p=")]
+	var authMsg = clientFirstBare + "," + serverFirst + "," + withoutProof
+
+	var salted = hi([]byte(s.password), salt, 4096)
+	var clientKey = hmacSum(salted, "Client Key")
+	var storedKey = sha256.Sum256(clientKey)
+	var proof = hmacSum(storedKey[:], authMsg)
+	for i := range proof {
+		proof[i] ^= clientKey[i]
+	}
+	if clientFinal[len(withoutProof)+3:] != base64.StdEncoding.EncodeToString(proof) {
+		return errors.New("invalid client proof")
+	}
+
+	var signature = base64.StdEncoding.EncodeToString(hmacSum(hmacSum(salted, "Server Key"), authMsg))
+	if final := s.final(signature); final != nil {
+		if err = s.writeAuth(frame.AuthTypeSASLFinal, final); err != nil {
+			return err
+		}
+	}
+	if err = s.writeAuth(frame.AuthTypeOk, nil); err != nil {
+		return err
+	}
+	return s.writeMessage('Z', []byte{'I'})
+}
+
+func hmacSum(key []byte, msg string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(msg))
+	return mac.Sum(nil)
+}
+
+func hi(password, salt []byte, iter int) []byte {
+	mac := hmac.New(sha256.New, password)
+	mac.Write(salt)
+	mac.Write([]byte{0, 0, 0, 1})
+	u := mac.Sum(nil)
+	result := append([]byte(nil), u...)
+	for i := 1; i < iter; i++ {
+		mac.Reset()
+		mac.Write(u)
+		u = mac.Sum(u[:0])
+		for j := range result {
+			result[j] ^= u[j]
+		}
+	}
+	return result
+}
+
+// startupWith 让客户端与模拟服务器完成启动过程，返回客户端的错误
+func startupWith(t *testing.T, final func(signature string) []byte) error {
+	t.Helper()
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	var server = &fakeServer{cn: serverConn, password: "secret", final: final}
+	var serverErr = make(chan error, 1)
+	go func() {
+		serverErr <- server.serve()
+	}()
+
+	var c = NewClient()
+	c.Dsn.Parameter = map[string]string{"user": "alice"}
+	c.Dsn.Password = "secret"
+	c.cn = clientConn
+	c.writer = frame.NewEncoder(clientConn)
+	c.reader = frame.NewDecoder(clientConn)
+	err := c.Startup(context.Background())
+	if err == nil {
+		if e := <-serverErr; e != nil {
+			t.Fatalf("server: %v", e)
+		}
+	}
+	return err
+}
+
+func TestStartupSCRAMVerifiesServerSignature(t *testing.T) {
+	err := startupWith(t, func(signature string) []byte {
+		return []byte("v=" + signature)
+	})
+	if err != nil {
+		t.Fatalf("expected authentication to succeed, got %v", err)
+	}
+}
+
+func TestStartupSCRAMRejectsWrongServerSignature(t *testing.T) {
+	err := startupWith(t, func(string) []byte {
+		return []byte("v=" + base64.StdEncoding.EncodeToString(make([]byte, sha256.Size)))
+	})
+	if err == nil || !strings.Contains(err.Error(), "server signature") {
+		t.Fatalf("expected a server signature error, got %v", err)
+	}
+}
+
+func TestStartupSCRAMRejectsServerError(t *testing.T) {
+	err := startupWith(t, func(string) []byte {
+		return []byte("e=invalid-proof")
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid-proof") {
+		t.Fatalf("expected the server error to be reported, got %v", err)
+	}
+}
+
+func TestStartupSCRAMRejectsOkWithoutFinal(t *testing.T) {
+	err := startupWith(t, func(string) []byte {
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "before SCRAM-SHA-256 authentication completed") {
+		t.Fatalf("expected AuthenticationOk to be refused, got %v", err)
+	}
+}
+
+// startupOAuth 让客户端与只接受 OAUTHBEARER 的模拟服务器完成启动过程；accept 为 false 时服务器拒绝令牌
+func startupOAuth(t *testing.T, accept bool) (token string, err error) {
+	t.Helper()
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	var server = &fakeServer{cn: serverConn}
+	var serverErr = make(chan error, 1)
+	go func() {
+		serverErr <- func() error {
+			if err := server.readStartup(); err != nil {
+				return err
+			}
+			if err := server.writeAuth(frame.AuthTypeSASL, []byte(frame.AuthSASLOAuthBearer+"\x00\x00")); err != nil {
+				return err
+			}
+			_, payload, err := server.readMessage()
+			if err != nil {
+				return err
+			}
+			var p = bytes.IndexByte(payload, 0)
+			var initial = string(payload[p+5:])
+			if !strings.HasPrefix(initial, "n,,\x01auth=Bearer ") || !strings.HasSuffix(initial, "\x01\x01") {
+				return fmt.Errorf("unexpected client-initial-response %q", initial)
+			}
+			token = strings.TrimSuffix(strings.TrimPrefix(initial, "n,,\x01auth=Bearer "), "\x01\x01")
+			if accept {
+				if err = server.writeAuth(frame.AuthTypeOk, nil); err != nil {
+					return err
+				}
+				return server.writeMessage('Z', []byte{'I'})
+			}
+			var discovery = `{"status":"invalid_token","scope":"openid db","openid-configuration":"https://id.example.com/.well-known/openid-configuration"}`
+			if err = server.writeAuth(frame.AuthTypeSASLContinue, []byte(discovery)); err != nil {
+				return err
+			}
+			if _, payload, err = server.readMessage(); err != nil {
+				return err
+			}
+			if !bytes.Equal(payload, []byte{0x01}) {
+				return fmt.Errorf("unexpected client response %q", payload)
+			}
+			return server.writeMessage('E', []byte("SFATAL\x00C28000\x00MOAuth bearer authentication failed\x00\x00"))
+		}()
+	}()
+
+	var c = NewClient()
+	c.Dsn.Parameter = map[string]string{"user": "alice"}
+	c.Dsn.OAuth.Scope = "openid"
+	c.Dsn.OAuth.TokenProvider = func(ctx context.Context, req OAuthTokenRequest) (string, error) {
+		return "token-for-" + req.Scope, nil
+	}
+	c.cn = clientConn
+	c.writer = frame.NewEncoder(clientConn)
+	c.reader = frame.NewDecoder(clientConn)
+	err = c.Startup(context.Background())
+	if e := <-serverErr; e != nil {
+		t.Fatalf("server: %v", e)
+	}
+	return token, err
+}
+
+func TestStartupOAuthBearer(t *testing.T) {
+	token, err := startupOAuth(t, true)
+	if err != nil {
+		t.Fatalf("expected authentication to succeed, got %v", err)
+	}
+	if token != "token-for-openid" {
+		t.Fatalf("unexpected token %q", token)
+	}
+}
+
+func TestStartupOAuthBearerDiscovery(t *testing.T) {
+	_, err := startupOAuth(t, false)
+	var oe *OAuthError
+	if !errors.As(err, &oe) {
+		t.Fatalf("expected *OAuthError, got %v", err)
+	}
+	if oe.Status != "invalid_token" || oe.Scope != "openid db" || oe.DiscoveryURI != "https://id.example.com/.well-known/openid-configuration" {
+		t.Fatalf("unexpected discovery response %+v", oe)
+	}
+	if oe.Err == nil {
+		t.Fatal("expected the server error to be attached")
+	}
+}
+
+func TestParseTimeZone(t *testing.T) {
+	var ref = time.Date(2024, time.July, 1, 12, 0, 0, 0, time.UTC)
+	var cases = []struct {
+		in     string
+		offset int
+		err    bool
+	}{
+		{in: "UTC", offset: 0},
+		{in: "Asia/Shanghai", offset: 8 * 3600},
+		{in: "<+08>-08", offset: 8 * 3600},
+		{in: "<-0330>+03:30", offset: -(3*3600 + 30*60)},
+		{in: "UTC+8", offset: -8 * 3600},
+		{in: "ABC-5:45", offset: 5*3600 + 45*60},
+		{in: "EST5EDT,M3.2.0,M11.1.0", err: true},
+		{in: "Mars/Olympus_Mons", err: true},
+		{in: "+08", err: true},
+		{in: "<+08>", err: true},
+		{in: "XYZ+25", err: true},
+	}
+	for _, c := range cases {
+		loc, err := parseTimeZone(c.in)
+		if c.err {
+			if err == nil {
+				t.Errorf("parseTimeZone(%q) should fail, got %v", c.in, loc)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseTimeZone(%q): %v", c.in, err)
+			continue
+		}
+		if _, offset := ref.In(loc).Zone(); offset != c.offset {
+			t.Errorf("parseTimeZone(%q) offset = %d, want %d", c.in, offset, c.offset)
+		}
+	}
+}
+
+func TestTimestampLocation(t *testing.T) {
+	var c = NewClient()
+	c.Location, c.timeZoneErr = parseTimeZone("EST5EDT,M3.2.0,M11.1.0")
+	if loc, err := c.TimestampLocation(); err == nil {
+		t.Errorf("TimestampLocation() = %v, want an error for an unsupported server TimeZone", loc)
+	}
+	c.Dsn.TimestampLocation = time.UTC
+	if loc, err := c.TimestampLocation(); err != nil || loc != time.UTC {
+		t.Errorf("TimestampLocation() = %v, %v, want UTC from timestamp_location", loc, err)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2026 YBCZ, Inc. All rights reserved.
+//
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file in the root of the source
+// tree.
+
+package client
+
+import (
+	"fmt"
+	"strings"
+)
+
+// connInfoKeywords libpq 认识的连接参数，以及本驱动自己的参数
+// 严格模式下只接受这些参数，其余的服务器参数应通过 options 传递
+var connInfoKeywords = map[string]bool{
+	"host": true, "hostaddr": true, "port": true, "dbname": true, "user": true, "password": true,
+	"passfile": true, "require_auth": true, "channel_binding": true, "connect_timeout": true,
+	"client_encoding": true, "options": true, "application_name": true, "fallback_application_name": true,
+	"keepalives": true, "keepalives_idle": true, "keepalives_interval": true, "keepalives_count": true,
+	"tcp_user_timeout": true, "replication": true, "gssencmode": true,
+	"sslmode": true, "sslnegotiation": true, "sslcompression": true, "sslcert": true, "sslkey": true,
+	"sslpassword": true, "sslcertmode": true, "sslrootcert": true, "sslcrl": true, "sslcrldir": true,
+	"sslsni": true, "requirepeer": true, "ssl_min_protocol_version": true, "ssl_max_protocol_version": true,
+	"min_protocol_version": true, "max_protocol_version": true,
+	"krbsrvname": true, "gsslib": true, "gssdelegation": true, "service": true,
+	"target_session_attrs": true, "load_balance_hosts": true,
+	"oauth_issuer": true, "oauth_client_id": true, "oauth_client_secret": true, "oauth_scope": true,
+	"timestamp_location": true,
+}
+
+// parseConnInfo 按 libpq 的语法解析 keyword = value 形式的连接串
+// 关键字与值之间的等号两侧可以有空白；值可以用单引号包围，其中可以出现空白；
+// 无论是否有引号，反斜杠都会转义下一个字符。同一关键字出现多次时以最后一次为准
+func parseConnInfo(s string) (map[string]string, error) {
+	var p = make(map[string]string)
+	var i = 0
+	for {
+		for i < len(s) && isConnInfoSpace(s[i]) {
+			i++
+		}
+		if i == len(s) {
+			return p, nil
+		}
+
+		var start = i
+		for i < len(s) && s[i] != '=' && !isConnInfoSpace(s[i]) {
+			i++
+		}
+		var keyword = s[start:i]
+		for i < len(s) && isConnInfoSpace(s[i]) {
+			i++
+		}
+		if i == len(s) || s[i] != '=' {
+			return nil, fmt.Errorf(`pg: missing "=" after %q in connection info string`, keyword)
+		}
+		if keyword == "" {
+			return nil, fmt.Errorf(`pg: missing keyword before "=" at offset %d in connection info string`, i)
+		}
+		i++
+		for i < len(s) && isConnInfoSpace(s[i]) {
+			i++
+		}
+
+		var value strings.Builder
+		if i < len(s) && s[i] == '\'' {
+			i++
+			for {
+				if i == len(s) {
+					return nil, fmt.Errorf("pg: unterminated quoted string in connection info string")
+				}
+				if s[i] == '\\' {
+					i++
+					if i < len(s) {
+						value.WriteByte(s[i])
+						i++
+					}
+				} else if s[i] == '\'' {
+					i++
+					break
+				} else {
+					value.WriteByte(s[i])
+					i++
+				}
+			}
+		} else {
+			for i < len(s) && !isConnInfoSpace(s[i]) {
+				if s[i] == '\\' {
+					i++
+					if i == len(s) {
+						break
+					}
+				}
+				value.WriteByte(s[i])
+				i++
+			}
+		}
+		p[keyword] = value.String()
+	}
+}
+
+// isConnInfoSpace 同 C 的 isspace
+func isConnInfoSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\v' || c == '\f' || c == '\r'
+}
+
+// checkConnInfoKeyword 严格模式下检查连接参数是否有效
+func checkConnInfoKeyword(keyword string) error {
+	if connInfoKeywords[keyword] {
+		return nil
+	}
+	if connInfoKeywords[strings.ToLower(keyword)] {
+		return fmt.Errorf("pg: invalid connection option %q (keywords are case sensitive, did you mean %q?)", keyword, strings.ToLower(keyword))
+	}
+	return fmt.Errorf("pg: invalid connection option %q; pass server settings through options, e.g. options='-c %s=...'", keyword, keyword)
+}
+
+// quoteConnInfoValue 必要时给值加上单引号，并转义其中的反斜杠及单引号
+func quoteConnInfoValue(v string) string {
+	if v != "" && !strings.ContainsAny(v, "'\\ \t\n\v\f\r") {
+		return v
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+}
+
+// formatConnInfo 把参数组成 keyword = value 形式的连接串
+func formatConnInfo(kv [][2]string) string {
+	var b strings.Builder
+	for i, item := range kv {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(item[0])
+		b.WriteByte('=')
+		b.WriteString(quoteConnInfoValue(item[1]))
+	}
+	return b.String()
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// connInfoCorpus libpq 的 PQconninfoParse 能接受的连接串及其解析结果
+var connInfoCorpus = []struct {
+	in   string
+	want map[string]string
+}{
+	{"", map[string]string{}},
+	{"   \t\n ", map[string]string{}},
+	{"host=localhost", map[string]string{"host": "localhost"}},
+	{"host=localhost port=5433 dbname=app user=alice", map[string]string{"host": "localhost", "port": "5433", "dbname": "app", "user": "alice"}},
+	{"  host = localhost   port=\t5433  ", map[string]string{"host": "localhost", "port": "5433"}},
+	{"password='a b'", map[string]string{"password": "a b"}},
+	{`password='it\'s'`, map[string]string{"password": "it's"}},
+	{`password=it\'s`, map[string]string{"password": "it's"}},
+	{`password='back\\slash'`, map[string]string{"password": `back\slash`}},
+	{`password=a\ b`, map[string]string{"password": "a b"}},
+	{"password=a=b=c", map[string]string{"password": "a=b=c"}},
+	{"password=''", map[string]string{"password": ""}},
+	{"password=", map[string]string{"password": ""}},
+	{"password= host=db", map[string]string{"password": "host=db"}},
+	{"options='-c search_path=app -c statement_timeout=5s'", map[string]string{"options": "-c search_path=app -c statement_timeout=5s"}},
+	{"host='db' port='5432'dbname=app", map[string]string{"host": "db", "port": "5432", "dbname": "app"}},
+	{"user=a user=b", map[string]string{"user": "b"}},
+	{"application_name='日本語 アプリ'", map[string]string{"application_name": "日本語 アプリ"}},
+	{`sslrootcert=C:\\certs\\root.crt`, map[string]string{"sslrootcert": `C:\certs\root.crt`}},
+}
+
+func TestParseConnInfo(t *testing.T) {
+	for _, c := range connInfoCorpus {
+		got, err := parseConnInfo(c.in)
+		if err != nil {
+			t.Errorf("parseConnInfo(%q): %v", c.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("parseConnInfo(%q) = %v, want %v", c.in, got, c.want)
+		}
+	}
+}
+
+func TestParseConnInfoErrors(t *testing.T) {
+	for _, in := range []string{
+		"host",
+		"host localhost",
+		"=localhost",
+		"password='unterminated",
+		`password='escaped\'`,
+	} {
+		if _, err := parseConnInfo(in); err == nil {
+			t.Errorf("parseConnInfo(%q) should fail", in)
+		}
+	}
+}
+
+func TestParseDSNPort(t *testing.T) {
+	dsn, err := ParseDSN("host=db port=6543 password='a b'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dsn.Port != "6543" || dsn.Password != "a b" {
+		t.Fatalf("unexpected port %q or password %q", dsn.Port, dsn.Password)
+	}
+	if _, has := dsn.Parameter["port"]; has {
+		t.Fatal("port must not be sent as a server parameter")
+	}
+}
+
+func TestParseDSNStrict(t *testing.T) {
+	if _, err := ParseDSNStrict("host=db options='-c search_path=app' timestamp_location=utc"); err != nil {
+		t.Fatal(err)
+	}
+	for _, in := range []string{
+		"host=db search_path=app",
+		"Host=db",
+		"postgres://db/app?search_path=app",
+	} {
+		if _, err := ParseDSNStrict(in); err == nil || !strings.Contains(err.Error(), "invalid connection option") {
+			t.Errorf("ParseDSNStrict(%q) = %v, want an invalid connection option error", in, err)
+		}
+	}
+}
+
+// sortedConnInfo 把解析结果按关键字排序后重新组成连接串
+func sortedConnInfo(kv map[string]string) string {
+	var keys = make([]string, 0, len(kv))
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var items = make([][2]string, len(keys))
+	for i, k := range keys {
+		items[i] = [2]string{k, kv[k]}
+	}
+	return formatConnInfo(items)
+}
+
+func FuzzParseConnInfo(f *testing.F) {
+	for _, c := range connInfoCorpus {
+		f.Add(c.in)
+	}
+	f.Fuzz(func(t *testing.T, in string) {
+		kv, err := parseConnInfo(in)
+		if err != nil {
+			if !strings.HasPrefix(err.Error(), "pg: ") {
+				t.Fatalf("unexpected error format: %v", err)
+			}
+			return
+		}
+		for k := range kv {
+			if k == "" || strings.ContainsAny(k, "= \t\n\v\f\r") {
+				t.Fatalf("invalid keyword %q parsed from %q", k, in)
+			}
+		}
+		// 重新组成的连接串应当解析出相同的结果
+		again, err := parseConnInfo(sortedConnInfo(kv))
+		if err != nil {
+			t.Fatalf("reparse of %q: %v", sortedConnInfo(kv), err)
+		}
+		if !reflect.DeepEqual(kv, again) {
+			t.Fatalf("round trip of %q: %v != %v", in, kv, again)
+		}
+	})
+}
@@ -0,0 +1,77 @@
+// Copyright 2026 YBCZ, Inc. All rights reserved.
+//
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file in the root of the source
+// tree.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+)
+
+// aLongTimeAgo 用于立即打断阻塞中的读写
+var aLongTimeAgo = time.Unix(1, 0)
+
+// defaultOperationTimeout connect_timeout 为 0（不限）时，BoundedContext 使用的期限
+const defaultOperationTimeout = time.Minute
+
+// BoundedContext 以 connect_timeout 为期限的上下文，用于调用方没有提供上下文的内部短操作，
+// 如 begin、rollback、listen，避免连接已失效时永远阻塞
+func (c *Client) BoundedContext() (context.Context, context.CancelFunc) {
+	return c.Dsn.BoundedContext()
+}
+
+// BoundedContext 见 Client.BoundedContext，建立连接时也使用该期限
+func (dsn DataSourceName) BoundedContext() (context.Context, context.CancelFunc) {
+	var timeout = dsn.ConnectTimeout
+	if timeout <= 0 {
+		timeout = defaultOperationTimeout
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// watch 按上下文为本次操作设置连接的读写期限，上下文被取消时立即打断阻塞中的读写。
+// 操作结束时须调用返回的函数：它清除期限，并在超时或取消时把错误替换为上下文的错误。
+// 超时或取消后连接上可能残留未读完的响应，此时连接已由 handleIOError 标记为断开。
+//
+//	defer c.watch(ctx)(&err)
+func (c *Client) watch(ctx context.Context) func(*error) {
+	// TLS 连接的期限设置在底层连接上，升级前后始终使用同一个底层连接
+	var cn = c.cn
+	if deadline, has := ctx.Deadline(); has {
+		_ = cn.SetDeadline(deadline)
+	}
+	var stop, stopped chan struct{}
+	if ctx.Done() != nil {
+		stop, stopped = make(chan struct{}), make(chan struct{})
+		go func() {
+			defer close(stopped)
+			select {
+			case <-ctx.Done():
+				_ = cn.SetDeadline(aLongTimeAgo)
+			case <-stop:
+			}
+		}()
+	}
+	return func(err *error) {
+		if stop != nil {
+			close(stop)
+			<-stopped
+		}
+		if c.ConnectStatus != ConnectStatusDisconnected {
+			_ = cn.SetDeadline(time.Time{})
+		}
+		if err != nil && errors.Is(*err, os.ErrDeadlineExceeded) {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				*err = ctxErr
+			} else if deadline, has := ctx.Deadline(); has && !time.Now().Before(deadline) {
+				// 连接的期限与上下文的期限同时到达，上下文的计时器可能还没来得及触发
+				*err = context.DeadlineExceeded
+			}
+		}
+	}
+}
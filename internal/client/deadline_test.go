@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/blusewang/pg/v2/internal/client/frame"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// silentClient 返回连接到一个只读取、从不应答的模拟服务器的客户端
+func silentClient(t *testing.T) *Client {
+	t.Helper()
+	clientConn, serverConn := net.Pipe()
+	t.Cleanup(func() {
+		_ = clientConn.Close()
+		_ = serverConn.Close()
+	})
+	go func() {
+		_, _ = io.Copy(io.Discard, serverConn)
+	}()
+	var c = NewClient()
+	c.ConnectStatus = ConnectStatusConnected
+	c.cn = clientConn
+	c.writer = frame.NewEncoder(clientConn)
+	c.reader = frame.NewDecoder(clientConn)
+	return c
+}
+
+func TestWatchCancel(t *testing.T) {
+	var c = silentClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	var start = time.Now()
+	_, err := c.QueryNoArgs(ctx, "select 1")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("cancellation did not unblock the read")
+	}
+}
+
+func TestWatchDeadline(t *testing.T) {
+	var c = silentClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := c.QueryNoArgs(ctx, "select 1")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if c.ConnectStatus != ConnectStatusDisconnected {
+		t.Fatal("the connection must be marked as broken after an interrupted read")
+	}
+}
+
+func TestWatchDoneContext(t *testing.T) {
+	var c = silentClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.QueryNoArgs(ctx, "select 1"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if c.ConnectStatus == ConnectStatusDisconnected {
+		t.Fatal("nothing was sent, the connection should still be usable")
+	}
+}
+
+func TestBoundedContext(t *testing.T) {
+	var c = silentClient(t)
+	c.Dsn.ConnectTimeout = 50 * time.Millisecond
+	ctx, cancel := c.BoundedContext()
+	defer cancel()
+	if _, err := c.QueryNoArgs(ctx, "rollback"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	c.Dsn.ConnectTimeout = 0
+	ctx, cancel = c.BoundedContext()
+	defer cancel()
+	if deadline, has := ctx.Deadline(); !has || time.Until(deadline) > defaultOperationTimeout {
+		t.Fatalf("connect_timeout=0 should fall back to %v, got %v", defaultOperationTimeout, deadline)
+	}
+}
@@ -7,41 +7,75 @@
 package client
 
 import (
-	"errors"
 	"fmt"
 	"net"
-	"net/url"
 	"os"
 	"os/user"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 type DataSourceName struct {
-	Host           string
-	Port           string
-	Password       string
-	ConnectTimeout time.Duration
-	Parameter      map[string]string
-	SSL            struct {
+	Host              string
+	Port              string
+	Password          string
+	ConnectTimeout    time.Duration
+	RequireAuth       RequireAuth
+	TCPUserTimeout    time.Duration
+	TimestampLocation *time.Location // timestamp、date、time 在 Go 中对应的时区，为 nil 时使用服务器的 TimeZone
+	Parameter         map[string]string
+	OAuth             struct {
+		Issuer        string
+		ClientID      string
+		Scope         string
+		TokenProvider OAuthTokenProvider // 无法通过连接串设置，由 Connector 提供
+	}
+	KeepAlive struct {
+		Enable   bool
+		Idle     time.Duration
+		Interval time.Duration
+		Count    int
+	}
+	SSL struct {
 		Mode        string
 		Cert        string
 		Key         string
 		RootCert    string
 		Crl         string
 		Compression int
+		// Password 解密加密私钥所用的口令，即 sslpassword
+		Password string
+		// CertMode 即 sslcertmode：disable 不发送客户端证书；allow 有证书时发送（默认）；
+		// require 必须发送，服务器未要求客户端证书时连接失败
+		CertMode string
+		// CertPEM、KeyPEM、RootCertPEM 通过 Connector 提供的内存中的 PEM，设置后优先于 sslcert、sslkey、sslrootcert
+		CertPEM     []byte
+		KeyPEM      []byte
+		RootCertPEM []byte
 	}
 }
 
 func ParseDSN(connectStr string) (dsn DataSourceName, err error) {
+	return parseDSN(connectStr, false)
+}
+
+// ParseDSNStrict 同 ParseDSN，但与 libpq 一样拒绝未知的连接参数，且关键字区分大小写
+// 服务器参数需通过 options 传递，如 options='-c search_path=app'
+func ParseDSNStrict(connectStr string) (dsn DataSourceName, err error) {
+	return parseDSN(connectStr, true)
+}
+
+func parseDSN(connectStr string, strict bool) (dsn DataSourceName, err error) {
 	dsn.setDefault()
-	if strings.Contains(connectStr, "://") {
-		err = dsn.parseURI(connectStr)
+	if isURI(connectStr) {
+		err = dsn.parseURI(connectStr, strict)
 	} else {
-		err = dsn.parseDSN(connectStr)
+		err = dsn.parseConnInfo(connectStr, strict)
 	}
 	return
 }
@@ -54,8 +88,10 @@ func (dsn *DataSourceName) setDefault() {
 	dsn.Parameter["DateStyle"] = "ISO, YMD"
 	dsn.Parameter["client_encoding"] = "UTF8"
 	dsn.ConnectTimeout = time.Duration(60) * time.Second
+	dsn.KeepAlive.Enable = true
 	dsn.SSL.Compression = 1
 	dsn.SSL.Mode = "prefer"
+	dsn.SSL.CertMode = "allow"
 	u, err := user.Current()
 	if err == nil {
 		dsn.Parameter["user"] = u.Name
@@ -97,21 +133,46 @@ func (dsn *DataSourceName) pickEnv(key string, env []string) string {
 	}
 	return ""
 }
-func (dsn *DataSourceName) parseDSN(str string) (err error) {
+func (dsn *DataSourceName) parseConnInfo(str string, strict bool) (err error) {
+	kv, err := parseConnInfo(str)
+	if err != nil {
+		return
+	}
+	return dsn.apply(kv, strict)
+}
+
+func (dsn *DataSourceName) parseURI(uri string, strict bool) (err error) {
+	kv, err := parseURI(uri)
+	if err != nil {
+		return
+	}
+	return dsn.apply(kv, strict)
+}
+
+// apply 应用两种形式的连接串解析出的参数，libpq 的连接参数均在此消耗，其余参数作为服务器参数在启动时发送
+func (dsn *DataSourceName) apply(kv map[string]string, strict bool) (err error) {
 	p := make(map[string]string)
-	for _, item := range strings.Split(str, " ") {
-		pair := strings.Split(item, "=")
-		if len(pair) == 2 {
-			p[strings.ToLower(strings.TrimSpace(pair[0]))] = strings.TrimSpace(pair[1])
+	for k, v := range kv {
+		if strict {
+			if err = checkConnInfoKeyword(k); err != nil {
+				return
+			}
+		} else {
+			k = strings.ToLower(k)
 		}
+		p[k] = v
 	}
 	if host, has := p["host"]; has {
-		dsn.Host = host
+		if host != "" {
+			dsn.Host = host
+		}
 		delete(p, "host")
 	}
-	if port, has := p["Port"]; has {
-		dsn.Port = port
-		delete(p, "Port")
+	if port, has := p["port"]; has {
+		if port != "" {
+			dsn.Port = port
+		}
+		delete(p, "port")
 	}
 	if u, has := p["user"]; has {
 		dsn.Parameter["user"] = u
@@ -127,11 +188,11 @@ func (dsn *DataSourceName) parseDSN(str string) (err error) {
 	}
 	if appName, has := p["application_name"]; has {
 		dsn.Parameter["application_name"] = appName
-		delete(p, "application_name")
 	} else if appName, has := p["fallback_application_name"]; has {
 		dsn.Parameter["application_name"] = appName
-		delete(p, "fallback_application_name")
 	}
+	delete(p, "application_name")
+	delete(p, "fallback_application_name")
 	if tos, has := p["connect_timeout"]; has {
 		to, err := strconv.Atoi(tos)
 		if err != nil {
@@ -141,84 +202,37 @@ func (dsn *DataSourceName) parseDSN(str string) (err error) {
 		delete(p, "connect_timeout")
 	}
 
-	dsn.pickSSLSetting(&p)
-
-	for k, v := range p {
-		dsn.Parameter[k] = v
-	}
-	return
-}
-
-func (dsn *DataSourceName) parseURI(uri string) (err error) {
-	u, err := url.Parse(uri)
-	if err != nil {
+	if err = dsn.pickSSLSetting(&p); err != nil {
 		return
 	}
-	if u.Scheme != "postgres" && u.Scheme != "postgresql" && u.Scheme != "pg" {
-		err = fmt.Errorf("invalid connection protocol: %s", u.Scheme)
+	if err = dsn.pickAuthSetting(&p); err != nil {
 		return
 	}
-	if u.Hostname() != "" {
-		dsn.Host = u.Hostname()
-	}
-	if u.Port() != "" {
-		dsn.Port = u.Port()
-	}
-	if u.User.Username() != "" {
-		dsn.Parameter["user"] = u.User.Username()
-	}
-	if pwd, has := u.User.Password(); has {
-		dsn.Password = pwd
-	}
-	if u.Path != "" {
-		ps := strings.Split(u.Path, "/")
-		if len(ps) > 0 {
-			dsn.Parameter["database"] = ps[1]
-		}
-	}
-	var qm = make(map[string]string)
-	for k, v := range u.Query() {
-		qm[strings.ToLower(k)] = v[0]
-	}
-
-	if tos, has := qm["connect_timeout"]; has {
-		to, err := strconv.Atoi(tos)
-		if err != nil {
-			return err
-		}
-		dsn.ConnectTimeout = time.Duration(to) * time.Second
-		delete(qm, "connect_timeout")
+	if err = dsn.pickTCPSetting(&p); err != nil {
+		return
 	}
-
-	if appName, has := qm["application_name"]; has {
-		dsn.Parameter["application_name"] = appName
-		delete(qm, "application_name")
-	} else if appName, has := qm["fallback_application_name"]; has {
-		dsn.Parameter["application_name"] = appName
-		delete(qm, "fallback_application_name")
+	if err = dsn.pickTimeSetting(&p); err != nil {
+		return
 	}
-
-	if host, has := qm["host"]; has {
-		dsn.Host = host
-		delete(qm, "host")
+	if err = pickUnsupportedSetting(&p); err != nil {
+		return
 	}
 
-	dsn.pickSSLSetting(&qm)
-
-	for k, v := range qm {
+	for k, v := range p {
 		dsn.Parameter[k] = v
 	}
 	return
 }
 
-func (dsn *DataSourceName) pickSSLSetting(envs *map[string]string) {
+func (dsn *DataSourceName) pickSSLSetting(envs *map[string]string) (err error) {
 	if envs != nil {
+		var mode, explicitMode = (*envs)["sslmode"]
 		if strings.HasPrefix(dsn.Host, "/") {
 			dsn.SSL.Mode = "disable"
-		} else if v, has := (*envs)["sslmode"]; has {
-			dsn.SSL.Mode = v
-			delete(*envs, "sslmode")
+		} else if explicitMode {
+			dsn.SSL.Mode = mode
 		}
+		delete(*envs, "sslmode")
 		if v, has := (*envs)["sslcompression"]; has {
 			if v == "0" {
 				dsn.SSL.Compression = 0
@@ -236,14 +250,182 @@ func (dsn *DataSourceName) pickSSLSetting(envs *map[string]string) {
 		if v, has := (*envs)["sslrootcert"]; has {
 			dsn.SSL.RootCert = v
 			delete(*envs, "sslrootcert")
+			// 与 libpq 16 一致：sslrootcert=system 信任操作系统的证书库，并强制 verify-full
+			if v == "system" {
+				if explicitMode && mode != "verify-full" {
+					return fmt.Errorf("pg: weak sslmode %q may not be used with sslrootcert=system (use \"verify-full\")", mode)
+				}
+				if dsn.SSL.Mode != "disable" {
+					dsn.SSL.Mode = "verify-full"
+				}
+			}
 		}
 		if v, has := (*envs)["sslcrl"]; has {
 			dsn.SSL.Crl = v
 			delete(*envs, "sslcrl")
 		}
+		if v, has := (*envs)["sslpassword"]; has {
+			dsn.SSL.Password = v
+			delete(*envs, "sslpassword")
+		}
+		if v, has := (*envs)["sslcertmode"]; has {
+			switch v {
+			case "disable", "allow", "require":
+				dsn.SSL.CertMode = v
+			default:
+				return fmt.Errorf("pg: invalid sslcertmode value: %q", v)
+			}
+			delete(*envs, "sslcertmode")
+		}
+	}
+	return
+}
+
+func (dsn *DataSourceName) pickAuthSetting(envs *map[string]string) (err error) {
+	if envs != nil {
+		if v, has := (*envs)["require_auth"]; has {
+			if dsn.RequireAuth, err = ParseRequireAuth(v); err != nil {
+				return
+			}
+			delete(*envs, "require_auth")
+		}
+		if v, has := (*envs)["oauth_issuer"]; has {
+			dsn.OAuth.Issuer = v
+			delete(*envs, "oauth_issuer")
+		}
+		if v, has := (*envs)["oauth_client_id"]; has {
+			dsn.OAuth.ClientID = v
+			delete(*envs, "oauth_client_id")
+		}
+		if v, has := (*envs)["oauth_scope"]; has {
+			dsn.OAuth.Scope = v
+			delete(*envs, "oauth_scope")
+		}
+	}
+	return
+}
+
+func (dsn *DataSourceName) pickTCPSetting(envs *map[string]string) (err error) {
+	if envs == nil {
+		return
+	}
+	var n int
+	if v, has := (*envs)["keepalives"]; has {
+		if n, err = dsn.atoiSetting("keepalives", v); err != nil {
+			return
+		}
+		dsn.KeepAlive.Enable = n != 0
+		delete(*envs, "keepalives")
+	}
+	if v, has := (*envs)["keepalives_idle"]; has {
+		if n, err = dsn.atoiSetting("keepalives_idle", v); err != nil {
+			return
+		}
+		dsn.KeepAlive.Idle = time.Duration(n) * time.Second
+		delete(*envs, "keepalives_idle")
+	}
+	if v, has := (*envs)["keepalives_interval"]; has {
+		if n, err = dsn.atoiSetting("keepalives_interval", v); err != nil {
+			return
+		}
+		dsn.KeepAlive.Interval = time.Duration(n) * time.Second
+		delete(*envs, "keepalives_interval")
+	}
+	if v, has := (*envs)["keepalives_count"]; has {
+		if dsn.KeepAlive.Count, err = dsn.atoiSetting("keepalives_count", v); err != nil {
+			return
+		}
+		delete(*envs, "keepalives_count")
 	}
+	if v, has := (*envs)["tcp_user_timeout"]; has {
+		if n, err = dsn.atoiSetting("tcp_user_timeout", v); err != nil {
+			return
+		}
+		dsn.TCPUserTimeout = time.Duration(n) * time.Millisecond
+		delete(*envs, "tcp_user_timeout")
+	}
+	return
 }
 
+// atoiSetting 解析非负整数型的连接参数
+func (dsn *DataSourceName) atoiSetting(key, value string) (n int, err error) {
+	n, err = strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("pg: invalid integer value %q for connection option %q", value, key)
+	}
+	return
+}
+
+// pickTimeSetting 解析 timestamp_location：server（默认）、local、utc 或 IANA 时区名称
+func (dsn *DataSourceName) pickTimeSetting(envs *map[string]string) (err error) {
+	if envs == nil {
+		return
+	}
+	if v, has := (*envs)["timestamp_location"]; has {
+		switch strings.ToLower(v) {
+		case "server":
+			dsn.TimestampLocation = nil
+		case "local":
+			dsn.TimestampLocation = time.Local
+		case "utc":
+			dsn.TimestampLocation = time.UTC
+		default:
+			if dsn.TimestampLocation, err = time.LoadLocation(v); err != nil {
+				return fmt.Errorf("pg: invalid timestamp_location %q: %v", v, err)
+			}
+		}
+		delete(*envs, "timestamp_location")
+	}
+	return
+}
+
+// pickUnsupportedSetting 消耗本驱动没有实现的 libpq 连接参数，它们不能作为服务器参数发送
+// 取值与不实现时的行为一致的予以接受，否则返回错误，而不是静默地忽略调用方的要求
+func pickUnsupportedSetting(envs *map[string]string) error {
+	if envs == nil {
+		return nil
+	}
+	// 值为 libpq 的默认值，或在本驱动中与默认值等效
+	var accepted = map[string][]string{
+		"channel_binding":          {"disable", "prefer"}, // 不支持 SCRAM-SHA-256-PLUS，prefer 时与 libpq 无法绑定时一样不绑定
+		"gssencmode":               {"disable", "prefer"}, // 不支持 GSSAPI 加密，prefer 时直接使用 SSL 或明文
+		"sslnegotiation":           {"postgres"},
+		"target_session_attrs":     {"any"},
+		"load_balance_hosts":       {"disable", "random"}, // 只支持单个主机，两者等效
+		"min_protocol_version":     {"3.0"},
+		"max_protocol_version":     {"3.0", "3.2", "latest"}, // 总是使用协议 3.0
+		"sslsni":                   {"1"},                    // verify-full 时按 host 发送 SNI
+		"ssl_min_protocol_version": {"TLSv1.2"},              // 与 crypto/tls 客户端的默认下限相同
+		"ssl_max_protocol_version": nil,
+		"hostaddr":                 nil,
+		"passfile":                 nil,
+		"oauth_client_secret":      nil,
+		"service":                  nil,
+		"requirepeer":              nil,
+		"sslcrldir":                nil,
+	}
+	for k, values := range accepted {
+		v, has := (*envs)[k]
+		if !has {
+			continue
+		}
+		var ok = v == ""
+		for _, value := range values {
+			ok = ok || v == value
+		}
+		if !ok {
+			return fmt.Errorf("pg: connection option %s=%q is not supported", k, v)
+		}
+		delete(*envs, k)
+	}
+	// 只在 GSSAPI 认证时使用，而本驱动不支持 GSSAPI 认证
+	for _, k := range []string{"krbsrvname", "gsslib", "gssdelegation"} {
+		delete(*envs, k)
+	}
+	return nil
+}
+
+// Address 连接的网络类型、地址及超时，host 以 / 开头时为 Unix 域套接字所在的目录
 func (dsn *DataSourceName) Address() (network, address string, timeout time.Duration) {
 	if strings.HasPrefix(dsn.Host, "/") {
 		network = "unix"
@@ -263,21 +445,235 @@ func (dsn *DataSourceName) Address() (network, address string, timeout time.Dura
 
 func (dsn *DataSourceName) SSLCheck() (err error) {
 	if dsn.SSL.Mode == "verify-ca" || dsn.SSL.Mode == "verify-full" {
-		if _, err = os.Stat(dsn.SSL.RootCert); err != nil {
+		if dsn.SSL.RootCertPEM == nil && dsn.SSL.RootCert != "system" {
+			if _, err = os.Stat(dsn.SSL.RootCert); err != nil {
+				return err
+			}
+		}
+	}
+	if dsn.SSL.CertMode == "require" && (dsn.SSL.CertPEM == nil || dsn.SSL.KeyPEM == nil) {
+		if _, err = os.Stat(dsn.SSL.Cert); err != nil {
+			return err
+		}
+		if _, err = os.Stat(dsn.SSL.Key); err != nil {
 			return err
 		}
 	}
+	return
+}
+
+// redactedValue Redacted 中代替敏感值的占位符
+const redactedValue = "xxxxx"
+
+// isSecretKeyword 值需要在 Redacted 中隐去的连接参数
+func isSecretKeyword(keyword string) bool {
+	keyword = strings.ToLower(keyword)
+	return strings.Contains(keyword, "password") || strings.Contains(keyword, "secret") || strings.Contains(keyword, "token")
+}
+
+// String 以 keyword = value 的形式输出连接串，值按需加引号及转义，ParseDSN 可以还原
+// 只输出 host、port、user、dbname 及与默认值不同的参数；Connector 提供的 PEM 证书及令牌提供方无法用连接串表示，不会输出
+// 其中含有口令，记录日志时请使用 Redacted
+func (dsn DataSourceName) String() string {
+	return formatConnInfo(dsn.keywords(false))
+}
+
+// URI 以 postgresql:// 的形式输出连接串，各部分均做百分号编码
+func (dsn DataSourceName) URI() string {
+	return formatURI(dsn.keywords(false))
+}
+
+// Redacted 同 String，但隐去 password、sslpassword 及其他口令、密钥、令牌类参数的值，可以安全地记录到日志
+func (dsn DataSourceName) Redacted() string {
+	return formatConnInfo(dsn.keywords(true))
+}
+
+// RedactedURI 同 URI，但隐去敏感参数的值
+func (dsn DataSourceName) RedactedURI() string {
+	return formatURI(dsn.keywords(true))
+}
 
-	if _, err = os.Stat(dsn.SSL.Cert); err != nil {
-		return err
+var (
+	defaults     DataSourceName
+	defaultsOnce sync.Once
+)
+
+// defaultDataSourceName 各参数的默认值，只计算一次，避免每次输出连接串都查询当前用户；调用方只读，不得修改其中的 map
+func defaultDataSourceName() *DataSourceName {
+	defaultsOnce.Do(defaults.setDefault)
+	return &defaults
+}
+
+// redactOptions 隐去 options 中敏感的服务器参数，如 -c password=... 或 --sslpassword=...
+func redactOptions(options string) string {
+	var b strings.Builder
+	var token strings.Builder
+	var afterC bool
+	var flush = func() {
+		var t = token.String()
+		token.Reset()
+		if t == "" {
+			return
+		}
+		var body string
+		switch {
+		case afterC:
+			body, afterC = t, false
+		case t == "-c":
+			afterC = true
+		case strings.HasPrefix(t, "--"):
+			body = t[2:]
+		case strings.HasPrefix(t, "-c"):
+			body = t[2:]
+		}
+		if name, _, has := strings.Cut(body, "="); has && isSecretKeyword(name) {
+			t = t[:len(t)-len(body)] + name + "=" + redactedValue
+		}
+		b.WriteString(t)
+	}
+	for i := 0; i < len(options); i++ {
+		switch c := options[i]; {
+		case c == '\\' && i+1 < len(options):
+			token.WriteByte(c)
+			i++
+			token.WriteByte(options[i])
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			flush()
+			b.WriteByte(c)
+		default:
+			token.WriteByte(c)
+		}
 	}
+	flush()
+	return b.String()
+}
 
-	info, err := os.Stat(dsn.SSL.Key)
-	if err != nil {
-		return err
+// keywords 按固定顺序列出连接参数，redact 为 true 时隐去敏感参数的值
+func (dsn DataSourceName) keywords(redact bool) (kv [][2]string) {
+	var def = defaultDataSourceName()
+	var add = func(k, v string) {
+		if redact && v != "" && isSecretKeyword(k) {
+			v = redactedValue
+		} else if redact && k == "options" {
+			v = redactOptions(v)
+		}
+		kv = append(kv, [2]string{k, v})
+	}
+	var seconds = func(d time.Duration) string {
+		return strconv.FormatInt(int64(d/time.Second), 10)
+	}
+
+	add("host", dsn.Host)
+	add("port", dsn.Port)
+	add("user", dsn.Parameter["user"])
+	add("dbname", dsn.Parameter["database"])
+	if dsn.Password != "" {
+		add("password", dsn.Password)
+	}
+	if v, has := dsn.Parameter["application_name"]; has {
+		add("application_name", v)
+	}
+	if dsn.ConnectTimeout != def.ConnectTimeout {
+		add("connect_timeout", seconds(dsn.ConnectTimeout))
+	}
+
+	if dsn.SSL.Mode != def.SSL.Mode {
+		add("sslmode", dsn.SSL.Mode)
+	}
+	if dsn.SSL.Compression != def.SSL.Compression {
+		add("sslcompression", strconv.Itoa(dsn.SSL.Compression))
+	}
+	for _, item := range [][3]string{
+		{"sslcert", dsn.SSL.Cert, def.SSL.Cert},
+		{"sslkey", dsn.SSL.Key, def.SSL.Key},
+		{"sslrootcert", dsn.SSL.RootCert, def.SSL.RootCert},
+		{"sslcrl", dsn.SSL.Crl, def.SSL.Crl},
+		{"sslpassword", dsn.SSL.Password, def.SSL.Password},
+		{"sslcertmode", dsn.SSL.CertMode, def.SSL.CertMode},
+		{"require_auth", dsn.RequireAuth.Raw, ""},
+		{"oauth_issuer", dsn.OAuth.Issuer, ""},
+		{"oauth_client_id", dsn.OAuth.ClientID, ""},
+		{"oauth_scope", dsn.OAuth.Scope, ""},
+	} {
+		if item[1] != item[2] {
+			add(item[0], item[1])
+		}
+	}
+
+	if !dsn.KeepAlive.Enable {
+		add("keepalives", "0")
+	}
+	if dsn.KeepAlive.Idle > 0 {
+		add("keepalives_idle", seconds(dsn.KeepAlive.Idle))
+	}
+	if dsn.KeepAlive.Interval > 0 {
+		add("keepalives_interval", seconds(dsn.KeepAlive.Interval))
+	}
+	if dsn.KeepAlive.Count > 0 {
+		add("keepalives_count", strconv.Itoa(dsn.KeepAlive.Count))
+	}
+	if dsn.TCPUserTimeout > 0 {
+		add("tcp_user_timeout", strconv.FormatInt(int64(dsn.TCPUserTimeout/time.Millisecond), 10))
 	}
-	if info.Mode().Perm()&0077 != 0 {
-		return errors.New("pq: Private key file has group or world access. Permissions should be u=rw (0600) or less")
+	switch dsn.TimestampLocation {
+	case nil:
+	case time.Local:
+		add("timestamp_location", "local")
+	case time.UTC:
+		add("timestamp_location", "utc")
+	default:
+		add("timestamp_location", dsn.TimestampLocation.String())
+	}
+
+	// 其余作为服务器参数发送的参数
+	var keys = make([]string, 0, len(dsn.Parameter))
+	for k, v := range dsn.Parameter {
+		switch k {
+		case "user", "database", "application_name":
+			continue
+		}
+		if dv, has := def.Parameter[k]; has && dv == v {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		add(k, dsn.Parameter[k])
 	}
 	return
 }
+
+// ConnectError 拨号或 TLS 握手失败时返回，Target 为隐去了敏感信息的目标，便于排查是哪一台主机连接失败
+type ConnectError struct {
+	Target string // 如 host=db.example.com port=5432 user=app dbname=app
+	Err    error
+}
+
+func (e *ConnectError) Error() string {
+	return fmt.Sprintf("pg: cannot connect to %s: %v", e.Target, e.Err)
+}
+
+func (e *ConnectError) Unwrap() error {
+	return e.Err
+}
+
+// target 连接的目标，按关键字从 keywords 中挑出 host、port、user、dbname，不依赖它们的位置
+func (dsn DataSourceName) target() string {
+	var kv = make([][2]string, 0, 4)
+	for _, item := range dsn.keywords(true) {
+		switch item[0] {
+		case "host", "port", "user", "dbname":
+			kv = append(kv, item)
+		}
+	}
+	return formatConnInfo(kv)
+}
+
+// WrapConnectError 把拨号及 TLS 握手的错误包装为 *ConnectError，err 为 nil 时返回 nil
+func (dsn DataSourceName) WrapConnectError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ConnectError{Target: dsn.target(), Err: err}
+}
@@ -0,0 +1,248 @@
+package client
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDSNKeepAlive(t *testing.T) {
+	var cases = []struct {
+		in       string
+		enable   bool
+		idle     time.Duration
+		interval time.Duration
+		count    int
+		userTO   time.Duration
+		err      string
+	}{
+		{in: "host=db", enable: true},
+		{in: "host=db keepalives=0", enable: false},
+		{in: "host=db keepalives=1 keepalives_idle=30 keepalives_interval=10 keepalives_count=3", enable: true, idle: 30 * time.Second, interval: 10 * time.Second, count: 3},
+		{in: "host=db tcp_user_timeout=1500", enable: true, userTO: 1500 * time.Millisecond},
+		{in: "postgresql://db?keepalives_idle=5&keepalives_count=9&tcp_user_timeout=250", enable: true, idle: 5 * time.Second, count: 9, userTO: 250 * time.Millisecond},
+		{in: "host=db keepalives=yes", err: `"keepalives"`},
+		{in: "host=db keepalives_idle=-1", err: `"keepalives_idle"`},
+		{in: "host=db keepalives_interval=1s", err: `"keepalives_interval"`},
+		{in: "host=db keepalives_count=x", err: `"keepalives_count"`},
+		{in: "host=db tcp_user_timeout=1.5", err: `"tcp_user_timeout"`},
+	}
+	for _, c := range cases {
+		dsn, err := ParseDSN(c.in)
+		if c.err != "" {
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("ParseDSN(%q) = %v, want an error about %s", c.in, err, c.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseDSN(%q): %v", c.in, err)
+			continue
+		}
+		var ka = dsn.KeepAlive
+		if ka.Enable != c.enable || ka.Idle != c.idle || ka.Interval != c.interval || ka.Count != c.count || dsn.TCPUserTimeout != c.userTO {
+			t.Errorf("ParseDSN(%q) = %+v, tcp_user_timeout %v", c.in, ka, dsn.TCPUserTimeout)
+		}
+		for _, k := range []string{"keepalives", "keepalives_idle", "keepalives_interval", "keepalives_count", "tcp_user_timeout"} {
+			if _, has := dsn.Parameter[k]; has {
+				t.Errorf("ParseDSN(%q): %s must not be sent as a server parameter", c.in, k)
+			}
+		}
+	}
+}
+
+func TestDSNStringRoundTrip(t *testing.T) {
+	for _, in := range []string{
+		"host=db.example.com port=6543 user=app dbname='my db' password='it\\'s a secret'",
+		"host=db sslmode=verify-full sslrootcert=system sslpassword=k3y sslcertmode=require",
+		"host=db keepalives=0 tcp_user_timeout=1500 connect_timeout=5 timestamp_location=Asia/Shanghai",
+		"host=db require_auth=scram-sha-256 oauth_issuer=https://id.example.com oauth_client_id=app",
+		"host=/var/run/postgresql application_name='my app' options='-c search_path=app'",
+		"postgresql://alice:p%40ss@[::1]:5433/app?search_path=a%2Cb",
+	} {
+		dsn, err := ParseDSN(in)
+		if err != nil {
+			t.Fatalf("ParseDSN(%q): %v", in, err)
+		}
+		for _, out := range []string{dsn.String(), dsn.URI()} {
+			again, err := ParseDSN(out)
+			if err != nil {
+				t.Errorf("ParseDSN(%q): %v", out, err)
+				continue
+			}
+			if again.String() != dsn.String() {
+				t.Errorf("round trip of %q through %q: got %q", in, out, again.String())
+			}
+		}
+	}
+}
+
+func TestDSNRedacted(t *testing.T) {
+	dsn, err := ParseDSN("host=db user=app password=hunter2 sslpassword=k3y")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, out := range []string{dsn.Redacted(), dsn.RedactedURI()} {
+		for _, secret := range []string{"hunter2", "k3y"} {
+			if strings.Contains(out, secret) {
+				t.Errorf("%q leaks %q", out, secret)
+			}
+		}
+		if !strings.Contains(out, "db") || !strings.Contains(out, redactedValue) {
+			t.Errorf("unexpected redacted form %q", out)
+		}
+	}
+
+	dsn.Parameter["options"] = `-c search_path=app -cpassword=hunter2 --sslpassword=k3y -c  app.token=a\ b`
+	var out = dsn.Redacted()
+	for _, secret := range []string{"hunter2", "k3y", "a\\ b"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("%q leaks %q", out, secret)
+		}
+	}
+	if !strings.Contains(out, "search_path=app") {
+		t.Errorf("unexpected redacted options %q", out)
+	}
+	if got, want := redactOptions(dsn.Parameter["options"]), `-c search_path=app -cpassword=xxxxx --sslpassword=xxxxx -c  app.token=xxxxx`; got != want {
+		t.Errorf("redactOptions = %q, want %q", got, want)
+	}
+
+	var cause = errors.New("connection refused")
+	err = dsn.WrapConnectError(cause)
+	var ce *ConnectError
+	if !errors.As(err, &ce) || !errors.Is(err, cause) {
+		t.Fatalf("expected a *ConnectError wrapping the cause, got %v", err)
+	}
+	if ce.Target != "host=db port=5432 user=app dbname=postgres" || strings.Contains(err.Error(), "hunter2") {
+		t.Fatalf("unexpected target %q", ce.Target)
+	}
+
+	// 目标中只有这四项，其余参数不出现
+	dsn.Parameter["application_name"] = "api"
+	dsn.Parameter["database"] = "app"
+	if target := dsn.target(); target != "host=db port=5432 user=app dbname=app" {
+		t.Fatalf("unexpected target %q", target)
+	}
+}
+
+func TestParseDSNSSLRootCertSystem(t *testing.T) {
+	var cases = []struct {
+		in   string
+		mode string
+		err  string
+	}{
+		{in: "host=db sslrootcert=system", mode: "verify-full"},
+		{in: "host=db sslmode=verify-full sslrootcert=system", mode: "verify-full"},
+		{in: "sslrootcert=system host=db sslmode=verify-full", mode: "verify-full"},
+		{in: "postgresql://db?sslrootcert=system", mode: "verify-full"},
+		{in: "host=/tmp sslrootcert=system", mode: "disable"},
+		{in: "host=db sslmode=disable sslrootcert=system", err: `weak sslmode "disable"`},
+		{in: "host=db sslmode=allow sslrootcert=system", err: `weak sslmode "allow"`},
+		{in: "host=db sslmode=prefer sslrootcert=system", err: `weak sslmode "prefer"`},
+		{in: "host=db sslmode=require sslrootcert=system", err: `weak sslmode "require"`},
+		{in: "postgresql://db?sslmode=verify-ca&sslrootcert=system", err: `weak sslmode "verify-ca"`},
+	}
+	for _, c := range cases {
+		dsn, err := ParseDSN(c.in)
+		if c.err != "" {
+			if err == nil || !strings.Contains(err.Error(), c.err) || !strings.Contains(err.Error(), "sslrootcert=system") {
+				t.Errorf("ParseDSN(%q) = %v, want an error about %s", c.in, err, c.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseDSN(%q): %v", c.in, err)
+			continue
+		}
+		if dsn.SSL.Mode != c.mode || dsn.SSL.RootCert != "system" {
+			t.Errorf("ParseDSN(%q) = sslmode %s, sslrootcert %s, want %s, system", c.in, dsn.SSL.Mode, dsn.SSL.RootCert, c.mode)
+		}
+		if _, has := dsn.Parameter["sslrootcert"]; has {
+			t.Errorf("ParseDSN(%q): sslrootcert must not be sent as a server parameter", c.in)
+		}
+		// 不会把 system 当作文件名去检查
+		if err = dsn.SSLCheck(); err != nil {
+			t.Errorf("ParseDSN(%q).SSLCheck(): %v", c.in, err)
+		}
+	}
+}
+
+// libpqKeywordSamples connInfoKeywords 中各参数的一个有效值
+var libpqKeywordSamples = map[string]string{
+	"host": "db", "hostaddr": "", "port": "5433", "dbname": "app", "user": "alice", "password": "pw",
+	"passfile": "", "require_auth": "scram-sha-256", "channel_binding": "prefer", "connect_timeout": "5",
+	"client_encoding": "UTF8", "options": "-c search_path=app", "application_name": "a", "fallback_application_name": "b",
+	"keepalives": "1", "keepalives_idle": "30", "keepalives_interval": "10", "keepalives_count": "3",
+	"tcp_user_timeout": "1000", "replication": "database", "gssencmode": "disable",
+	"sslmode": "require", "sslnegotiation": "postgres", "sslcompression": "0", "sslcert": "c", "sslkey": "k",
+	"sslpassword": "p", "sslcertmode": "allow", "sslrootcert": "r", "sslcrl": "l", "sslcrldir": "",
+	"sslsni": "1", "requirepeer": "", "ssl_min_protocol_version": "TLSv1.2", "ssl_max_protocol_version": "",
+	"min_protocol_version": "3.0", "max_protocol_version": "latest",
+	"krbsrvname": "postgres", "gsslib": "gssapi", "gssdelegation": "0", "service": "",
+	"target_session_attrs": "any", "load_balance_hosts": "random",
+	"oauth_issuer": "https://id.example.com", "oauth_client_id": "app", "oauth_client_secret": "", "oauth_scope": "openid",
+	"timestamp_location": "utc",
+}
+
+func TestParseDSNConsumesLibpqKeywords(t *testing.T) {
+	// 只有这些是服务器在启动包中认识的参数
+	var serverSettings = map[string]bool{
+		"user": true, "database": true, "DateStyle": true, "client_encoding": true,
+		"options": true, "application_name": true, "replication": true,
+	}
+	var kv [][2]string
+	for k := range connInfoKeywords {
+		v, ok := libpqKeywordSamples[k]
+		if !ok {
+			t.Errorf("no sample value for %s", k)
+			continue
+		}
+		kv = append(kv, [2]string{k, v})
+		dsn, err := ParseDSNStrict(formatConnInfo([][2]string{{k, v}}))
+		if err != nil {
+			t.Errorf("ParseDSNStrict(%s=%s): %v", k, v, err)
+			continue
+		}
+		for p := range dsn.Parameter {
+			if !serverSettings[p] {
+				t.Errorf("ParseDSNStrict(%s=%s) sends %s as a server parameter", k, v, p)
+			}
+		}
+	}
+	dsn, err := ParseDSN(formatConnInfo(kv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for p := range dsn.Parameter {
+		if !serverSettings[p] {
+			t.Errorf("%s is sent as a server parameter", p)
+		}
+	}
+}
+
+func TestParseDSNUnsupportedKeywords(t *testing.T) {
+	for _, in := range []string{
+		"channel_binding=require",
+		"gssencmode=require",
+		"sslnegotiation=direct",
+		"target_session_attrs=read-write",
+		"load_balance_hosts=yes",
+		"service=prod",
+		"requirepeer=postgres",
+		"sslcrldir=/etc/crl",
+		"min_protocol_version=3.2",
+		"max_protocol_version=4.0",
+		"hostaddr=10.0.0.5",
+		"passfile=/etc/pgpass",
+		"sslsni=0",
+		"ssl_min_protocol_version=TLSv1.3",
+		"ssl_max_protocol_version=TLSv1.3",
+		"oauth_client_secret=s3cr3t",
+	} {
+		_, err := ParseDSN("host=db " + in)
+		if err == nil || !strings.Contains(err.Error(), "not supported") {
+			t.Errorf("ParseDSN(%s) = %v, want a not supported error", in, err)
+		}
+	}
+}
@@ -204,6 +204,20 @@ const (
 	PgTypeRegrole          = 4096
 	PgTypeArrRegrole       = 4097
 	PgTypePgSubscription   = 6101
+
+	// PostgreSQL 14 起的多范围类型
+	PgTypeInt4multirange    = 4451
+	PgTypeNummultirange     = 4532
+	PgTypeTsmultirange      = 4533
+	PgTypeTstzmultirange    = 4534
+	PgTypeDatemultirange    = 4535
+	PgTypeInt8multirange    = 4536
+	PgTypeArrInt4multirange = 6150
+	PgTypeArrNummultirange  = 6151
+	PgTypeArrTsmultirange   = 6152
+	PgTypeArrTstzmultirange = 6153
+	PgTypeArrDatemultirange = 6155
+	PgTypeArrInt8multirange = 6157
 )
 
 var PgTypeMap = map[PgType]string{
@@ -379,4 +393,109 @@ var PgTypeMap = map[PgType]string{
 	PgTypeRegrole:          "PgTypeRegrole",
 	PgTypeArrRegrole:       "PgTypeArrRegrole",
 	PgTypePgSubscription:   "PgTypePgSubscription",
+
+	// PostgreSQL 14 起的多范围类型
+	PgTypeInt4multirange:    "PgTypeInt4multirange",
+	PgTypeNummultirange:     "PgTypeNummultirange",
+	PgTypeTsmultirange:      "PgTypeTsmultirange",
+	PgTypeTstzmultirange:    "PgTypeTstzmultirange",
+	PgTypeDatemultirange:    "PgTypeDatemultirange",
+	PgTypeInt8multirange:    "PgTypeInt8multirange",
+	PgTypeArrInt4multirange: "PgTypeArrInt4multirange",
+	PgTypeArrNummultirange:  "PgTypeArrNummultirange",
+	PgTypeArrTsmultirange:   "PgTypeArrTsmultirange",
+	PgTypeArrTstzmultirange: "PgTypeArrTstzmultirange",
+	PgTypeArrDatemultirange: "PgTypeArrDatemultirange",
+	PgTypeArrInt8multirange: "PgTypeArrInt8multirange",
+}
+
+// PgArrayElemType 数组类型所对应的元素类型
+var PgArrayElemType = map[PgType]PgType{
+	PgTypeArrXml:           PgTypeXml,
+	PgTypeArrJson:          PgTypeJson,
+	PgTypeArrLine:          PgTypeLine,
+	PgTypeArrCidr:          PgTypeCidr,
+	PgTypeArrCircle:        PgTypeCircle,
+	PgTypeArrMacaddr8:      PgTypeMacaddr8,
+	PgTypeArrMoney:         PgTypeMoney,
+	PgTypeArrBool:          PgTypeBool,
+	PgTypeArrBytea:         PgTypeBytea,
+	PgTypeArrChar:          PgTypeChar,
+	PgTypeArrName:          PgTypeName,
+	PgTypeArrInt2:          PgTypeInt2,
+	PgTypeArrInt2vector:    PgTypeInt2vector,
+	PgTypeArrInt4:          PgTypeInt4,
+	PgTypeArrRegproc:       PgTypeRegproc,
+	PgTypeArrText:          PgTypeText,
+	PgTypeArrTid:           PgTypeTid,
+	PgTypeArrXid:           PgTypeXid,
+	PgTypeArrCid:           PgTypeCid,
+	PgTypeArrOidvector:     PgTypeOidvector,
+	PgTypeArrBpchar:        PgTypeBpchar,
+	PgTypeArrVarchar:       PgTypeVarchar,
+	PgTypeArrInt8:          PgTypeInt8,
+	PgTypeArrPoint:         PgTypePoint,
+	PgTypeArrLseg:          PgTypeLseg,
+	PgTypeArrPath:          PgTypePath,
+	PgTypeArrBox:           PgTypeBox,
+	PgTypeArrFloat4:        PgTypeFloat4,
+	PgTypeArrFloat8:        PgTypeFloat8,
+	PgTypeArrAbstime:       PgTypeAbstime,
+	PgTypeArrReltime:       PgTypeReltime,
+	PgTypeArrTinterval:     PgTypeTinterval,
+	PgTypeArrPolygon:       PgTypePolygon,
+	PgTypeArrOid:           PgTypeOid,
+	PgTypeArrAclitem:       PgTypeAclitem,
+	PgTypeArrMacaddr:       PgTypeMacaddr,
+	PgTypeArrInet:          PgTypeInet,
+	PgTypeArrTimestamp:     PgTypeTimestamp,
+	PgTypeArrDate:          PgTypeDate,
+	PgTypeArrTime:          PgTypeTime,
+	PgTypeArrTimestamptz:   PgTypeTimestamptz,
+	PgTypeArrInterval:      PgTypeInterval,
+	PgTypeArrNumeric:       PgTypeNumeric,
+	PgTypeArrCstring:       PgTypeCstring,
+	PgTypeArrTimetz:        PgTypeTimetz,
+	PgTypeArrBit:           PgTypeBit,
+	PgTypeArrVarbit:        PgTypeVarbit,
+	PgTypeArrRefcursor:     PgTypeRefcursor,
+	PgTypeArrRegprocedure:  PgTypeRegprocedure,
+	PgTypeArrRegoper:       PgTypeRegoper,
+	PgTypeArrRegoperator:   PgTypeRegoperator,
+	PgTypeArrRegclass:      PgTypeRegclass,
+	PgTypeArrRegtype:       PgTypeRegtype,
+	PgTypeArrRecord:        PgTypeRecord,
+	PgTypeArrTxidSnapshot:  PgTypeTxidSnapshot,
+	PgTypeArrUuid:          PgTypeUuid,
+	PgTypeArrPgLsn:         PgTypePgLsn,
+	PgTypeArrTsvector:      PgTypeTsvector,
+	PgTypeArrGtsvector:     PgTypeGtsvector,
+	PgTypeArrTsquery:       PgTypeTsquery,
+	PgTypeArrRegconfig:     PgTypeRegconfig,
+	PgTypeArrRegdictionary: PgTypeRegdictionary,
+	PgTypeArrJsonb:         PgTypeJsonb,
+	PgTypeArrInt4range:     PgTypeInt4range,
+	PgTypeArrNumrange:      PgTypeNumrange,
+	PgTypeArrTsrange:       PgTypeTsrange,
+	PgTypeArrTstzrange:     PgTypeTstzrange,
+	PgTypeArrDaterange:     PgTypeDaterange,
+	PgTypeArrInt8range:     PgTypeInt8range,
+	PgTypeArrRegnamespace:  PgTypeRegnamespace,
+	PgTypeArrRegrole:       PgTypeRegrole,
+
+	// PostgreSQL 14 起的多范围类型
+	PgTypeArrInt4multirange: PgTypeInt4multirange,
+	PgTypeArrNummultirange:  PgTypeNummultirange,
+	PgTypeArrTsmultirange:   PgTypeTsmultirange,
+	PgTypeArrTstzmultirange: PgTypeTstzmultirange,
+	PgTypeArrDatemultirange: PgTypeDatemultirange,
+	PgTypeArrInt8multirange: PgTypeInt8multirange,
+}
+
+// ArrayDelimiter 元素类型在数组文本格式中使用的分隔符，即 pg_type.typdelim
+func ArrayDelimiter(elem PgType) byte {
+	if elem == PgTypeBox {
+		return ';'
+	}
+	return ','
 }
@@ -6,18 +6,26 @@
 
 package frame
 
+import "bytes"
+
 type AuthRequest struct {
 	*Data
 }
 
 const (
 	AuthTypeOk              uint32 = 0
+	AuthTypeKerberosV5      uint32 = 2
 	AuthTypePwd             uint32 = 3
 	AuthTypeMd5             uint32 = 5
+	AuthTypeGSS             uint32 = 7
+	AuthTypeGSSContinue     uint32 = 8
+	AuthTypeSSPI            uint32 = 9
 	AuthTypeSASL            uint32 = 10
 	AuthTypeSASLContinue    uint32 = 11
+	AuthTypeSASLFinal       uint32 = 12
 	AuthSASLSCRAMSHA256     string = "SCRAM-SHA-256"
 	AuthSASLSCRAMSHA256PLUS string = "SCRAM-SHA-256-PLUS"
+	AuthSASLOAuthBearer     string = "OAUTHBEARER"
 )
 
 func (ar *AuthRequest) GetType() uint32 {
@@ -32,6 +40,18 @@ func (ar *AuthRequest) GetSASLAuthMechanism() string {
 	return ar.readString()
 }
 
+// GetSASLAuthMechanisms 服务器可接受的全部 SASL 机制，列表以空串结束
+func (ar *AuthRequest) GetSASLAuthMechanisms() (ms []string) {
+	for ar.position < len(ar.payload) && bytes.IndexByte(ar.payload[ar.position:], 0) >= 0 {
+		m := ar.readString()
+		if m == "" {
+			break
+		}
+		ms = append(ms, m)
+	}
+	return
+}
+
 func (ar *AuthRequest) GetSASLAuthData() []byte {
 	return ar.payload[4:]
 }
@@ -8,13 +8,16 @@ package frame
 
 import (
 	"database/sql/driver"
+	"github.com/blusewang/pg/v2/internal/codec"
 	"go/types"
 	"math"
 	"strconv"
 	"time"
 )
 
-func NewBind(stat string, args []driver.Value) *Data {
+// NewBind paramFormats、resultFormats 为各参数及各列的格式，0 为文本格式，1 为二进制格式；为空时全部使用文本格式
+// 二进制格式的参数须为 []byte
+func NewBind(stat string, args []driver.Value, paramFormats, resultFormats []uint16) *Data {
 	b := &Data{
 		Name:    'B',
 		payload: []byte{},
@@ -24,7 +27,10 @@ func NewBind(stat string, args []driver.Value) *Data {
 	// statement
 	b.writeString(stat)
 	// parameter formats
-	b.writeUint16(0)
+	b.writeUint16(uint16(len(paramFormats)))
+	for _, f := range paramFormats {
+		b.writeUint16(f)
+	}
 	// parameter values
 	b.writeUint16(uint16(len(args)))
 	for _, arg := range args {
@@ -40,7 +46,10 @@ func NewBind(stat string, args []driver.Value) *Data {
 		}
 	}
 	// Result formats
-	b.writeUint16(0)
+	b.writeUint16(uint16(len(resultFormats)))
+	for _, f := range resultFormats {
+		b.writeUint16(f)
+	}
 	return b
 }
 
@@ -59,9 +68,9 @@ func value2Row(value driver.Value) []byte {
 	case string:
 		return []byte(value.(string))
 	case time.Time:
-		return []byte(value.(time.Time).Format(time.RFC3339Nano))
+		return []byte(codec.FormatTimestamp(value.(time.Time)))
 	case *time.Time:
-		return []byte(value.(*time.Time).Format(time.RFC3339Nano))
+		return []byte(codec.FormatTimestamp(*value.(*time.Time)))
 	default:
 		return []byte{}
 	}
@@ -0,0 +1,111 @@
+// Copyright 2026 YBCZ, Inc. All rights reserved.
+//
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file in the root of the source
+// tree.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/blusewang/pg/v2/internal/client/frame"
+)
+
+// OAuthTokenRequest 调用令牌提供方时的参数
+type OAuthTokenRequest struct {
+	Issuer   string // 连接参数 oauth_issuer
+	ClientID string // 连接参数 oauth_client_id
+	Scope    string // 连接参数 oauth_scope；重试时为服务器要求的 scope
+	// DiscoveryURI 服务器在上一次认证失败时给出的 OpenID 配置地址，首次调用时为空
+	DiscoveryURI string
+}
+
+// OAuthTokenProvider 为 OAUTHBEARER 认证提供 Bearer 令牌，令牌的获取、缓存及刷新由应用负责
+type OAuthTokenProvider func(ctx context.Context, req OAuthTokenRequest) (token string, err error)
+
+// OAuthError 服务器拒绝了令牌时返回，包含服务器在发现响应中给出的信息
+type OAuthError struct {
+	Status       string // 如 invalid_token
+	Scope        string
+	DiscoveryURI string // openid-configuration
+	Err          error  // 服务器随后发来的错误
+}
+
+func (e *OAuthError) Error() string {
+	var msg = fmt.Sprintf("pg: OAUTHBEARER authentication failed: %s", e.Status)
+	if e.DiscoveryURI != "" {
+		msg += fmt.Sprintf(" (openid-configuration %s", e.DiscoveryURI)
+		if e.Scope != "" {
+			msg += fmt.Sprintf(", scope %q", e.Scope)
+		}
+		msg += ")"
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *OAuthError) Unwrap() error {
+	return e.Err
+}
+
+// oauthInitialResponse RFC 7628 的 client-initial-response，不使用通道绑定，也不指定授权身份
+func oauthInitialResponse(token string) string {
+	return "n,,\x01auth=Bearer " + token + "\x01\x01"
+}
+
+// startOAuth 向令牌提供方获取令牌并发送 SASLInitialResponse
+func (c *Client) startOAuth(ctx context.Context) error {
+	var provider = c.Dsn.OAuth.TokenProvider
+	if provider == nil {
+		return errors.New("pg: server requested OAUTHBEARER authentication but no OAuth token provider is configured")
+	}
+	var req = OAuthTokenRequest{Issuer: c.Dsn.OAuth.Issuer, ClientID: c.Dsn.OAuth.ClientID, Scope: c.Dsn.OAuth.Scope}
+	if c.OAuthHint != nil {
+		req.DiscoveryURI = c.OAuthHint.DiscoveryURI
+		if c.OAuthHint.Scope != "" {
+			req.Scope = c.OAuthHint.Scope
+		}
+	}
+	token, err := provider(ctx, req)
+	if err != nil {
+		return fmt.Errorf("pg: OAuth token provider failed: %w", err)
+	}
+	if token == "" {
+		return errors.New("pg: OAuth token provider returned an empty token")
+	}
+	ar := frame.NewAuthSASLInitialResponse()
+	ar.Mechanism(frame.AuthSASLOAuthBearer)
+	ar.AuthResponse(oauthInitialResponse(token))
+	return c.writer.Send(ar.Data)
+}
+
+// oauthFailure 处理服务器拒绝令牌时的 SASLContinue：解析其中的 JSON，按 RFC 7628 回复单个 0x01，
+// 再读取服务器随后发来的错误
+func (c *Client) oauthFailure(data []byte) error {
+	var body struct {
+		Status       string `json:"status"`
+		Scope        string `json:"scope"`
+		DiscoveryURI string `json:"openid-configuration"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return fmt.Errorf("pg: invalid OAUTHBEARER error response from server: %q", data)
+	}
+	var e = &OAuthError{Status: body.Status, Scope: body.Scope, DiscoveryURI: body.DiscoveryURI}
+	ar := frame.NewAuthSASLResponse()
+	ar.AuthResponse([]byte{0x01})
+	if err := c.writer.Send(ar.Data); err != nil {
+		return err
+	}
+	d, err := c.reader.Receive()
+	if err != nil {
+		e.Err = c.handleIOError(err)
+	} else if d.Type() == frame.TypeError {
+		e.Err = c.handlePgError(d)
+	}
+	return e
+}
@@ -0,0 +1,95 @@
+// Copyright 2026 YBCZ, Inc. All rights reserved.
+//
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file in the root of the source
+// tree.
+
+package client
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 与 libpq 的 require_auth 取值保持一致，oauth 为 libpq 18 新增
+const (
+	AuthMethodPassword    = "password"
+	AuthMethodMd5         = "md5"
+	AuthMethodGSS         = "gss"
+	AuthMethodSSPI        = "sspi"
+	AuthMethodScramSHA256 = "scram-sha-256"
+	AuthMethodOAuth       = "oauth"
+	AuthMethodNone        = "none"
+)
+
+var authMethods = []string{
+	AuthMethodPassword,
+	AuthMethodMd5,
+	AuthMethodGSS,
+	AuthMethodSSPI,
+	AuthMethodScramSHA256,
+	AuthMethodOAuth,
+	AuthMethodNone,
+}
+
+// RequireAuth 客户端可接受的认证方式，为空时不做限制
+type RequireAuth struct {
+	Raw     string
+	allowed map[string]bool
+}
+
+// ParseRequireAuth 解析 require_auth 的取值
+// 形如 "md5,scram-sha-256" 表示仅允许列出的方式；形如 "!password,!md5" 表示排除列出的方式。
+// 两种写法不能混用。
+func ParseRequireAuth(str string) (ra RequireAuth, err error) {
+	ra.Raw = str
+	if strings.TrimSpace(str) == "" {
+		return
+	}
+	var listed = make(map[string]bool)
+	var negated, positive bool
+	for _, item := range strings.Split(str, ",") {
+		method := strings.TrimSpace(item)
+		if strings.HasPrefix(method, "!") {
+			negated = true
+			method = method[1:]
+		} else {
+			positive = true
+		}
+		if negated && positive {
+			return ra, fmt.Errorf("pg: negative require_auth method %q cannot be mixed with non-negative methods", item)
+		}
+		if !isAuthMethod(method) {
+			return ra, fmt.Errorf("pg: invalid require_auth method: %q", method)
+		}
+		if listed[method] {
+			return ra, fmt.Errorf("pg: require_auth method %q is specified more than once", method)
+		}
+		listed[method] = true
+	}
+
+	ra.allowed = make(map[string]bool)
+	for _, method := range authMethods {
+		if listed[method] != negated {
+			ra.allowed[method] = true
+		}
+	}
+	return
+}
+
+func isAuthMethod(method string) bool {
+	for _, v := range authMethods {
+		if v == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Check 校验服务器所要求的认证方式是否被允许，须在发送任何凭据之前调用
+func (ra RequireAuth) Check(method, request string) error {
+	if ra.allowed == nil || ra.allowed[method] {
+		return nil
+	}
+	return fmt.Errorf("pg: authentication method requirement %q failed: %s", ra.Raw, request)
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"github.com/blusewang/pg/v2/internal/client/frame"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestParseRequireAuth(t *testing.T) {
+	var cases = []struct {
+		in      string
+		allowed []string
+		denied  []string
+		err     string
+	}{
+		{in: "", allowed: authMethods},
+		{in: "scram-sha-256", allowed: []string{AuthMethodScramSHA256}, denied: []string{AuthMethodPassword, AuthMethodMd5, AuthMethodNone}},
+		{in: "md5, scram-sha-256", allowed: []string{AuthMethodMd5, AuthMethodScramSHA256}, denied: []string{AuthMethodPassword, AuthMethodNone}},
+		{in: "!password,!md5", allowed: []string{AuthMethodScramSHA256, AuthMethodNone, AuthMethodGSS}, denied: []string{AuthMethodPassword, AuthMethodMd5}},
+		{in: "none", allowed: []string{AuthMethodNone}, denied: []string{AuthMethodPassword, AuthMethodScramSHA256}},
+		{in: "!none", allowed: []string{AuthMethodPassword, AuthMethodScramSHA256}, denied: []string{AuthMethodNone}},
+		{in: "md5,!password", err: "cannot be mixed"},
+		{in: "!md5,password", err: "cannot be mixed"},
+		{in: "md5,md5", err: "more than once"},
+		{in: "kerberos", err: "invalid require_auth method"},
+	}
+	for _, c := range cases {
+		ra, err := ParseRequireAuth(c.in)
+		if c.err != "" {
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("ParseRequireAuth(%q) = %v, want an error containing %q", c.in, err, c.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseRequireAuth(%q): %v", c.in, err)
+			continue
+		}
+		for _, m := range c.allowed {
+			if err = ra.Check(m, "test"); err != nil {
+				t.Errorf("ParseRequireAuth(%q) should allow %s: %v", c.in, m, err)
+			}
+		}
+		for _, m := range c.denied {
+			if err = ra.Check(m, "test"); err == nil {
+				t.Errorf("ParseRequireAuth(%q) should deny %s", c.in, m)
+			}
+		}
+	}
+}
+
+// startupRequireAuth 让客户端按 requireAuth 与发出 at 认证请求的模拟服务器完成启动，
+// 返回服务器是否收到了客户端发来的凭据，以及客户端的错误
+func startupRequireAuth(t *testing.T, requireAuth string, at uint32) (sent bool, err error) {
+	t.Helper()
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+
+	var server = &fakeServer{cn: serverConn}
+	var done = make(chan bool, 1)
+	go func() {
+		var sent bool
+		defer func() { done <- sent }()
+		if server.readStartup() != nil {
+			return
+		}
+		var data []byte
+		if at == frame.AuthTypeMd5 {
+			data = binary.BigEndian.AppendUint32(nil, 0x01020304)
+		}
+		if at != frame.AuthTypeOk {
+			if server.writeAuth(at, data) != nil {
+				return
+			}
+			if _, _, err := server.readMessage(); err != nil {
+				return
+			}
+			sent = true
+		}
+		if server.writeAuth(frame.AuthTypeOk, nil) != nil {
+			return
+		}
+		_ = server.writeMessage('Z', []byte{'I'})
+	}()
+
+	var c = NewClient()
+	c.Dsn.Parameter = map[string]string{"user": "alice"}
+	c.Dsn.Password = "secret"
+	if c.Dsn.RequireAuth, err = ParseRequireAuth(requireAuth); err != nil {
+		t.Fatal(err)
+	}
+	c.cn = clientConn
+	c.writer = frame.NewEncoder(clientConn)
+	c.reader = frame.NewDecoder(clientConn)
+	err = c.Startup(context.Background())
+	_ = clientConn.Close()
+	return <-done, err
+}
+
+func TestStartupRequireAuth(t *testing.T) {
+	var cases = []struct {
+		requireAuth string
+		at          uint32
+		ok          bool
+	}{
+		{"", frame.AuthTypePwd, true},
+		{"password", frame.AuthTypePwd, true},
+		{"scram-sha-256", frame.AuthTypePwd, false},
+		{"scram-sha-256", frame.AuthTypeMd5, false},
+		{"!password", frame.AuthTypePwd, false},
+		{"!password", frame.AuthTypeMd5, true},
+		{"md5,password", frame.AuthTypeMd5, true},
+		{"none", frame.AuthTypeOk, true},
+		{"none", frame.AuthTypePwd, false},
+		{"password", frame.AuthTypeOk, false},
+		{"!none", frame.AuthTypeOk, false},
+	}
+	for _, c := range cases {
+		sent, err := startupRequireAuth(t, c.requireAuth, c.at)
+		if c.ok {
+			if err != nil {
+				t.Errorf("require_auth=%q, request %d: %v", c.requireAuth, c.at, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), "authentication method requirement") {
+			t.Errorf("require_auth=%q, request %d: expected a requirement error, got %v", c.requireAuth, c.at, err)
+		}
+		if sent {
+			t.Errorf("require_auth=%q, request %d: credentials were sent before the request was refused", c.requireAuth, c.at)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2026 YBCZ, Inc. All rights reserved.
+//
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file in the root of the source
+// tree.
+
+package scram
+
+import (
+	"container/list"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+	"sync"
+)
+
+// keyCacheSize 缓存的条目上限，超出时淘汰最久未使用的条目
+const keyCacheSize = 128
+
+// cacheKey 以进程内随机密钥对哈希算法、用户、口令、盐及迭代次数计算的 HMAC，
+// 口令等不以任何可离线穷举的形式留在内存中
+type cacheKey [sha256.Size]byte
+
+type cacheEntry struct {
+	key       cacheKey
+	clientKey []byte
+	serverKey []byte
+}
+
+// keyCache 进程内共享的 ClientKey、ServerKey 缓存，连接池新建连接时无需重复执行 PBKDF2
+var keyCache = struct {
+	sync.Mutex
+	entries map[cacheKey]*list.Element
+	lru     *list.List
+}{entries: make(map[cacheKey]*list.Element), lru: list.New()}
+
+// cacheSecret 计算 cacheKey 的随机密钥，首次使用时生成；无法生成时为 nil，此时不使用缓存
+var cacheSecret = struct {
+	sync.Once
+	key []byte
+}{}
+
+func newCacheKey(newHash func() hash.Hash, user, pass string, salt []byte, iter int) (key cacheKey, ok bool) {
+	cacheSecret.Do(func() {
+		var b = make([]byte, 32)
+		if _, err := rand.Read(b); err == nil {
+			cacheSecret.key = b
+		}
+	})
+	if cacheSecret.key == nil {
+		return key, false
+	}
+	var mac = hmac.New(sha256.New, cacheSecret.key)
+	// 以空输入的摘要区分哈希算法，各字段带长度前缀，避免拼接产生歧义
+	for _, field := range [][]byte{newHash().Sum(nil), []byte(user), []byte(pass), salt} {
+		_ = binary.Write(mac, binary.BigEndian, uint32(len(field)))
+		mac.Write(field)
+	}
+	_ = binary.Write(mac, binary.BigEndian, int64(iter))
+	mac.Sum(key[:0])
+	return key, true
+}
+
+// loadKeys 返回缓存中密钥的副本，淘汰时原数据会被清零，因此调用方不能持有缓存中的切片
+func loadKeys(key cacheKey) (clientKey, serverKey []byte, ok bool) {
+	keyCache.Lock()
+	defer keyCache.Unlock()
+	el, ok := keyCache.entries[key]
+	if !ok {
+		return nil, nil, false
+	}
+	keyCache.lru.MoveToFront(el)
+	e := el.Value.(*cacheEntry)
+	return append([]byte(nil), e.clientKey...), append([]byte(nil), e.serverKey...), true
+}
+
+func storeKeys(key cacheKey, clientKey, serverKey []byte) {
+	keyCache.Lock()
+	defer keyCache.Unlock()
+	if el, ok := keyCache.entries[key]; ok {
+		keyCache.lru.MoveToFront(el)
+		return
+	}
+	keyCache.entries[key] = keyCache.lru.PushFront(&cacheEntry{
+		key:       key,
+		clientKey: append([]byte(nil), clientKey...),
+		serverKey: append([]byte(nil), serverKey...),
+	})
+	for keyCache.lru.Len() > keyCacheSize {
+		el := keyCache.lru.Back()
+		e := el.Value.(*cacheEntry)
+		keyCache.lru.Remove(el)
+		delete(keyCache.entries, e.key)
+		zero(e.clientKey)
+		zero(e.serverKey)
+	}
+}
+
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
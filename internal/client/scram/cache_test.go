@@ -0,0 +1,127 @@
+package scram
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func resetKeyCache() {
+	keyCache.Lock()
+	defer keyCache.Unlock()
+	keyCache.entries = make(map[cacheKey]*list.Element)
+	keyCache.lru = list.New()
+}
+
+func mustCacheKey(t *testing.T, user, pass string, salt []byte, iter int) cacheKey {
+	key, ok := newCacheKey(sha256.New, user, pass, salt, iter)
+	if !ok {
+		t.Fatal("the cache key secret is unavailable")
+	}
+	return key
+}
+
+func TestCacheKey(t *testing.T) {
+	var base = mustCacheKey(t, "u", "p", []byte("salt"), 4096)
+	if mustCacheKey(t, "u", "p", []byte("salt"), 4096) != base {
+		t.Error("equal inputs should give equal keys")
+	}
+	var others = []cacheKey{
+		mustCacheKey(t, "v", "p", []byte("salt"), 4096),
+		mustCacheKey(t, "u", "q", []byte("salt"), 4096),
+		mustCacheKey(t, "u", "p", []byte("salt2"), 4096),
+		mustCacheKey(t, "u", "p", []byte("salt"), 4097),
+		// 字段的边界不同
+		mustCacheKey(t, "up", "", []byte("salt"), 4096),
+		mustCacheKey(t, "u", "psalt", nil, 4096),
+	}
+	if key, ok := newCacheKey(sha1.New, "u", "p", []byte("salt"), 4096); ok {
+		others = append(others, key)
+	}
+	for i, key := range others {
+		if key == base {
+			t.Errorf("key %d should differ from the base key", i)
+		}
+	}
+}
+
+func TestKeyCacheHit(t *testing.T) {
+	resetKeyCache()
+	var key = mustCacheKey(t, "u", "p", []byte("salt"), 4096)
+	if _, _, ok := loadKeys(key); ok {
+		t.Fatal("an empty cache should miss")
+	}
+	var ck, sk = []byte{1, 2, 3}, []byte{4, 5, 6}
+	storeKeys(key, ck, sk)
+	ck[0] = 9 // 缓存保存的是副本
+	gotC, gotS, ok := loadKeys(key)
+	if !ok || !bytes.Equal(gotC, []byte{1, 2, 3}) || !bytes.Equal(gotS, []byte{4, 5, 6}) {
+		t.Fatalf("loadKeys = %v, %v, %v", gotC, gotS, ok)
+	}
+	gotC[0] = 9 // 返回的也是副本
+	if again, _, _ := loadKeys(key); again[0] != 1 {
+		t.Error("callers must not share the cached slice")
+	}
+}
+
+func TestKeyCacheEviction(t *testing.T) {
+	resetKeyCache()
+	var keys = make([]cacheKey, keyCacheSize+2)
+	for i := range keys {
+		keys[i] = mustCacheKey(t, "u", "p", []byte(fmt.Sprint(i)), 4096)
+	}
+	for i := 0; i < keyCacheSize; i++ {
+		storeKeys(keys[i], []byte{byte(i), 1}, []byte{byte(i), 2})
+	}
+	// 使用 keys[0] 后，最久未使用的是 keys[1]
+	if _, _, ok := loadKeys(keys[0]); !ok {
+		t.Fatal("keys[0] should be cached")
+	}
+	keyCache.Lock()
+	var evicted = keyCache.entries[keys[1]].Value.(*cacheEntry)
+	keyCache.Unlock()
+
+	storeKeys(keys[keyCacheSize], []byte{1}, []byte{2})
+	if _, _, ok := loadKeys(keys[1]); ok {
+		t.Error("the least recently used key should be evicted")
+	}
+	if _, _, ok := loadKeys(keys[0]); !ok {
+		t.Error("a recently used key should stay cached")
+	}
+	if !bytes.Equal(evicted.clientKey, []byte{0, 0}) || !bytes.Equal(evicted.serverKey, []byte{0, 0}) {
+		t.Errorf("evicted keys should be zeroed, got %v, %v", evicted.clientKey, evicted.serverKey)
+	}
+	if n := keyCache.lru.Len(); n != keyCacheSize || len(keyCache.entries) != keyCacheSize {
+		t.Errorf("cache holds %d entries, want %d", n, keyCacheSize)
+	}
+}
+
+func TestDeriveKeysConcurrent(t *testing.T) {
+	resetKeyCache()
+	var want = make(map[string][2][]byte)
+	for _, pass := range []string{"a", "b", "c"} {
+		c := NewClient(sha256.New, "u", pass)
+		salted := c.saltPassword([]byte("salt"), 64)
+		want[pass] = [2][]byte{c.hmac(salted, "Client Key"), c.hmac(salted, "Server Key")}
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(pass string) {
+			defer wg.Done()
+			c := NewClient(sha256.New, "u", pass)
+			c.deriveKeys([]byte("salt"), 64)
+			if !bytes.Equal(c.clientKey, want[pass][0]) || !bytes.Equal(c.serverKey, want[pass][1]) {
+				t.Errorf("deriveKeys for %q returned wrong keys", pass)
+			}
+		}([]string{"a", "b", "c"}[i%3])
+	}
+	wg.Wait()
+	if n := keyCache.lru.Len(); n != 3 {
+		t.Errorf("cache holds %d entries, want 3", n)
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2026 YBCZ, Inc. All rights reserved.
+//
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file in the root of the source
+// tree.
+
+package scram
+
+import (
+	"errors"
+	"golang.org/x/text/unicode/bidi"
+	"golang.org/x/text/unicode/norm"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SASLprep 按 RFC 4013 规范化字符串，与 PostgreSQL 的 pg_saslprep 一致：
+// 纯 ASCII 的字符串原样返回；非 ASCII 空白映射为空格，B.1 中的字符删除，再做 NFKC 规范化；
+// 含有 RFC 3454 中禁止的字符、未分配的码位、不符合双向文本规则或映射后为空时返回错误
+// 未分配的码位及双向文本的类别按 Go 及 golang.org/x/text 所带的 Unicode 版本判断，而非 RFC 3454 所依据的 Unicode 3.2
+func SASLprep(s string) (string, error) {
+	var ascii = true
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return s, nil
+	}
+	if !utf8.ValidString(s) {
+		return "", errors.New("scram: SASLprep input is not valid UTF-8")
+	}
+
+	// 映射
+	var mapped = make([]rune, 0, len(s))
+	for _, r := range s {
+		switch {
+		case inTable(r, nonASCIISpace):
+			mapped = append(mapped, ' ')
+		case inTable(r, mappedToNothing):
+		default:
+			mapped = append(mapped, r)
+		}
+	}
+	if len(mapped) == 0 {
+		return "", errors.New("scram: SASLprep output is empty")
+	}
+
+	// 规范化
+	var out = norm.NFKC.String(string(mapped))
+
+	// 禁止的字符
+	var hasRandAL, hasL bool
+	for _, r := range out {
+		if prohibited(r) {
+			return "", errors.New("scram: SASLprep input contains a prohibited character")
+		}
+		switch p, _ := bidi.LookupRune(r); p.Class() {
+		case bidi.R, bidi.AL:
+			hasRandAL = true
+		case bidi.L:
+			hasL = true
+		}
+	}
+
+	// 双向文本：含有 RandALCat 时不能含有 LCat，且首尾都必须是 RandALCat
+	if hasRandAL {
+		first, _ := utf8.DecodeRuneInString(out)
+		last, _ := utf8.DecodeLastRuneInString(out)
+		if hasL || !isRandAL(first) || !isRandAL(last) {
+			return "", errors.New("scram: SASLprep input violates the bidirectional text rules")
+		}
+	}
+	return out, nil
+}
+
+// saslprepOrRaw 规范化失败时与 libpq 一样退回原始字节
+func saslprepOrRaw(s string) string {
+	if prep, err := SASLprep(s); err == nil {
+		return prep
+	}
+	return s
+}
+
+func isRandAL(r rune) bool {
+	p, _ := bidi.LookupRune(r)
+	return p.Class() == bidi.R || p.Class() == bidi.AL
+}
+
+// prohibited RFC 4013 第 2.3 节禁止的字符，以及 A.1 中未分配的码位
+func prohibited(r rune) bool {
+	if inTable(r, nonASCIISpace) || inTable(r, prohibitedRanges) {
+		return true
+	}
+	// C.4 每个平面最后的两个码位
+	if r&0xfffe == 0xfffe {
+		return true
+	}
+	return !unicode.In(r, unicode.L, unicode.M, unicode.N, unicode.P, unicode.S, unicode.Z, unicode.Cc, unicode.Cf)
+}
+
+type runeRange struct {
+	lo, hi rune
+}
+
+func inTable(r rune, table []runeRange) bool {
+	for _, rr := range table {
+		if r >= rr.lo && r <= rr.hi {
+			return true
+		}
+	}
+	return false
+}
+
+// mappedToNothing RFC 3454 B.1
+var mappedToNothing = []runeRange{
+	{0x00AD, 0x00AD}, {0x034F, 0x034F}, {0x1806, 0x1806}, {0x180B, 0x180D},
+	{0x200B, 0x200D}, {0x2060, 0x2060}, {0xFE00, 0xFE0F}, {0xFEFF, 0xFEFF},
+}
+
+// nonASCIISpace RFC 3454 C.1.2
+var nonASCIISpace = []runeRange{
+	{0x00A0, 0x00A0}, {0x1680, 0x1680}, {0x2000, 0x200B}, {0x202F, 0x202F},
+	{0x205F, 0x205F}, {0x3000, 0x3000},
+}
+
+// prohibitedRanges RFC 3454 C.2.1、C.2.2、C.3、C.4、C.5、C.6、C.7、C.8、C.9
+var prohibitedRanges = []runeRange{
+	// C.2.1
+	{0x0000, 0x001F}, {0x007F, 0x007F},
+	// C.2.2
+	{0x0080, 0x009F}, {0x06DD, 0x06DD}, {0x070F, 0x070F}, {0x180E, 0x180E},
+	{0x200C, 0x200D}, {0x2028, 0x2029}, {0x2060, 0x2063}, {0x206A, 0x206F},
+	{0xFEFF, 0xFEFF}, {0xFFF9, 0xFFFC}, {0x1D173, 0x1D17A},
+	// C.3
+	{0xE000, 0xF8FF}, {0xF0000, 0xFFFFD}, {0x100000, 0x10FFFD},
+	// C.4
+	{0xFDD0, 0xFDEF},
+	// C.5
+	{0xD800, 0xDFFF},
+	// C.6
+	{0xFFF9, 0xFFFD},
+	// C.7
+	{0x2FF0, 0x2FFB},
+	// C.8
+	{0x0340, 0x0341}, {0x200E, 0x200F}, {0x202A, 0x202E},
+	// C.9
+	{0xE0001, 0xE0001}, {0xE0020, 0xE007F},
+}
@@ -0,0 +1,61 @@
+package scram
+
+import (
+	"testing"
+)
+
+func TestSASLprep(t *testing.T) {
+	var cases = []struct {
+		in  string
+		out string
+		err bool
+	}{
+		// RFC 4013 第 3 节的示例
+		{in: "I\u00adX", out: "IX"},     // 软连字符映射为空
+		{in: "user", out: "user"},       // 无变化
+		{in: "USER", out: "USER"},       // 保留大小写
+		{in: "\u00aa", out: "a"},        // NFKC
+		{in: "\u2168", out: "IX"},       // NFKC
+		{in: "\u06271", err: true},      // 双向文本：以 RandALCat 开头但不以其结尾
+		{in: "\u00e9\u0007", err: true}, // 禁止的字符
+		{in: "\u0007", out: "\u0007"},   // 纯 ASCII 原样返回，与 PostgreSQL 一致
+
+		{in: "a\u00a0b\u3000c", out: "a b c"},    // 非 ASCII 空白映射为空格
+		{in: "\u00adp\u200bw\ufe0f", out: "p w"}, // U+200B 同时属于 C.1.2，与 PostgreSQL 一样按空白处理
+		{in: "\u06271\u0628", out: "\u06271\u0628"},
+		{in: "\u0627a\u0628", err: true}, // RandALCat 与 LCat 混用
+		{in: "a\u0627", err: true},
+		{in: "\u00ad", err: true},      // 映射后为空
+		{in: "\ue000x", err: true},     // 私用区
+		{in: "\U000e0001", err: true},  // 标签字符
+		{in: "\u00e9\xff", err: true},  // 非法的 UTF-8
+		{in: "e\u0301", out: "\u00e9"}, // NFKC 组合
+		{in: "\u00e9", out: "\u00e9"},
+	}
+	for _, c := range cases {
+		out, err := SASLprep(c.in)
+		if c.err {
+			if err == nil {
+				t.Errorf("SASLprep(%+q) = %+q, want an error", c.in, out)
+			}
+			continue
+		}
+		if err != nil || out != c.out {
+			t.Errorf("SASLprep(%+q) = %+q, %v, want %+q", c.in, out, err, c.out)
+		}
+	}
+}
+
+func TestSASLprepOrRaw(t *testing.T) {
+	for in, want := range map[string]string{
+		"I\u00adX":       "IX",
+		"\u0627a\u0628":  "\u0627a\u0628",
+		"\u00e9\u0007":   "\u00e9\u0007",
+		"\xff\xfe\u00e9": "\xff\xfe\u00e9",
+		"p\u00a0w\u00aa": "p wa",
+	} {
+		if out := saslprepOrRaw(in); out != want {
+			t.Errorf("saslprepOrRaw(%+q) = %+q, want %+q", in, out, want)
+		}
+	}
+}
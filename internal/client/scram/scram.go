@@ -63,7 +63,8 @@ type Client struct {
 
 	clientNonce []byte
 	serverNonce []byte
-	saltedPass  []byte
+	clientKey   []byte
+	serverKey   []byte
 	authMsg     bytes.Buffer
 }
 
@@ -72,11 +73,14 @@ type Client struct {
 // For SCRAM-SHA-256, for example, use:
 //
 //	client := scram.NewClient(sha256.New, user, pass)
+//
+// The user and password are normalized with SASLprep like the PostgreSQL
+// server does, falling back to the raw bytes when SASLprep rejects them.
 func NewClient(newHash func() hash.Hash, user, pass string) *Client {
 	c := &Client{
 		newHash: newHash,
-		user:    user,
-		pass:    pass,
+		user:    saslprepOrRaw(user),
+		pass:    saslprepOrRaw(pass),
 	}
 	c.out.Grow(256)
 	c.authMsg.Grow(256)
@@ -180,7 +184,7 @@ func (c *Client) step2(in []byte) error {
 	if err != nil {
 		return fmt.Errorf("server sent an invalid SCRAM-SHA-256 iteration count: %q", fields[2])
 	}
-	c.saltPassword(salt, iterCount)
+	c.deriveKeys(salt, iterCount)
 
 	c.authMsg.WriteString(",c=biws,r=")
 	c.authMsg.Write(c.serverNonce)
@@ -204,13 +208,37 @@ func (c *Client) step3(in []byte) error {
 	} else if !isv {
 		return fmt.Errorf("unsupported SCRAM-SHA-256 final message from server: %q", in)
 	}
-	if !bytes.Equal(c.serverSignature(), fields[0][2:]) {
+	if !hmac.Equal(c.serverSignature(), fields[0][2:]) {
 		return fmt.Errorf("cannot authenticate SCRAM-SHA-256 server signature: %q", fields[0][2:])
 	}
 	return nil
 }
 
-func (c *Client) saltPassword(salt []byte, iterCount int) {
+// deriveKeys 计算 ClientKey 和 ServerKey，相同的用户、口令、盐及迭代次数直接使用缓存
+func (c *Client) deriveKeys(salt []byte, iterCount int) {
+	key, cacheable := newCacheKey(c.newHash, c.user, c.pass, salt, iterCount)
+	if cacheable {
+		var ok bool
+		if c.clientKey, c.serverKey, ok = loadKeys(key); ok {
+			return
+		}
+	}
+	saltedPass := c.saltPassword(salt, iterCount)
+	c.clientKey = c.hmac(saltedPass, "Client Key")
+	c.serverKey = c.hmac(saltedPass, "Server Key")
+	zero(saltedPass)
+	if cacheable {
+		storeKeys(key, c.clientKey, c.serverKey)
+	}
+}
+
+func (c *Client) hmac(key []byte, msg string) []byte {
+	mac := hmac.New(c.newHash, key)
+	mac.Write([]byte(msg))
+	return mac.Sum(nil)
+}
+
+func (c *Client) saltPassword(salt []byte, iterCount int) []byte {
 	mac := hmac.New(c.newHash, []byte(c.pass))
 	mac.Write(salt)
 	mac.Write([]byte{0, 0, 0, 1})
@@ -225,20 +253,17 @@ func (c *Client) saltPassword(salt []byte, iterCount int) {
 			hi[j] ^= b
 		}
 	}
-	c.saltedPass = hi
+	return hi
 }
 
 func (c *Client) clientProof() []byte {
-	mac := hmac.New(c.newHash, c.saltedPass)
-	mac.Write([]byte("Client Key"))
-	clientKey := mac.Sum(nil)
 	hash := c.newHash()
-	hash.Write(clientKey)
+	hash.Write(c.clientKey)
 	storedKey := hash.Sum(nil)
-	mac = hmac.New(c.newHash, storedKey)
+	mac := hmac.New(c.newHash, storedKey)
 	mac.Write(c.authMsg.Bytes())
 	clientProof := mac.Sum(nil)
-	for i, b := range clientKey {
+	for i, b := range c.clientKey {
 		clientProof[i] ^= b
 	}
 	clientProof64 := make([]byte, b64.EncodedLen(len(clientProof)))
@@ -247,11 +272,7 @@ func (c *Client) clientProof() []byte {
 }
 
 func (c *Client) serverSignature() []byte {
-	mac := hmac.New(c.newHash, c.saltedPass)
-	mac.Write([]byte("Server Key"))
-	serverKey := mac.Sum(nil)
-
-	mac = hmac.New(c.newHash, serverKey)
+	mac := hmac.New(c.newHash, c.serverKey)
 	mac.Write(c.authMsg.Bytes())
 	serverSignature := mac.Sum(nil)
 
@@ -0,0 +1,38 @@
+// Copyright 2026 YBCZ, Inc. All rights reserved.
+//
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file in the root of the source
+// tree.
+
+package scram
+
+import (
+	"crypto/sha256"
+	"strconv"
+	"strings"
+)
+
+// Verifier 生成服务器在 pg_authid.rolpassword 中保存的 SCRAM-SHA-256 校验值，
+// 格式为 SCRAM-SHA-256$<iterations>:<salt>$<StoredKey>:<ServerKey>，与服务器及 libpq 的 PQencryptPasswordConn 一致
+// 口令同样先做 SASLprep，失败时使用原始字节
+func Verifier(password string, salt []byte, iterations int) string {
+	c := &Client{newHash: sha256.New, pass: saslprepOrRaw(password)}
+	saltedPass := c.saltPassword(salt, iterations)
+	defer zero(saltedPass)
+	clientKey := c.hmac(saltedPass, "Client Key")
+	defer zero(clientKey)
+	storedKey := sha256.Sum256(clientKey)
+	serverKey := c.hmac(saltedPass, "Server Key")
+
+	var b strings.Builder
+	b.WriteString("SCRAM-SHA-256")
+	b.WriteByte('$')
+	b.WriteString(strconv.Itoa(iterations))
+	b.WriteByte(':')
+	b.WriteString(b64.EncodeToString(salt))
+	b.WriteByte('$')
+	b.WriteString(b64.EncodeToString(storedKey[:]))
+	b.WriteByte(':')
+	b.WriteString(b64.EncodeToString(serverKey))
+	return b.String()
+}
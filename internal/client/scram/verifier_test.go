@@ -0,0 +1,22 @@
+package scram
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestVerifier(t *testing.T) {
+	// RFC 7677 第 3 节的口令、盐及迭代次数，ServerKey 与其中的 ServerSignature 相符
+	salt, _ := base64.StdEncoding.DecodeString("W22ZaJ0SNY7soEsUEjb6gQ==")
+	var want = "SCRAM-SHA-256$4096:W22ZaJ0SNY7soEsUEjb6gQ==$WG5d8oPm3OtcPnkdi4Uo7BkeZkBFzpcXkuLmtbsT4qY=:wfPLwcE6nTWhTAmQ7tl2KeoiWGPlZqQxSrmfPwDl2dU="
+	if v := Verifier("pencil", salt, 4096); v != want {
+		t.Errorf("Verifier = %s, want %s", v, want)
+	}
+	// 口令先做 SASLprep，软连字符被删除
+	if v := Verifier("pen\u00adcil", salt, 4096); v != want {
+		t.Errorf("Verifier of an unnormalized password = %s, want %s", v, want)
+	}
+	if v := Verifier("pencil", salt, 4095); v == want {
+		t.Error("the iteration count should change the verifier")
+	}
+}
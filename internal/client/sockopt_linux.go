@@ -0,0 +1,45 @@
+// Copyright 2026 YBCZ, Inc. All rights reserved.
+//
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file in the root of the source
+// tree.
+
+//go:build linux
+
+package client
+
+import (
+	"golang.org/x/sys/unix"
+	"net"
+)
+
+// setSocketOptions 把 keepalives_interval、keepalives_count、tcp_user_timeout 应用到已建立的连接上
+// keepalives、keepalives_idle 已由 net.Dialer 处理
+func setSocketOptions(tc *net.TCPConn, dsn DataSourceName) (err error) {
+	rc, err := tc.SyscallConn()
+	if err != nil {
+		return
+	}
+	var opErr error
+	err = rc.Control(func(fd uintptr) {
+		if dsn.KeepAlive.Enable && dsn.KeepAlive.Interval > 0 {
+			opErr = unix.SetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_KEEPINTVL, int(dsn.KeepAlive.Interval.Seconds()))
+			if opErr != nil {
+				return
+			}
+		}
+		if dsn.KeepAlive.Enable && dsn.KeepAlive.Count > 0 {
+			opErr = unix.SetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_KEEPCNT, dsn.KeepAlive.Count)
+			if opErr != nil {
+				return
+			}
+		}
+		if dsn.TCPUserTimeout > 0 {
+			opErr = unix.SetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_USER_TIMEOUT, int(dsn.TCPUserTimeout.Milliseconds()))
+		}
+	})
+	if err == nil {
+		err = opErr
+	}
+	return
+}
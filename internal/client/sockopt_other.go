@@ -0,0 +1,32 @@
+// Copyright 2026 YBCZ, Inc. All rights reserved.
+//
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file in the root of the source
+// tree.
+
+//go:build !linux
+
+package client
+
+import (
+	"fmt"
+	"net"
+	"runtime"
+)
+
+// setSocketOptions 非 Linux 平台只支持 keepalives、keepalives_idle，
+// 设置了 keepalives_interval、keepalives_count、tcp_user_timeout 时返回错误，而不是静默忽略
+func setSocketOptions(_ *net.TCPConn, dsn DataSourceName) error {
+	var option string
+	switch {
+	case dsn.KeepAlive.Enable && dsn.KeepAlive.Interval > 0:
+		option = "keepalives_interval"
+	case dsn.KeepAlive.Enable && dsn.KeepAlive.Count > 0:
+		option = "keepalives_count"
+	case dsn.TCPUserTimeout > 0:
+		option = "tcp_user_timeout"
+	default:
+		return nil
+	}
+	return fmt.Errorf("pg: connection option %s is not supported on %s", option, runtime.GOOS)
+}
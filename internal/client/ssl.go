@@ -0,0 +1,291 @@
+// Copyright 2026 YBCZ, Inc. All rights reserved.
+//
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file in the root of the source
+// tree.
+
+package client
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+)
+
+// clientCertificate 按 sslcertmode 加载客户端证书，返回 nil 表示不发送证书
+// 证书及私钥优先使用内存中的 PEM，其次是 sslcert、sslkey 指定的文件；私钥加密时用 sslpassword 解密
+// 内存中的 PEM 只提供了证书或私钥之一时返回错误，不会与文件混用
+func (dsn DataSourceName) clientCertificate() (*tls.Certificate, error) {
+	if dsn.SSL.CertMode == "disable" {
+		return nil, nil
+	}
+	var certPEM, keyPEM = dsn.SSL.CertPEM, dsn.SSL.KeyPEM
+	if (certPEM == nil) != (keyPEM == nil) {
+		return nil, errors.New("pg: in-memory client certificate and private key must be provided together")
+	}
+	if certPEM == nil {
+		var err error
+		if certPEM, keyPEM, err = dsn.readCertFiles(); err != nil {
+			if dsn.SSL.CertMode == "require" {
+				return nil, err
+			}
+			// allow 时没有可用的证书就不发送
+			return nil, nil
+		}
+	}
+	keyPEM, err := decryptKeyPEM(keyPEM, dsn.SSL.Password)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("pg: cannot load client certificate: %w", err)
+	}
+	return &cert, nil
+}
+
+func (dsn DataSourceName) readCertFiles() (certPEM, keyPEM []byte, err error) {
+	if certPEM, err = os.ReadFile(dsn.SSL.Cert); err != nil {
+		return
+	}
+	info, err := os.Stat(dsn.SSL.Key)
+	if err != nil {
+		return
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return nil, nil, errors.New("pq: Private key file has group or world access. Permissions should be u=rw (0600) or less")
+	}
+	keyPEM, err = os.ReadFile(dsn.SSL.Key)
+	return
+}
+
+// rootCertPool 内存中的 PEM 或 sslrootcert 文件中的根证书，都没有时返回 nil
+// sslrootcert=system 时使用操作系统的证书库
+func (dsn DataSourceName) rootCertPool() (*x509.CertPool, error) {
+	var raw = dsn.SSL.RootCertPEM
+	if raw == nil && dsn.SSL.RootCert == "system" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, fmt.Errorf("pg: cannot load system certificate pool: %w", err)
+		}
+		return pool, nil
+	}
+	if raw == nil {
+		if _, err := os.Stat(dsn.SSL.RootCert); err != nil {
+			return nil, nil
+		}
+		var err error
+		if raw, err = os.ReadFile(dsn.SSL.RootCert); err != nil {
+			return nil, err
+		}
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(raw) {
+		return nil, errors.New("pg: can't parse root cert")
+	}
+	return pool, nil
+}
+
+// verifyChain 用根证书校验服务器的证书链，但不校验主机名，用于 verify-ca
+func verifyChain(roots *x509.CertPool) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("pg: server did not present a certificate")
+		}
+		var certs = make([]*x509.Certificate, len(rawCerts))
+		for i, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return err
+			}
+			certs[i] = cert
+		}
+		var opts = x509.VerifyOptions{Roots: roots, Intermediates: x509.NewCertPool()}
+		for _, cert := range certs[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		_, err := certs[0].Verify(opts)
+		return err
+	}
+}
+
+// decryptKeyPEM 解密加密的私钥，支持传统的 Proc-Type: 4,ENCRYPTED 格式及 PKCS#8 的 ENCRYPTED PRIVATE KEY
+// 未加密的私钥原样返回
+func decryptKeyPEM(keyPEM []byte, password string) ([]byte, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return keyPEM, nil
+	}
+	var der []byte
+	var err error
+	switch {
+	case block.Type == "ENCRYPTED PRIVATE KEY":
+		if password == "" {
+			return nil, errors.New("pg: client key is encrypted but sslpassword is not set")
+		}
+		if der, err = decryptPKCS8(block.Bytes, []byte(password)); err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+	case x509.IsEncryptedPEMBlock(block): //nolint:staticcheck // 仍需兼容 OpenSSL 传统格式的加密私钥
+		if password == "" {
+			return nil, errors.New("pg: client key is encrypted but sslpassword is not set")
+		}
+		if der, err = x509.DecryptPEMBlock(block, []byte(password)); err != nil { //nolint:staticcheck
+			return nil, fmt.Errorf("pg: cannot decrypt client key: %w", err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: der}), nil
+	}
+	return keyPEM, nil
+}
+
+var (
+	oidPBES2      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+	oidAES128CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidDESEDE3CBC = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+)
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkixAlgorithm
+	EncryptedData []byte
+}
+
+type pkixAlgorithm struct {
+	Algorithm  asn1.ObjectIdentifier
+	Parameters asn1.RawValue `asn1:"optional"`
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkixAlgorithm
+	EncryptionScheme  pkixAlgorithm
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int           `asn1:"optional"`
+	PRF            pkixAlgorithm `asn1:"optional"`
+}
+
+// decryptPKCS8 按 RFC 8018 的 PBES2（PBKDF2 + AES-CBC 或 3DES-CBC）解密 PKCS#8 私钥，
+// 这是 openssl pkcs8 -topk8 及 OpenSSL 3 默认生成的格式
+func decryptPKCS8(der, password []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, fmt.Errorf("pg: invalid encrypted client key: %w", err)
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("pg: unsupported client key encryption algorithm %v", info.Algorithm.Algorithm)
+	}
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("pg: invalid encrypted client key: %w", err)
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, fmt.Errorf("pg: unsupported client key derivation function %v", params.KeyDerivationFunc.Algorithm)
+	}
+	var kdf pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		return nil, fmt.Errorf("pg: invalid encrypted client key: %w", err)
+	}
+	var prf func() hash.Hash
+	switch alg := kdf.PRF.Algorithm; {
+	case len(alg) == 0 || alg.Equal(oidHMACSHA1):
+		prf = sha1.New
+	case alg.Equal(oidHMACSHA256):
+		prf = sha256.New
+	case alg.Equal(oidHMACSHA384):
+		prf = sha512.New384
+	case alg.Equal(oidHMACSHA512):
+		prf = sha512.New
+	default:
+		return nil, fmt.Errorf("pg: unsupported client key PRF %v", alg)
+	}
+
+	var keyLen int
+	var newCipher func([]byte) (cipher.Block, error)
+	switch alg := params.EncryptionScheme.Algorithm; {
+	case alg.Equal(oidAES128CBC):
+		keyLen, newCipher = 16, aes.NewCipher
+	case alg.Equal(oidAES192CBC):
+		keyLen, newCipher = 24, aes.NewCipher
+	case alg.Equal(oidAES256CBC):
+		keyLen, newCipher = 32, aes.NewCipher
+	case alg.Equal(oidDESEDE3CBC):
+		keyLen, newCipher = 24, des.NewTripleDESCipher
+	default:
+		return nil, fmt.Errorf("pg: unsupported client key cipher %v", alg)
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, fmt.Errorf("pg: invalid encrypted client key: %w", err)
+	}
+
+	block, err := newCipher(pbkdf2(prf, password, kdf.Salt, kdf.IterationCount, keyLen))
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() || len(info.EncryptedData) == 0 || len(info.EncryptedData)%block.BlockSize() != 0 {
+		return nil, errors.New("pg: invalid encrypted client key")
+	}
+	var plain = make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, info.EncryptedData)
+
+	// 去掉 PKCS#7 填充，填充不正确通常意味着 sslpassword 不对
+	var pad = int(plain[len(plain)-1])
+	if pad == 0 || pad > block.BlockSize() {
+		return nil, errors.New("pg: cannot decrypt client key, sslpassword may be incorrect")
+	}
+	for _, b := range plain[len(plain)-pad:] {
+		if int(b) != pad {
+			return nil, errors.New("pg: cannot decrypt client key, sslpassword may be incorrect")
+		}
+	}
+	plain = plain[:len(plain)-pad]
+	if _, err = x509.ParsePKCS8PrivateKey(plain); err != nil {
+		return nil, errors.New("pg: cannot decrypt client key, sslpassword may be incorrect")
+	}
+	return plain, nil
+}
+
+// pbkdf2 RFC 8018 的 PBKDF2
+func pbkdf2(prf func() hash.Hash, password, salt []byte, iter, keyLen int) []byte {
+	mac := hmac.New(prf, password)
+	var key = make([]byte, 0, keyLen)
+	for block := uint32(1); len(key) < keyLen; block++ {
+		mac.Reset()
+		mac.Write(salt)
+		mac.Write([]byte{byte(block >> 24), byte(block >> 16), byte(block >> 8), byte(block)})
+		u := mac.Sum(nil)
+		t := append([]byte(nil), u...)
+		for i := 1; i < iter; i++ {
+			mac.Reset()
+			mac.Write(u)
+			u = mac.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
@@ -0,0 +1,202 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/pem"
+	"github.com/blusewang/pg/v2/internal/client/frame"
+	"hash"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPBKDF2(t *testing.T) {
+	// RFC 6070 及 RFC 7914 第 11 节的测试向量
+	var cases = []struct {
+		prf      func() hash.Hash
+		password string
+		salt     string
+		iter     int
+		want     string
+	}{
+		{sha1.New, "password", "salt", 1, "0c60c80f961f0e71f3a9b524af6012062fe037a6"},
+		{sha1.New, "password", "salt", 2, "ea6c014dc72d6f8ccd1ed92ace1d41f0d8de8957"},
+		{sha1.New, "password", "salt", 4096, "4b007901b765489abead49d926f721d065a429c1"},
+		{sha1.New, "passwordPASSWORDpassword", "saltSALTsaltSALTsaltSALTsaltSALTsalt", 4096, "3d2eec4fe41c849b80c8d83662c0e44a8b291a964cf2f07038"},
+		{sha256.New, "passwd", "salt", 1, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+	}
+	for _, c := range cases {
+		want, _ := hex.DecodeString(c.want)
+		if got := pbkdf2(c.prf, []byte(c.password), []byte(c.salt), c.iter, len(want)); !bytes.Equal(got, want) {
+			t.Errorf("pbkdf2(%s, %s, %d) = %x, want %s", c.password, c.salt, c.iter, got, c.want)
+		}
+	}
+}
+
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+	raw, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestDecryptKeyPEM(t *testing.T) {
+	var plain = readTestdata(t, "key.pem")
+	block, _ := pem.Decode(plain)
+	// 各加密私钥都由 key.pem 以口令 secret 生成：
+	// key-aes256.pem  openssl pkcs8 -topk8 -v2 aes-256-cbc -v2prf hmacWithSHA256
+	// key-des3.pem    openssl pkcs8 -topk8 -v2 des3 -v2prf hmacWithSHA1
+	// key-legacy.pem  openssl ec -aes128，即传统的 Proc-Type: 4,ENCRYPTED 格式
+	for _, name := range []string{"key-aes256.pem", "key-des3.pem", "key-legacy.pem"} {
+		var encrypted = readTestdata(t, name)
+		out, err := decryptKeyPEM(encrypted, "secret")
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if _, err = tls.X509KeyPair(readTestdata(t, "cert.pem"), out); err != nil {
+			t.Errorf("%s: decrypted key does not match the certificate: %v", name, err)
+		}
+		if name != "key-legacy.pem" {
+			if b, _ := pem.Decode(out); b == nil || !bytes.Equal(b.Bytes, block.Bytes) {
+				t.Errorf("%s: decrypted key differs from key.pem", name)
+			}
+		}
+
+		if _, err = decryptKeyPEM(encrypted, "wrong"); err == nil {
+			t.Errorf("%s: a wrong password should fail", name)
+		}
+		if _, err = decryptKeyPEM(encrypted, ""); err == nil || !strings.Contains(err.Error(), "sslpassword is not set") {
+			t.Errorf("%s: a missing password = %v", name, err)
+		}
+	}
+	if out, err := decryptKeyPEM(plain, ""); err != nil || !bytes.Equal(out, plain) {
+		t.Errorf("an unencrypted key should be returned as is, got %v", err)
+	}
+}
+
+func TestDecryptPKCS8(t *testing.T) {
+	block, _ := pem.Decode(readTestdata(t, "key-aes256.pem"))
+	if _, err := decryptPKCS8(block.Bytes, []byte("wrong")); err == nil || !strings.Contains(err.Error(), "sslpassword may be incorrect") {
+		t.Errorf("a wrong password = %v", err)
+	}
+	if _, err := decryptPKCS8(block.Bytes[:len(block.Bytes)-1], []byte("secret")); err == nil {
+		t.Error("a truncated key should fail")
+	}
+	if _, err := decryptPKCS8([]byte{0x30, 0x00}, []byte("secret")); err == nil {
+		t.Error("an empty sequence should fail")
+	}
+}
+
+// tlsHandshake 让客户端与接受 SSLRequest 的模拟服务器完成 TLS 握手，返回客户端的错误
+func tlsHandshake(t *testing.T, dsn DataSourceName, clientAuth tls.ClientAuthType) error {
+	_, err := tlsHandshakeSNI(t, dsn, clientAuth)
+	return err
+}
+
+// tlsHandshakeSNI 同 tlsHandshake，同时返回服务器收到的 SNI
+func tlsHandshakeSNI(t *testing.T, dsn DataSourceName, clientAuth tls.ClientAuthType) (string, error) {
+	t.Helper()
+	cert, err := tls.X509KeyPair(readTestdata(t, "cert.pem"), readTestdata(t, "key.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+	var sni = make(chan string, 1)
+	go func() {
+		defer close(sni)
+		if _, err := io.ReadFull(serverConn, make([]byte, 8)); err != nil {
+			return
+		}
+		if _, err := serverConn.Write([]byte{'S'}); err != nil {
+			return
+		}
+		_ = tls.Server(serverConn, &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			ClientAuth:   clientAuth,
+			GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+				sni <- hello.ServerName
+				return nil, nil
+			},
+		}).Handshake()
+	}()
+
+	var c = NewClient()
+	c.Dsn = dsn
+	c.cn = clientConn
+	c.writer = frame.NewEncoder(clientConn)
+	c.reader = frame.NewDecoder(clientConn)
+	err = c.AutoSSL(context.Background())
+	return <-sni, err
+}
+
+func TestAutoSSLCertModeRequire(t *testing.T) {
+	var dsn DataSourceName
+	dsn.SSL.Mode = "require"
+	dsn.SSL.CertMode = "require"
+	dsn.SSL.CertPEM = readTestdata(t, "cert.pem")
+	dsn.SSL.KeyPEM = readTestdata(t, "key-aes256.pem")
+	dsn.SSL.Password = "secret"
+
+	err := tlsHandshake(t, dsn, tls.NoClientCert)
+	if err == nil || !strings.Contains(err.Error(), "did not request a client certificate") {
+		t.Errorf("a server that does not request a certificate = %v", err)
+	}
+	if err = tlsHandshake(t, dsn, tls.RequireAnyClientCert); err != nil {
+		t.Errorf("a server that requests a certificate: %v", err)
+	}
+	dsn.SSL.CertMode = "allow"
+	if err = tlsHandshake(t, dsn, tls.NoClientCert); err != nil {
+		t.Errorf("sslcertmode=allow: %v", err)
+	}
+}
+
+func TestClientCertificatePEM(t *testing.T) {
+	var dsn DataSourceName
+	dsn.SSL.CertMode = "allow"
+	dsn.SSL.Cert = filepath.Join("testdata", "cert.pem")
+	dsn.SSL.Key = filepath.Join("testdata", "key.pem")
+	for _, c := range []struct{ cert, key []byte }{{readTestdata(t, "cert.pem"), nil}, {nil, readTestdata(t, "key.pem")}} {
+		dsn.SSL.CertPEM, dsn.SSL.KeyPEM = c.cert, c.key
+		if cert, err := dsn.clientCertificate(); err == nil || cert != nil {
+			t.Errorf("a lone in-memory PEM = %v, %v", cert, err)
+		}
+	}
+	dsn.SSL.CertPEM, dsn.SSL.KeyPEM = readTestdata(t, "cert.pem"), readTestdata(t, "key.pem")
+	if cert, err := dsn.clientCertificate(); err != nil || cert == nil {
+		t.Errorf("in-memory certificate and key = %v, %v", cert, err)
+	}
+	dsn.SSL.CertMode = "disable"
+	dsn.SSL.KeyPEM = nil
+	if cert, err := dsn.clientCertificate(); err != nil || cert != nil {
+		t.Errorf("sslcertmode=disable = %v, %v", cert, err)
+	}
+}
+
+func TestAutoSSLServerName(t *testing.T) {
+	var dsn DataSourceName
+	dsn.Host = "localhost"
+	dsn.SSL.Mode = "verify-full"
+	dsn.SSL.RootCertPEM = readTestdata(t, "cert.pem")
+	dsn.SSL.CertMode = "disable"
+	sni, err := tlsHandshakeSNI(t, dsn, tls.NoClientCert)
+	if err != nil || sni != "localhost" {
+		t.Errorf("verify-full = SNI %q, %v", sni, err)
+	}
+	dsn.Host = "db.example.com"
+	if _, err = tlsHandshakeSNI(t, dsn, tls.NoClientCert); err == nil {
+		t.Error("a certificate for another host should fail verify-full")
+	}
+}
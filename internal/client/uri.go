@@ -0,0 +1,256 @@
+// Copyright 2026 YBCZ, Inc. All rights reserved.
+//
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file in the root of the source
+// tree.
+
+package client
+
+import (
+	"fmt"
+	"strings"
+)
+
+// isURI 连接串是否以 scheme:// 开头，keyword = value 形式的值中可能出现 ://，如 oauth_issuer=https://...
+func isURI(s string) bool {
+	var i = strings.Index(s, "://")
+	if i <= 0 {
+		return false
+	}
+	for j, c := range s[:i] {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || j > 0 && ('0' <= c && c <= '9' || c == '+' || c == '-' || c == '.')) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseURI 按 libpq 的规则把 postgresql://[user[:password]@][host][:port][/dbname][?param=value&...] 形式的连接串
+// 解析为与 keyword = value 形式相同的参数，查询参数可以是任何 keyword = value 形式接受的关键字，且优先于前面的部分
+// 每一部分都会做百分号解码，因此 Unix 域套接字目录可以写作 postgresql://%2Fvar%2Frun%2Fpostgresql/db，
+// 也可以写作 postgresql:///db?host=/var/run/postgresql
+func parseURI(uri string) (map[string]string, error) {
+	var rest string
+	var found bool
+	// 与 libpq 一致，只接受这两种前缀
+	for _, prefix := range []string{"postgresql://", "postgres://"} {
+		if strings.HasPrefix(uri, prefix) {
+			rest, found = uri[len(prefix):], true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("pg: invalid connection protocol: %s", uri[:strings.Index(uri, "://")])
+	}
+
+	var kv = make(map[string]string)
+	var query string
+	if i := strings.IndexByte(rest, '?'); i >= 0 {
+		rest, query = rest[:i], rest[i+1:]
+	}
+	var path string
+	var hasPath bool
+	if i := strings.IndexByte(rest, '/'); i >= 0 {
+		rest, path, hasPath = rest[:i], rest[i+1:], true
+	}
+
+	if i := strings.IndexByte(rest, '@'); i >= 0 {
+		var userInfo = rest[:i]
+		rest = rest[i+1:]
+		var password string
+		var hasPassword bool
+		if j := strings.IndexByte(userInfo, ':'); j >= 0 {
+			userInfo, password, hasPassword = userInfo[:j], userInfo[j+1:], true
+		}
+		if userInfo != "" {
+			v, err := uriDecode(userInfo)
+			if err != nil {
+				return nil, err
+			}
+			kv["user"] = v
+		}
+		if hasPassword {
+			v, err := uriDecode(password)
+			if err != nil {
+				return nil, err
+			}
+			kv["password"] = v
+		}
+	}
+
+	if strings.IndexByte(rest, ',') >= 0 {
+		return nil, fmt.Errorf("pg: multiple hosts in connection URI are not supported: %q", rest)
+	}
+	var host, port = rest, ""
+	if strings.HasPrefix(rest, "[") {
+		// IPv6 地址
+		var end = strings.IndexByte(rest, ']')
+		if end < 0 {
+			return nil, fmt.Errorf(`pg: missing "]" in IPv6 host address in URI: %q`, uri)
+		}
+		if end == 1 {
+			return nil, fmt.Errorf("pg: IPv6 host address may not be empty in URI: %q", uri)
+		}
+		host, rest = rest[1:end], rest[end+1:]
+		if rest != "" && rest[0] != ':' {
+			return nil, fmt.Errorf(`pg: unexpected character %q after IPv6 host address in URI: %q`, rest[0], uri)
+		}
+		if rest != "" {
+			port = rest[1:]
+		}
+	} else if i := strings.IndexByte(rest, ':'); i >= 0 {
+		host, port = rest[:i], rest[i+1:]
+	}
+	if host != "" {
+		v, err := uriDecode(host)
+		if err != nil {
+			return nil, err
+		}
+		kv["host"] = v
+	}
+	if port != "" {
+		v, err := uriDecode(port)
+		if err != nil {
+			return nil, err
+		}
+		kv["port"] = v
+	}
+	if hasPath && path != "" {
+		v, err := uriDecode(path)
+		if err != nil {
+			return nil, err
+		}
+		kv["dbname"] = v
+	}
+
+	if query == "" {
+		return kv, nil
+	}
+	for _, param := range strings.Split(query, "&") {
+		if param == "" {
+			continue
+		}
+		var eq = strings.IndexByte(param, '=')
+		if eq < 0 {
+			return nil, fmt.Errorf(`pg: missing key/value separator "=" in URI query parameter: %q`, param)
+		}
+		if strings.IndexByte(param[eq+1:], '=') >= 0 {
+			return nil, fmt.Errorf(`pg: extra key/value separator "=" in URI query parameter: %q`, param)
+		}
+		k, err := uriDecode(param[:eq])
+		if err != nil {
+			return nil, err
+		}
+		v, err := uriDecode(param[eq+1:])
+		if err != nil {
+			return nil, err
+		}
+		// 与 libpq 一致，兼容 JDBC 的 ssl=true
+		if k == "ssl" && v == "true" {
+			k, v = "sslmode", "require"
+		}
+		kv[k] = v
+	}
+	return kv, nil
+}
+
+// uriDecode 百分号解码；与 libpq 一致，"+" 不会解码为空格，%00 视为错误
+func uriDecode(s string) (string, error) {
+	if strings.IndexByte(s, '%') < 0 {
+		return s, nil
+	}
+	var b = make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			b = append(b, s[i])
+			continue
+		}
+		if i+2 >= len(s) {
+			return "", fmt.Errorf("pg: invalid percent-encoded token: %q", s)
+		}
+		hi, ok1 := unhex(s[i+1])
+		lo, ok2 := unhex(s[i+2])
+		if !ok1 || !ok2 {
+			return "", fmt.Errorf("pg: invalid percent-encoded token: %q", s)
+		}
+		if hi == 0 && lo == 0 {
+			return "", fmt.Errorf("pg: forbidden value %%00 in percent-encoded value: %q", s)
+		}
+		b = append(b, hi<<4|lo)
+		i += 2
+	}
+	return string(b), nil
+}
+
+func unhex(c byte) (byte, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0', true
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10, true
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// formatURI 把参数组成 postgresql:// 形式的连接串，host、port、user、password、dbname 放在对应的位置，其余作为查询参数
+func formatURI(kv [][2]string) string {
+	var parts = make(map[string]string)
+	var query []string
+	for _, item := range kv {
+		switch item[0] {
+		case "host", "port", "user", "password", "dbname":
+			parts[item[0]] = item[1]
+		default:
+			query = append(query, uriEncode(item[0])+"="+uriEncode(item[1]))
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("postgresql://")
+	if parts["user"] != "" || parts["password"] != "" {
+		b.WriteString(uriEncode(parts["user"]))
+		if parts["password"] != "" {
+			b.WriteByte(':')
+			b.WriteString(uriEncode(parts["password"]))
+		}
+		b.WriteByte('@')
+	}
+	if strings.IndexByte(parts["host"], ':') >= 0 && !strings.HasPrefix(parts["host"], "/") {
+		// IPv6 地址
+		b.WriteString("[" + strings.ReplaceAll(parts["host"], "%", "%25") + "]")
+	} else {
+		b.WriteString(uriEncode(parts["host"]))
+	}
+	if parts["port"] != "" {
+		b.WriteByte(':')
+		b.WriteString(uriEncode(parts["port"]))
+	}
+	if parts["dbname"] != "" {
+		b.WriteByte('/')
+		b.WriteString(uriEncode(parts["dbname"]))
+	}
+	if len(query) > 0 {
+		b.WriteByte('?')
+		b.WriteString(strings.Join(query, "&"))
+	}
+	return b.String()
+}
+
+// uriEncode 对字母、数字及 -._~ 以外的字节做百分号编码
+func uriEncode(s string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&15])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,321 @@
+// Copyright 2026 YBCZ, Inc. All rights reserved.
+//
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file in the root of the source
+// tree.
+
+package codec
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// ArrayDimension 数组某一维的长度及下标下界
+type ArrayDimension struct {
+	Length     int
+	LowerBound int
+}
+
+// Array 数组文本格式的解析结果，与 PostgreSQL 的 array_in、array_out 规则一致
+type Array struct {
+	Dims     []ArrayDimension
+	Elements [][]byte // 按行优先展开的元素，nil 表示 NULL
+}
+
+// ParseArray 解析数组的文本格式，如 {1,2,NULL}、{{"a b","c\"d"},{e,f}}、[0:1]={x,y}
+// delim 为元素类型的分隔符，除 box 使用 ';' 外均为 ','
+func ParseArray(src []byte, delim byte) (a Array, err error) {
+	p := arrayParser{src: src, delim: delim, leafDepth: -1}
+	if err = p.parse(); err != nil {
+		return a, fmt.Errorf("pg: invalid array value %q: %v", src, err)
+	}
+	return p.Array, nil
+}
+
+type arrayParser struct {
+	Array
+	src       []byte
+	pos       int
+	delim     byte
+	explicit  []ArrayDimension
+	lengths   []int
+	leafDepth int // 元素所在的层级，-1 表示尚未遇到元素
+}
+
+func (p *arrayParser) parse() (err error) {
+	p.skipSpace()
+	if p.peek() == '[' {
+		if err = p.parseDims(); err != nil {
+			return
+		}
+	}
+	p.skipSpace()
+	if p.peek() != '{' {
+		return fmt.Errorf("expected '{' at %d", p.pos)
+	}
+	if err = p.parseLevel(0); err != nil {
+		return
+	}
+	p.skipSpace()
+	if p.pos != len(p.src) {
+		return fmt.Errorf("junk after closing right brace at %d", p.pos)
+	}
+
+	if len(p.Elements) == 0 {
+		// 空数组没有维度
+		p.lengths = nil
+	}
+	if p.explicit != nil {
+		if len(p.explicit) != len(p.lengths) {
+			return fmt.Errorf("specified array dimensions do not match array contents")
+		}
+		for i, d := range p.explicit {
+			if d.Length != p.lengths[i] {
+				return fmt.Errorf("specified array dimensions do not match array contents")
+			}
+		}
+		p.Dims = p.explicit
+		return
+	}
+	for _, l := range p.lengths {
+		p.Dims = append(p.Dims, ArrayDimension{Length: l, LowerBound: 1})
+	}
+	return
+}
+
+// parseDims 解析 [lb:ub][lb:ub]= 形式的维度说明
+func (p *arrayParser) parseDims() error {
+	for p.peek() == '[' {
+		end := bytes.IndexByte(p.src[p.pos:], ']')
+		if end < 0 {
+			return fmt.Errorf("missing ']' in array dimensions")
+		}
+		spec := string(p.src[p.pos+1 : p.pos+end])
+		p.pos += end + 1
+		var lower, upper = 1, 0
+		var err error
+		if i := bytes.IndexByte([]byte(spec), ':'); i >= 0 {
+			if lower, err = strconv.Atoi(spec[:i]); err != nil {
+				return fmt.Errorf("invalid lower bound %q", spec[:i])
+			}
+			spec = spec[i+1:]
+		}
+		if upper, err = strconv.Atoi(spec); err != nil {
+			return fmt.Errorf("invalid upper bound %q", spec)
+		}
+		if upper < lower {
+			return fmt.Errorf("upper bound cannot be less than lower bound")
+		}
+		p.explicit = append(p.explicit, ArrayDimension{Length: upper - lower + 1, LowerBound: lower})
+	}
+	p.skipSpace()
+	if p.peek() != '=' {
+		return fmt.Errorf("missing '=' after array dimensions")
+	}
+	p.pos++
+	return nil
+}
+
+// parseLevel 解析一层花括号，depth 从 0 开始
+func (p *arrayParser) parseLevel(depth int) (err error) {
+	p.pos++ // '{'
+	if depth == len(p.lengths) {
+		p.lengths = append(p.lengths, -1)
+	}
+	var count int
+	p.skipSpace()
+	if p.peek() == '}' {
+		p.pos++
+		return p.setLength(depth, 0)
+	}
+	for {
+		p.skipSpace()
+		if p.peek() == '{' {
+			if p.leafDepth != -1 && depth >= p.leafDepth {
+				return fmt.Errorf("multidimensional arrays must have sub-arrays with matching dimensions")
+			}
+			if err = p.parseLevel(depth + 1); err != nil {
+				return
+			}
+		} else {
+			if p.leafDepth == -1 {
+				p.leafDepth = depth
+			} else if p.leafDepth != depth {
+				return fmt.Errorf("multidimensional arrays must have sub-arrays with matching dimensions")
+			}
+			var elem []byte
+			if elem, err = p.parseElement(); err != nil {
+				return
+			}
+			p.Elements = append(p.Elements, elem)
+		}
+		count++
+		p.skipSpace()
+		switch c := p.peek(); {
+		case c == p.delim:
+			p.pos++
+		case c == '}':
+			p.pos++
+			return p.setLength(depth, count)
+		default:
+			return fmt.Errorf("unexpected %q at %d", c, p.pos)
+		}
+	}
+}
+
+func (p *arrayParser) setLength(depth, length int) error {
+	if p.lengths[depth] == -1 {
+		p.lengths[depth] = length
+	} else if p.lengths[depth] != length {
+		return fmt.Errorf("multidimensional arrays must have sub-arrays with matching dimensions")
+	}
+	return nil
+}
+
+// parseElement 解析单个元素，处理双引号及反斜杠转义；不带引号的 NULL 返回 nil
+func (p *arrayParser) parseElement() (elem []byte, err error) {
+	elem = []byte{}
+	if p.peek() == '"' {
+		p.pos++
+		for {
+			if p.pos >= len(p.src) {
+				return nil, fmt.Errorf("unexpected end of input")
+			}
+			c := p.src[p.pos]
+			p.pos++
+			switch c {
+			case '\\':
+				if p.pos >= len(p.src) {
+					return nil, fmt.Errorf("unexpected end of input")
+				}
+				elem = append(elem, p.src[p.pos])
+				p.pos++
+			case '"':
+				return elem, nil
+			default:
+				elem = append(elem, c)
+			}
+		}
+	}
+
+	var escaped bool
+	var trimTo int // 最后一个有效字符之后的位置，用于去掉末尾的空白
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == p.delim || c == '}' {
+			break
+		}
+		switch c {
+		case '{', '"':
+			return nil, fmt.Errorf("unexpected %q at %d", c, p.pos)
+		case '\\':
+			p.pos++
+			if p.pos >= len(p.src) {
+				return nil, fmt.Errorf("unexpected end of input")
+			}
+			elem = append(elem, p.src[p.pos])
+			escaped = true
+			trimTo = len(elem)
+		default:
+			elem = append(elem, c)
+			if !isArraySpace(c) {
+				trimTo = len(elem)
+			}
+		}
+		p.pos++
+	}
+	elem = elem[:trimTo]
+	if len(elem) == 0 {
+		return nil, fmt.Errorf("empty element at %d", p.pos)
+	}
+	if !escaped && bytes.EqualFold(elem, []byte("NULL")) {
+		return nil, nil
+	}
+	return elem, nil
+}
+
+func (p *arrayParser) peek() byte {
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *arrayParser) skipSpace() {
+	for p.pos < len(p.src) && isArraySpace(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+func isArraySpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
+
+// FormatArray 输出数组的文本格式，必要时为元素加引号并转义
+// 下标下界不全为 1 时输出 [lb:ub]= 形式的维度说明
+func FormatArray(a Array, delim byte) []byte {
+	var b bytes.Buffer
+	var total = 1
+	for _, d := range a.Dims {
+		total *= d.Length
+	}
+	if len(a.Dims) == 0 || total == 0 {
+		return []byte("{}")
+	}
+	for _, d := range a.Dims {
+		if d.LowerBound != 1 {
+			for _, d := range a.Dims {
+				b.WriteString(fmt.Sprintf("[%d:%d]", d.LowerBound, d.LowerBound+d.Length-1))
+			}
+			b.WriteByte('=')
+			break
+		}
+	}
+	var pos int
+	formatArrayLevel(&b, a, delim, 0, &pos)
+	return b.Bytes()
+}
+
+func formatArrayLevel(b *bytes.Buffer, a Array, delim byte, depth int, pos *int) {
+	b.WriteByte('{')
+	for i := 0; i < a.Dims[depth].Length; i++ {
+		if i > 0 {
+			b.WriteByte(delim)
+		}
+		if depth+1 < len(a.Dims) {
+			formatArrayLevel(b, a, delim, depth+1, pos)
+			continue
+		}
+		writeArrayElement(b, a.Elements[*pos], delim)
+		*pos++
+	}
+	b.WriteByte('}')
+}
+
+func writeArrayElement(b *bytes.Buffer, elem []byte, delim byte) {
+	if elem == nil {
+		b.WriteString("NULL")
+		return
+	}
+	var quote = len(elem) == 0 || bytes.EqualFold(elem, []byte("NULL"))
+	for _, c := range elem {
+		if c == '"' || c == '\\' || c == '{' || c == '}' || c == delim || isArraySpace(c) {
+			quote = true
+			break
+		}
+	}
+	if !quote {
+		b.Write(elem)
+		return
+	}
+	b.WriteByte('"')
+	for _, c := range elem {
+		if c == '"' || c == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	b.WriteByte('"')
+}
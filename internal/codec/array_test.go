@@ -0,0 +1,97 @@
+package codec
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseArray(t *testing.T) {
+	var cases = []struct {
+		in       string
+		delim    byte
+		dims     []ArrayDimension
+		elements []string // "<NULL>" 表示 NULL
+	}{
+		{in: "{}", delim: ','},
+		{in: "{1,2,3}", delim: ',', dims: []ArrayDimension{{3, 1}}, elements: []string{"1", "2", "3"}},
+		{in: "{NULL,null,\"NULL\",NULLX}", delim: ',', dims: []ArrayDimension{{4, 1}}, elements: []string{"<NULL>", "<NULL>", "NULL", "NULLX"}},
+		{in: `{"a b","c\"d","e\\f",""}`, delim: ',', dims: []ArrayDimension{{4, 1}}, elements: []string{"a b", `c"d`, `e\f`, ""}},
+		{in: `{a\,b,\NULL,x\\}`, delim: ',', dims: []ArrayDimension{{3, 1}}, elements: []string{"a,b", "NULL", `x\`}},
+		{in: "{  a b  , c }", delim: ',', dims: []ArrayDimension{{2, 1}}, elements: []string{"a b", "c"}},
+		{in: "{{1,2},{3,4},{5,NULL}}", delim: ',', dims: []ArrayDimension{{3, 1}, {2, 1}}, elements: []string{"1", "2", "3", "4", "5", "<NULL>"}},
+		{in: "{{{1},{2}}}", delim: ',', dims: []ArrayDimension{{1, 1}, {2, 1}, {1, 1}}, elements: []string{"1", "2"}},
+		{in: "[0:2]={a,b,c}", delim: ',', dims: []ArrayDimension{{3, 0}}, elements: []string{"a", "b", "c"}},
+		{in: "[-1:0][2:3]={{a,b},{c,d}}", delim: ',', dims: []ArrayDimension{{2, -1}, {2, 2}}, elements: []string{"a", "b", "c", "d"}},
+		{in: "{(1,2),(0,0);(3,4),(2,2)}", delim: ';', dims: []ArrayDimension{{2, 1}}, elements: []string{"(1,2),(0,0)", "(3,4),(2,2)"}},
+	}
+	for _, c := range cases {
+		a, err := ParseArray([]byte(c.in), c.delim)
+		if err != nil {
+			t.Errorf("ParseArray(%s): %v", c.in, err)
+			continue
+		}
+		var elements []string
+		for _, e := range a.Elements {
+			if e == nil {
+				elements = append(elements, "<NULL>")
+			} else {
+				elements = append(elements, string(e))
+			}
+		}
+		if !reflect.DeepEqual(a.Dims, c.dims) || !reflect.DeepEqual(elements, c.elements) {
+			t.Errorf("ParseArray(%s) = %v %q, want %v %q", c.in, a.Dims, elements, c.dims, c.elements)
+		}
+	}
+
+	for _, in := range []string{"", "1,2", "{1,2", "{1,2}x", "{{1,2},{3}}", "{{1},2}", "{1,{2}}", "{,}", `{"a}`, "[1:3]={1,2}", "[2:1]={}", "[1:2]{1,2}", `{a"b}`} {
+		if _, err := ParseArray([]byte(in), ','); err == nil {
+			t.Errorf("ParseArray(%s) should fail", in)
+		}
+	}
+}
+
+func TestFormatArray(t *testing.T) {
+	var cases = []struct {
+		a     Array
+		delim byte
+		out   string
+	}{
+		{Array{}, ',', "{}"},
+		{Array{Dims: []ArrayDimension{{0, 1}}}, ',', "{}"},
+		{Array{Dims: []ArrayDimension{{3, 1}}, Elements: [][]byte{[]byte("1"), nil, []byte("NULL")}}, ',', `{1,NULL,"NULL"}`},
+		{Array{Dims: []ArrayDimension{{4, 1}}, Elements: [][]byte{[]byte("a b"), []byte(`c"d`), []byte(`e\f`), {}}}, ',', `{"a b","c\"d","e\\f",""}`},
+		{Array{Dims: []ArrayDimension{{2, 1}}, Elements: [][]byte{[]byte("{x}"), []byte("a,b")}}, ',', `{"{x}","a,b"}`},
+		{Array{Dims: []ArrayDimension{{2, 1}, {2, 1}}, Elements: [][]byte{[]byte("1"), []byte("2"), []byte("3"), []byte("4")}}, ',', "{{1,2},{3,4}}"},
+		{Array{Dims: []ArrayDimension{{2, 0}}, Elements: [][]byte{[]byte("a"), []byte("b")}}, ',', "[0:1]={a,b}"},
+		{Array{Dims: []ArrayDimension{{1, 1}, {2, -3}}, Elements: [][]byte{[]byte("a"), []byte("b")}}, ',', "[1:1][-3:-2]={{a,b}}"},
+		{Array{Dims: []ArrayDimension{{2, 1}}, Elements: [][]byte{[]byte("(1,2),(0,0)"), []byte("a;b")}}, ';', `{(1,2),(0,0);"a;b"}`},
+	}
+	for _, c := range cases {
+		var out = string(FormatArray(c.a, c.delim))
+		if out != c.out {
+			t.Errorf("FormatArray(%v) = %s, want %s", c.a, out, c.out)
+			continue
+		}
+		back, err := ParseArray([]byte(out), c.delim)
+		if err != nil {
+			t.Errorf("ParseArray(%s): %v", out, err)
+			continue
+		}
+		if len(c.a.Elements) > 0 && (!reflect.DeepEqual(back.Dims, c.a.Dims) || !reflect.DeepEqual(back.Elements, c.a.Elements)) {
+			t.Errorf("ParseArray(%s) = %v %q, want %v %q", out, back.Dims, back.Elements, c.a.Dims, c.a.Elements)
+		}
+	}
+}
+
+func TestArrayElementSpecialCharacters(t *testing.T) {
+	var elements = [][]byte{[]byte(" lead"), []byte("trail "), []byte("tab\there"), []byte("new\nline"), []byte(strings.Repeat(`\"`, 3))}
+	var a = Array{Dims: []ArrayDimension{{len(elements), 1}}, Elements: elements}
+	back, err := ParseArray(FormatArray(a, ','), ',')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back.Elements, elements) {
+		t.Errorf("round trip = %q, want %q", back.Elements, elements)
+	}
+}
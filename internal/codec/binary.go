@@ -0,0 +1,212 @@
+// Copyright 2026 YBCZ, Inc. All rights reserved.
+//
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file in the root of the source
+// tree.
+
+package codec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// pgEpochUnix 二进制格式中日期时间的起点 2000-01-01 00:00:00 UTC 对应的 Unix 时间
+const pgEpochUnix = 946684800
+
+// ParseTimestampBinary 解析 timestamp、timestamptz 的二进制格式，即自 2000-01-01 UTC 起的微秒数
+// 结果为 UTC 时间，timestamp 的墙上时间需由调用方按需要的时区重新解释
+func ParseTimestampBinary(src []byte) (time.Time, error) {
+	if len(src) != 8 {
+		return time.Time{}, fmt.Errorf("pg: invalid binary timestamp length %d", len(src))
+	}
+	var us = int64(binary.BigEndian.Uint64(src))
+	switch us {
+	case math.MaxInt64:
+		return PositiveInfinity, nil
+	case math.MinInt64:
+		return NegativeInfinity, nil
+	}
+	var sec, frac = us / 1000000, us % 1000000
+	if frac < 0 {
+		sec, frac = sec-1, frac+1000000
+	}
+	return time.Unix(sec+pgEpochUnix, frac*1000).UTC(), nil
+}
+
+// FormatTimestampBinary 输出 timestamptz 的二进制格式，不足 1 微秒的部分被舍去
+func FormatTimestampBinary(t time.Time) []byte {
+	var us int64
+	switch {
+	case t.Equal(PositiveInfinity):
+		us = math.MaxInt64
+	case t.Equal(NegativeInfinity):
+		us = math.MinInt64
+	default:
+		us = (t.Unix()-pgEpochUnix)*1000000 + int64(t.Nanosecond()/1000)
+	}
+	return binary.BigEndian.AppendUint64(nil, uint64(us))
+}
+
+// ParseDateBinary 解析 date 的二进制格式，即自 2000-01-01 起的天数，返回 UTC 的零点
+func ParseDateBinary(src []byte) (time.Time, error) {
+	if len(src) != 4 {
+		return time.Time{}, fmt.Errorf("pg: invalid binary date length %d", len(src))
+	}
+	var days = int32(binary.BigEndian.Uint32(src))
+	switch days {
+	case math.MaxInt32:
+		return PositiveInfinity, nil
+	case math.MinInt32:
+		return NegativeInfinity, nil
+	}
+	return time.Date(2000, time.January, 1+int(days), 0, 0, 0, 0, time.UTC), nil
+}
+
+// FormatDateBinary 输出 date 的二进制格式，取 t 的墙上日期
+func FormatDateBinary(t time.Time) []byte {
+	var days int64
+	switch {
+	case t.Equal(PositiveInfinity):
+		days = math.MaxInt32
+	case t.Equal(NegativeInfinity):
+		days = math.MinInt32
+	default:
+		y, m, d := t.Date()
+		days = (time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() - pgEpochUnix) / 86400
+	}
+	return binary.BigEndian.AppendUint32(nil, uint32(int32(days)))
+}
+
+// numeric 二进制格式中的符号位
+const (
+	numericPos    = 0x0000
+	numericNeg    = 0x4000
+	numericNaN    = 0xC000
+	numericPosInf = 0xD000
+	numericNegInf = 0xF000
+)
+
+// ParseNumericBinary 解析 numeric 的二进制格式，返回其文本格式
+// 二进制格式为 ndigits、weight、sign、dscale 四个 int16，随后是 ndigits 个万进制的数字
+func ParseNumericBinary(src []byte) (string, error) {
+	if len(src) < 8 {
+		return "", fmt.Errorf("pg: invalid binary numeric value: too short")
+	}
+	var ndigits = int(binary.BigEndian.Uint16(src))
+	var weight = int(int16(binary.BigEndian.Uint16(src[2:])))
+	var sign = binary.BigEndian.Uint16(src[4:])
+	var dscale = int(binary.BigEndian.Uint16(src[6:]))
+	if len(src) != 8+ndigits*2 {
+		return "", fmt.Errorf("pg: invalid binary numeric value: length mismatch")
+	}
+	switch sign {
+	case numericNaN:
+		return "NaN", nil
+	case numericPosInf:
+		return "Infinity", nil
+	case numericNegInf:
+		return "-Infinity", nil
+	case numericPos, numericNeg:
+	default:
+		return "", fmt.Errorf("pg: invalid binary numeric sign 0x%04x", sign)
+	}
+	var digit = func(w int) int {
+		// 第 i 个数字的权重为 weight-i
+		if i := weight - w; i >= 0 && i < ndigits {
+			return int(binary.BigEndian.Uint16(src[8+i*2:]))
+		}
+		return 0
+	}
+	var b strings.Builder
+	if sign == numericNeg {
+		b.WriteByte('-')
+	}
+	var intPart strings.Builder
+	for w := weight; w >= 0; w-- {
+		intPart.WriteString(fmt.Sprintf("%04d", digit(w)))
+	}
+	if s := strings.TrimLeft(intPart.String(), "0"); s != "" {
+		b.WriteString(s)
+	} else {
+		b.WriteByte('0')
+	}
+	if dscale > 0 {
+		var frac strings.Builder
+		for w := -1; frac.Len() < dscale; w-- {
+			frac.WriteString(fmt.Sprintf("%04d", digit(w)))
+		}
+		b.WriteByte('.')
+		b.WriteString(frac.String()[:dscale])
+	}
+	return b.String(), nil
+}
+
+// FormatNumericBinary 输出 Int / 10^scale 的 numeric 二进制格式
+func FormatNumericBinary(i *big.Int, scale int32) []byte {
+	var digits = new(big.Int).Abs(i).String()
+	var dscale = int(scale)
+	if dscale < 0 {
+		digits += strings.Repeat("0", -dscale)
+		dscale = 0
+	}
+	if len(digits) <= dscale {
+		digits = strings.Repeat("0", dscale-len(digits)+1) + digits
+	}
+	var intPart, fracPart = digits[:len(digits)-dscale], digits[len(digits)-dscale:]
+	// 按万进制分组：整数部分左侧补 0，小数部分右侧补 0
+	if n := len(intPart) % 4; n != 0 {
+		intPart = strings.Repeat("0", 4-n) + intPart
+	}
+	if n := len(fracPart) % 4; n != 0 {
+		fracPart += strings.Repeat("0", 4-n)
+	}
+	var all = intPart + fracPart
+	var groups = make([]uint16, 0, len(all)/4)
+	for p := 0; p < len(all); p += 4 {
+		var g uint16
+		for _, c := range all[p : p+4] {
+			g = g*10 + uint16(c-'0')
+		}
+		groups = append(groups, g)
+	}
+	var weight = len(intPart)/4 - 1
+	for len(groups) > 0 && groups[0] == 0 {
+		groups = groups[1:]
+		weight--
+	}
+	for len(groups) > 0 && groups[len(groups)-1] == 0 {
+		groups = groups[:len(groups)-1]
+	}
+	var sign uint16 = numericPos
+	if i.Sign() < 0 {
+		sign = numericNeg
+	}
+	if len(groups) == 0 {
+		weight = 0
+	}
+	var b = make([]byte, 0, 8+len(groups)*2)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(groups)))
+	b = binary.BigEndian.AppendUint16(b, uint16(int16(weight)))
+	b = binary.BigEndian.AppendUint16(b, sign)
+	b = binary.BigEndian.AppendUint16(b, uint16(dscale))
+	for _, g := range groups {
+		b = binary.BigEndian.AppendUint16(b, g)
+	}
+	return b
+}
+
+// FormatNumericSpecialBinary 输出 NaN（inf 为 0）、Infinity（inf 为 1）、-Infinity（inf 为 -1）的 numeric 二进制格式
+func FormatNumericSpecialBinary(inf int8) []byte {
+	var sign uint16 = numericNaN
+	if inf > 0 {
+		sign = numericPosInf
+	} else if inf < 0 {
+		sign = numericNegInf
+	}
+	return []byte{0, 0, 0, 0, byte(sign >> 8), byte(sign), 0, 0}
+}
@@ -0,0 +1,50 @@
+// Copyright 2026 YBCZ, Inc. All rights reserved.
+//
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file in the root of the source
+// tree.
+
+package codec
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// ParseBytea 解析 bytea 的文本格式，支持 bytea_output 为 hex（\x0102）和 escape（\001\\a）两种输出
+func ParseBytea(src []byte) ([]byte, error) {
+	if len(src) >= 2 && src[0] == '\\' && src[1] == 'x' {
+		b := make([]byte, hex.DecodedLen(len(src)-2))
+		if _, err := hex.Decode(b, src[2:]); err != nil {
+			return nil, fmt.Errorf("pg: invalid bytea value: %v", err)
+		}
+		return b, nil
+	}
+	b := make([]byte, 0, len(src))
+	for i := 0; i < len(src); i++ {
+		if src[i] != '\\' {
+			b = append(b, src[i])
+			continue
+		}
+		switch {
+		case i+1 < len(src) && src[i+1] == '\\':
+			b = append(b, '\\')
+			i++
+		case i+3 < len(src) && isOctal(src[i+1]) && isOctal(src[i+2]) && isOctal(src[i+3]):
+			b = append(b, (src[i+1]-'0')<<6|(src[i+2]-'0')<<3|(src[i+3]-'0'))
+			i += 3
+		default:
+			return nil, fmt.Errorf("pg: invalid bytea escape sequence at %d", i)
+		}
+	}
+	return b, nil
+}
+
+func isOctal(c byte) bool {
+	return c >= '0' && c <= '7'
+}
+
+// FormatBytea 输出 bytea 的 hex 格式
+func FormatBytea(b []byte) string {
+	return `\x` + hex.EncodeToString(b)
+}
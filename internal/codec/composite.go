@@ -0,0 +1,150 @@
+// Copyright 2026 YBCZ, Inc. All rights reserved.
+//
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file in the root of the source
+// tree.
+
+package codec
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Record 复合类型或匿名记录解码后的值
+// 复合类型的 Names 为各属性名，Values 按属性类型解码；匿名记录缺少类型信息，Names 为 nil，Values 均为 string
+// Values 中的 nil 表示 NULL
+type Record struct {
+	Names  []string
+	Values []interface{}
+}
+
+func (r *Record) Scan(src interface{}) error {
+	var raw []byte
+	switch v := src.(type) {
+	case Record:
+		*r = v
+		return nil
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	case nil:
+		return fmt.Errorf("pg: cannot scan NULL into Record, use *Record instead")
+	default:
+		return fmt.Errorf("pg: cannot scan %T into Record", src)
+	}
+	fields, err := ParseRecord(raw)
+	if err != nil {
+		return err
+	}
+	*r = Record{Values: make([]interface{}, len(fields))}
+	for i, f := range fields {
+		if f != nil {
+			r.Values[i] = string(f)
+		}
+	}
+	return nil
+}
+
+// ParseRecord 解析复合类型及匿名记录的文本格式，如 (1,"a b",,"")
+// 不带引号的空字段为 NULL，返回 nil；"" 为空字符串
+func ParseRecord(src []byte) (fields [][]byte, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("pg: invalid record value %q: %v", src, err)
+		}
+	}()
+	var s = bytes.TrimSpace(src)
+	if len(s) < 2 || s[0] != '(' || s[len(s)-1] != ')' {
+		return nil, fmt.Errorf("missing parentheses")
+	}
+	s = s[1 : len(s)-1]
+	var pos int
+	for {
+		var field []byte
+		var quoted bool
+		for pos < len(s) && s[pos] != ',' {
+			c := s[pos]
+			pos++
+			switch c {
+			case '"':
+				quoted = true
+				for {
+					if pos >= len(s) {
+						return nil, fmt.Errorf("unexpected end of input")
+					}
+					c = s[pos]
+					pos++
+					if c == '\\' {
+						if pos >= len(s) {
+							return nil, fmt.Errorf("unexpected end of input")
+						}
+						field = append(field, s[pos])
+						pos++
+					} else if c == '"' {
+						// 引号内连续两个双引号表示一个双引号
+						if pos >= len(s) || s[pos] != '"' {
+							break
+						}
+						field = append(field, '"')
+						pos++
+					} else {
+						field = append(field, c)
+					}
+				}
+			case '\\':
+				if pos >= len(s) {
+					return nil, fmt.Errorf("unexpected end of input")
+				}
+				field = append(field, s[pos])
+				pos++
+			default:
+				field = append(field, c)
+			}
+		}
+		if field == nil && quoted {
+			field = []byte{}
+		}
+		fields = append(fields, field)
+		if pos >= len(s) {
+			return fields, nil
+		}
+		pos++ // ','
+	}
+}
+
+// FormatRecord 输出复合类型的文本格式，nil 字段为 NULL
+func FormatRecord(fields [][]byte) []byte {
+	var b bytes.Buffer
+	b.WriteByte('(')
+	for i, f := range fields {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		if f == nil {
+			continue
+		}
+		var quote = len(f) == 0
+		for _, c := range f {
+			if c == '"' || c == '\\' || c == '(' || c == ')' || c == ',' || isArraySpace(c) {
+				quote = true
+				break
+			}
+		}
+		if !quote {
+			b.Write(f)
+			continue
+		}
+		b.WriteByte('"')
+		for _, c := range f {
+			if c == '"' || c == '\\' {
+				b.WriteByte(c)
+			}
+			b.WriteByte(c)
+		}
+		b.WriteByte('"')
+	}
+	b.WriteByte(')')
+	return b.Bytes()
+}
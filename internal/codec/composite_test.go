@@ -0,0 +1,91 @@
+package codec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRecord(t *testing.T) {
+	var cases = []struct {
+		in   string
+		want []interface{} // string 或 nil
+	}{
+		{`(1,a)`, []interface{}{"1", "a"}},
+		{` (1,"a b") `, []interface{}{"1", "a b"}},
+		{`(,"",x)`, []interface{}{nil, "", "x"}},
+		{`("say ""hi""","back\\slash",a\,b)`, []interface{}{`say "hi"`, `back\slash`, "a,b"}},
+		{`("(1,""x y"")",)`, []interface{}{`(1,"x y")`, nil}},
+		{`(ab"c,d"e)`, []interface{}{"abc,de"}},
+		{`(中文,"值")`, []interface{}{"中文", "值"}},
+	}
+	for _, c := range cases {
+		fields, err := ParseRecord([]byte(c.in))
+		if err != nil {
+			t.Errorf("ParseRecord(%s): %v", c.in, err)
+			continue
+		}
+		if got := recordStrings(fields); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("ParseRecord(%s) = %#v, want %#v", c.in, got, c.want)
+		}
+		back, err := ParseRecord(FormatRecord(fields))
+		if err != nil || !reflect.DeepEqual(recordStrings(back), c.want) {
+			t.Errorf("round trip of %s = %#v, %v", c.in, recordStrings(back), err)
+		}
+	}
+	for _, in := range []string{``, `1,2`, `(1,2`, `("a)`, `(a\)`} {
+		if _, err := ParseRecord([]byte(in)); err == nil {
+			t.Errorf("ParseRecord(%s) should fail", in)
+		}
+	}
+}
+
+func TestFormatRecord(t *testing.T) {
+	var cases = []struct {
+		in  [][]byte
+		out string
+	}{
+		{[][]byte{[]byte("1"), []byte("a")}, `(1,a)`},
+		{[][]byte{nil, {}, []byte("x")}, `(,"",x)`},
+		{[][]byte{[]byte(`a "b"`), []byte(`c\d`), []byte("e,f"), []byte("(g)")}, `("a ""b""","c\\d","e,f","(g)")`},
+	}
+	for _, c := range cases {
+		if out := string(FormatRecord(c.in)); out != c.out {
+			t.Errorf("FormatRecord = %s, want %s", out, c.out)
+		}
+	}
+
+	// 嵌套的记录先格式化内层，再作为外层的一个属性
+	var inner = FormatRecord([][]byte{[]byte("1"), []byte("x y"), nil})
+	var outer = FormatRecord([][]byte{inner, []byte("2")})
+	if string(outer) != `("(1,""x y"",)",2)` {
+		t.Errorf("nested FormatRecord = %s", outer)
+	}
+	fields, err := ParseRecord(outer)
+	if err != nil || string(fields[0]) != string(inner) {
+		t.Fatalf("ParseRecord(%s) = %q, %v", outer, fields, err)
+	}
+	fields, err = ParseRecord(fields[0])
+	if err != nil || !reflect.DeepEqual(recordStrings(fields), []interface{}{"1", "x y", nil}) {
+		t.Errorf("inner record = %q, %v", fields, err)
+	}
+}
+
+func TestRecordScan(t *testing.T) {
+	var r Record
+	if err := r.Scan(`(1,,"a b")`); err != nil || !reflect.DeepEqual(r.Values, []interface{}{"1", nil, "a b"}) || r.Names != nil {
+		t.Errorf("Scan = %#v, %v", r, err)
+	}
+	if err := r.Scan(nil); err == nil {
+		t.Error("Scan(nil) should fail")
+	}
+}
+
+func recordStrings(fields [][]byte) []interface{} {
+	var values = make([]interface{}, len(fields))
+	for i, f := range fields {
+		if f != nil {
+			values[i] = string(f)
+		}
+	}
+	return values
+}
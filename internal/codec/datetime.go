@@ -0,0 +1,198 @@
+// Copyright 2026 YBCZ, Inc. All rights reserved.
+//
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file in the root of the source
+// tree.
+
+package codec
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PositiveInfinity、NegativeInfinity 分别对应 timestamp、timestamptz、date 的 infinity 和 -infinity
+// 二者都落在 PostgreSQL 可表示的范围之外，作为参数传入时会还原为 infinity 和 -infinity
+var (
+	PositiveInfinity = time.Date(294277, time.January, 1, 0, 0, 0, 0, time.UTC)
+	NegativeInfinity = time.Date(-4713, time.January, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// ParseTimestamp 解析 DateStyle=ISO 时 timestamp、timestamptz 的文本格式
+// 如 2006-01-02 15:04:05.999999、2006-01-02 15:04:05.999999+08、0044-03-15 12:00:00+00:53:28 BC
+// 不带时区偏移时按 loc 解释墙上时间
+func ParseTimestamp(str string, loc *time.Location) (t time.Time, err error) {
+	switch str {
+	case "infinity":
+		return PositiveInfinity, nil
+	case "-infinity":
+		return NegativeInfinity, nil
+	}
+	var s, bc = trimBC(str)
+	var p = strings.IndexByte(s, ' ')
+	if p < 0 {
+		return t, fmt.Errorf("pg: invalid timestamp value %q", str)
+	}
+	year, month, day, err := parseDate(s[:p], bc)
+	if err != nil {
+		return t, fmt.Errorf("pg: invalid timestamp value %q", str)
+	}
+	hour, minute, sec, nsec, zone, err := parseClockTime(s[p+1:], loc)
+	if err != nil {
+		return t, fmt.Errorf("pg: invalid timestamp value %q", str)
+	}
+	return time.Date(year, month, day, hour, minute, sec, nsec, zone), nil
+}
+
+// ParseDate 解析 DateStyle=ISO 时 date 的文本格式，返回 loc 中当天的零点
+func ParseDate(str string, loc *time.Location) (t time.Time, err error) {
+	switch str {
+	case "infinity":
+		return PositiveInfinity, nil
+	case "-infinity":
+		return NegativeInfinity, nil
+	}
+	var s, bc = trimBC(str)
+	year, month, day, err := parseDate(s, bc)
+	if err != nil {
+		return t, fmt.Errorf("pg: invalid date value %q", str)
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, loc), nil
+}
+
+// ParseTime 解析 time、timetz 的文本格式，日期部分固定为 0000-01-01
+// 不带时区偏移时按 loc 解释墙上时间
+func ParseTime(str string, loc *time.Location) (t time.Time, err error) {
+	hour, minute, sec, nsec, zone, err := parseClockTime(str, loc)
+	if err != nil {
+		return t, fmt.Errorf("pg: invalid time value %q", str)
+	}
+	return time.Date(0, time.January, 1, hour, minute, sec, nsec, zone), nil
+}
+
+func trimBC(s string) (string, bool) {
+	if strings.HasSuffix(s, " BC") {
+		return s[:len(s)-3], true
+	}
+	return s, false
+}
+
+// parseDate 解析 YYYY-MM-DD，年份可以超过 4 位；公元前的年份转为天文纪年，即 1 BC 为第 0 年
+func parseDate(s string, bc bool) (year int, month time.Month, day int, err error) {
+	var parts = strings.Split(s, "-")
+	if len(parts) != 3 {
+		return 0, 0, 0, fmt.Errorf("invalid date %q", s)
+	}
+	var m int
+	if year, err = strconv.Atoi(parts[0]); err != nil {
+		return
+	}
+	if m, err = strconv.Atoi(parts[1]); err != nil {
+		return
+	}
+	if day, err = strconv.Atoi(parts[2]); err != nil {
+		return
+	}
+	if bc {
+		year = 1 - year
+	}
+	return year, time.Month(m), day, nil
+}
+
+// parseClockTime 解析 HH:MM:SS[.ffffff][+-HH[:MM[:SS]]]
+func parseClockTime(s string, loc *time.Location) (hour, minute, sec, nsec int, zone *time.Location, err error) {
+	zone = loc
+	if p := strings.IndexAny(s, "+-"); p >= 0 {
+		var offset int
+		if offset, err = parseOffset(s[p:]); err != nil {
+			return
+		}
+		zone = time.FixedZone("", offset)
+		s = s[:p]
+	}
+	var parts = strings.Split(s, ":")
+	if len(parts) != 3 {
+		err = fmt.Errorf("invalid time %q", s)
+		return
+	}
+	if hour, err = strconv.Atoi(parts[0]); err != nil {
+		return
+	}
+	if minute, err = strconv.Atoi(parts[1]); err != nil {
+		return
+	}
+	var secPart, fracPart = parts[2], ""
+	if p := strings.IndexByte(secPart, '.'); p >= 0 {
+		secPart, fracPart = secPart[:p], secPart[p+1:]
+	}
+	if sec, err = strconv.Atoi(secPart); err != nil {
+		return
+	}
+	if fracPart != "" {
+		if len(fracPart) > 9 {
+			fracPart = fracPart[:9]
+		}
+		if nsec, err = strconv.Atoi(fracPart + strings.Repeat("0", 9-len(fracPart))); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// parseOffset 解析 +HH、+HH:MM、+HH:MM:SS 形式的时区偏移，返回秒数
+func parseOffset(s string) (offset int, err error) {
+	var sign = 1
+	if s[0] == '-' {
+		sign = -1
+	}
+	var parts = strings.Split(s[1:], ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid time zone offset %q", s)
+	}
+	var unit = 3600
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, err
+		}
+		offset += n * unit
+		unit /= 60
+	}
+	return sign * offset, nil
+}
+
+// FormatTimestamp 输出 timestamptz 可接受的文本格式，同时适用于 timestamp 和 date 参数
+// 服务器解析 timestamp、date 时会忽略时区偏移，因此调用方应先把 t 转到期望的时区
+func FormatTimestamp(t time.Time) string {
+	if t.Equal(PositiveInfinity) {
+		return "infinity"
+	} else if t.Equal(NegativeInfinity) {
+		return "-infinity"
+	}
+	var year, bc = t.Year(), false
+	if year <= 0 {
+		year, bc = 1-year, true
+	}
+	var b = make([]byte, 0, 40)
+	b = append(b, fmt.Sprintf("%04d-%02d-%02d %02d:%02d:%02d", year, t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second())...)
+	if ns := t.Nanosecond(); ns != 0 {
+		b = append(b, strings.TrimRight(fmt.Sprintf(".%09d", ns), "0")...)
+	}
+	_, offset := t.Zone()
+	if offset < 0 {
+		b = append(b, '-')
+		offset = -offset
+	} else {
+		b = append(b, '+')
+	}
+	b = append(b, fmt.Sprintf("%02d:%02d", offset/3600, offset%3600/60)...)
+	if offset%60 != 0 {
+		b = append(b, fmt.Sprintf(":%02d", offset%60)...)
+	}
+	if bc {
+		b = append(b, " BC"...)
+	}
+	return string(b)
+}
@@ -0,0 +1,124 @@
+package codec
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	var shanghai = time.FixedZone("", 8*3600)
+	var cases = []struct {
+		in   string
+		loc  *time.Location
+		want time.Time
+	}{
+		{"2006-01-02 15:04:05", time.UTC, time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"2006-01-02 15:04:05.123456", shanghai, time.Date(2006, 1, 2, 15, 4, 5, 123456000, shanghai)},
+		{"2006-01-02 15:04:05.5+08", time.UTC, time.Date(2006, 1, 2, 7, 4, 5, 500000000, time.UTC)},
+		{"2006-01-02 15:04:05-03:30", time.UTC, time.Date(2006, 1, 2, 18, 34, 5, 0, time.UTC)},
+		{"1900-01-01 00:00:00+00:53:28", time.UTC, time.Date(1899, 12, 31, 23, 6, 32, 0, time.UTC)},
+		{"0001-01-01 00:00:00 BC", time.UTC, time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0044-03-15 12:00:00+00 BC", time.UTC, time.Date(-43, 3, 15, 12, 0, 0, 0, time.UTC)},
+		{"20000-01-01 00:00:00+00", time.UTC, time.Date(20000, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"infinity", time.UTC, PositiveInfinity},
+		{"-infinity", time.UTC, NegativeInfinity},
+	}
+	for _, c := range cases {
+		got, err := ParseTimestamp(c.in, c.loc)
+		if err != nil {
+			t.Errorf("ParseTimestamp(%q): %v", c.in, err)
+			continue
+		}
+		if !got.Equal(c.want) {
+			t.Errorf("ParseTimestamp(%q) = %v, want %v", c.in, got, c.want)
+		}
+	}
+	for _, in := range []string{"", "2006-01-02", "2006-01 15:04:05", "2006-01-02 15:04", "2006-01-02 15:04:05+x"} {
+		if _, err := ParseTimestamp(in, time.UTC); err == nil {
+			t.Errorf("ParseTimestamp(%q) should fail", in)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	var loc = time.FixedZone("", -5*3600)
+	var cases = []struct {
+		in   string
+		want time.Time
+	}{
+		{"2006-01-02", time.Date(2006, 1, 2, 0, 0, 0, 0, loc)},
+		{"0001-12-31 BC", time.Date(0, 12, 31, 0, 0, 0, 0, loc)},
+		{"4713-01-01 BC", time.Date(-4712, 1, 1, 0, 0, 0, 0, loc)},
+		{"infinity", PositiveInfinity},
+		{"-infinity", NegativeInfinity},
+	}
+	for _, c := range cases {
+		got, err := ParseDate(c.in, loc)
+		if err != nil {
+			t.Errorf("ParseDate(%q): %v", c.in, err)
+			continue
+		}
+		if !got.Equal(c.want) {
+			t.Errorf("ParseDate(%q) = %v, want %v", c.in, got, c.want)
+		}
+	}
+	if _, err := ParseDate("2006/01/02", loc); err == nil {
+		t.Error("ParseDate(2006/01/02) should fail")
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	var loc = time.FixedZone("", 3600)
+	var cases = []struct {
+		in     string
+		want   string
+		offset int
+	}{
+		{"15:04:05", "15:04:05", 3600},
+		{"00:00:00.000001", "00:00:00.000001", 3600},
+		{"24:00:00", "00:00:00", 3600},
+		{"15:04:05+08", "15:04:05", 8 * 3600},
+		{"15:04:05.5-03:30", "15:04:05.5", -(3*3600 + 30*60)},
+		{"15:04:05+05:30:15", "15:04:05", 5*3600 + 30*60 + 15},
+	}
+	for _, c := range cases {
+		got, err := ParseTime(c.in, loc)
+		if err != nil {
+			t.Errorf("ParseTime(%q): %v", c.in, err)
+			continue
+		}
+		if _, offset := got.Zone(); got.Format("15:04:05.999999") != c.want || offset != c.offset {
+			t.Errorf("ParseTime(%q) = %s %d, want %s %d", c.in, got.Format("15:04:05.999999"), offset, c.want, c.offset)
+		}
+	}
+	if _, err := ParseTime("15:04", loc); err == nil {
+		t.Error("ParseTime(15:04) should fail")
+	}
+}
+
+func TestFormatTimestamp(t *testing.T) {
+	var cases = []struct {
+		in   time.Time
+		want string
+	}{
+		{time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC), "2006-01-02 15:04:05+00:00"},
+		{time.Date(2006, 1, 2, 15, 4, 5, 120000000, time.FixedZone("", 8*3600)), "2006-01-02 15:04:05.12+08:00"},
+		{time.Date(2006, 1, 2, 15, 4, 5, 1, time.FixedZone("", -(3*3600+30*60))), "2006-01-02 15:04:05.000000001-03:30"},
+		{time.Date(1899, 12, 31, 23, 6, 32, 0, time.FixedZone("", 3208)), "1899-12-31 23:06:32+00:53:28"},
+		{time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC), "0001-01-01 00:00:00+00:00 BC"},
+		{time.Date(-43, 3, 15, 12, 0, 0, 0, time.UTC), "0044-03-15 12:00:00+00:00 BC"},
+		{PositiveInfinity, "infinity"},
+		{NegativeInfinity, "-infinity"},
+	}
+	for _, c := range cases {
+		var got = FormatTimestamp(c.in)
+		if got != c.want {
+			t.Errorf("FormatTimestamp(%v) = %q, want %q", c.in, got, c.want)
+			continue
+		}
+		back, err := ParseTimestamp(got, time.UTC)
+		if err != nil || !back.Equal(c.in) {
+			t.Errorf("ParseTimestamp(%q) = %v, %v, want %v", got, back, err, c.in)
+		}
+	}
+}
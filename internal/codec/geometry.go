@@ -0,0 +1,472 @@
+// Copyright 2026 YBCZ, Inc. All rights reserved.
+//
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file in the root of the source
+// tree.
+
+package codec
+
+import (
+	"database/sql/driver"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Point 对应 point，文本格式为 (x,y)
+type Point struct {
+	X, Y float64
+}
+
+// Line 对应 line，即直线 Ax + By + C = 0，文本格式为 {A,B,C}
+type Line struct {
+	A, B, C float64
+}
+
+// Lseg 对应 lseg，文本格式为 [(x1,y1),(x2,y2)]
+type Lseg struct {
+	P1, P2 Point
+}
+
+// Box 对应 box，文本格式为 (x1,y1),(x2,y2)，服务器总是先输出右上角
+type Box struct {
+	High, Low Point
+}
+
+// Path 对应 path，闭合路径的文本格式为 ((x1,y1),...)，开放路径为 [(x1,y1),...]
+type Path struct {
+	Points []Point
+	Closed bool
+}
+
+// Polygon 对应 polygon，文本格式为 ((x1,y1),...)
+type Polygon struct {
+	Points []Point
+}
+
+// Circle 对应 circle，文本格式为 <(x,y),r>
+type Circle struct {
+	Center Point
+	Radius float64
+}
+
+// parseFloats 取出几何类型文本格式中的全部数值，忽略括号与逗号
+func parseFloats(src []byte, name string) ([]float64, error) {
+	var fields = strings.FieldsFunc(string(src), func(r rune) bool {
+		return strings.ContainsRune("()[]{}<>, \t\n\r", r)
+	})
+	var fs = make([]float64, len(fields))
+	for i, f := range fields {
+		var err error
+		if fs[i], err = strconv.ParseFloat(f, 64); err != nil {
+			return nil, fmt.Errorf("pg: invalid %s value %q", name, src)
+		}
+	}
+	return fs, nil
+}
+
+func parseFixedFloats(src []byte, name string, n int) ([]float64, error) {
+	fs, err := parseFloats(src, name)
+	if err == nil && len(fs) != n {
+		err = fmt.Errorf("pg: invalid %s value %q", name, src)
+	}
+	return fs, err
+}
+
+func parsePoints(src []byte, name string) ([]Point, error) {
+	fs, err := parseFloats(src, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(fs)%2 != 0 || len(fs) == 0 {
+		return nil, fmt.Errorf("pg: invalid %s value %q", name, src)
+	}
+	var ps = make([]Point, len(fs)/2)
+	for i := range ps {
+		ps[i] = Point{fs[i*2], fs[i*2+1]}
+	}
+	return ps, nil
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func formatPoints(ps []Point) string {
+	var ss = make([]string, len(ps))
+	for i, p := range ps {
+		ss[i] = p.String()
+	}
+	return strings.Join(ss, ",")
+}
+
+// geometryText 取出 Scan 收到的文本格式
+func geometryText(src interface{}, name string) ([]byte, error) {
+	switch v := src.(type) {
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	case nil:
+		return nil, fmt.Errorf("pg: cannot scan NULL into %s, use *%s instead", name, name)
+	}
+	return nil, fmt.Errorf("pg: cannot scan %T into %s", src, name)
+}
+
+func readFloats(src []byte, name string, n int) ([]float64, error) {
+	if len(src) != n*8 {
+		return nil, fmt.Errorf("pg: invalid binary %s length %d", name, len(src))
+	}
+	var fs = make([]float64, n)
+	for i := range fs {
+		fs[i] = math.Float64frombits(binary.BigEndian.Uint64(src[i*8:]))
+	}
+	return fs, nil
+}
+
+func appendFloats(b []byte, fs ...float64) []byte {
+	for _, f := range fs {
+		b = binary.BigEndian.AppendUint64(b, math.Float64bits(f))
+	}
+	return b
+}
+
+// readPoints 读取 path、polygon 二进制格式中 4 字节个数开头的点
+func readPoints(src []byte, name string) ([]Point, error) {
+	if len(src) < 4 {
+		return nil, fmt.Errorf("pg: invalid binary %s length %d", name, len(src))
+	}
+	var n = int(binary.BigEndian.Uint32(src))
+	fs, err := readFloats(src[4:], name, n*2)
+	if err != nil {
+		return nil, err
+	}
+	var ps = make([]Point, n)
+	for i := range ps {
+		ps[i] = Point{fs[i*2], fs[i*2+1]}
+	}
+	return ps, nil
+}
+
+func appendPoints(b []byte, ps []Point) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(ps)))
+	for _, p := range ps {
+		b = appendFloats(b, p.X, p.Y)
+	}
+	return b
+}
+
+// ParsePoint 解析 point 的文本格式
+func ParsePoint(src []byte) (Point, error) {
+	fs, err := parseFixedFloats(src, "point", 2)
+	if err != nil {
+		return Point{}, err
+	}
+	return Point{fs[0], fs[1]}, nil
+}
+
+func (p Point) String() string {
+	return "(" + formatFloat(p.X) + "," + formatFloat(p.Y) + ")"
+}
+
+func (p *Point) Scan(src interface{}) (err error) {
+	if v, ok := src.(Point); ok {
+		*p = v
+		return nil
+	}
+	raw, err := geometryText(src, "Point")
+	if err == nil {
+		*p, err = ParsePoint(raw)
+	}
+	return
+}
+
+func (p Point) Value() (driver.Value, error) {
+	return p.String(), nil
+}
+
+func (p Point) MarshalBinary() ([]byte, error) {
+	return appendFloats(nil, p.X, p.Y), nil
+}
+
+func (p *Point) UnmarshalBinary(src []byte) error {
+	fs, err := readFloats(src, "point", 2)
+	if err == nil {
+		*p = Point{fs[0], fs[1]}
+	}
+	return err
+}
+
+// ParseLine 解析 line 的文本格式
+func ParseLine(src []byte) (Line, error) {
+	fs, err := parseFixedFloats(src, "line", 3)
+	if err != nil {
+		return Line{}, err
+	}
+	if fs[0] == 0 && fs[1] == 0 {
+		return Line{}, errors.New("pg: invalid line value: A and B cannot both be zero")
+	}
+	return Line{fs[0], fs[1], fs[2]}, nil
+}
+
+func (l Line) String() string {
+	return "{" + formatFloat(l.A) + "," + formatFloat(l.B) + "," + formatFloat(l.C) + "}"
+}
+
+func (l *Line) Scan(src interface{}) (err error) {
+	if v, ok := src.(Line); ok {
+		*l = v
+		return nil
+	}
+	raw, err := geometryText(src, "Line")
+	if err == nil {
+		*l, err = ParseLine(raw)
+	}
+	return
+}
+
+func (l Line) Value() (driver.Value, error) {
+	return l.String(), nil
+}
+
+func (l Line) MarshalBinary() ([]byte, error) {
+	return appendFloats(nil, l.A, l.B, l.C), nil
+}
+
+func (l *Line) UnmarshalBinary(src []byte) error {
+	fs, err := readFloats(src, "line", 3)
+	if err == nil {
+		*l = Line{fs[0], fs[1], fs[2]}
+	}
+	return err
+}
+
+// ParseLseg 解析 lseg 的文本格式
+func ParseLseg(src []byte) (Lseg, error) {
+	fs, err := parseFixedFloats(src, "lseg", 4)
+	if err != nil {
+		return Lseg{}, err
+	}
+	return Lseg{Point{fs[0], fs[1]}, Point{fs[2], fs[3]}}, nil
+}
+
+func (l Lseg) String() string {
+	return "[" + l.P1.String() + "," + l.P2.String() + "]"
+}
+
+func (l *Lseg) Scan(src interface{}) (err error) {
+	if v, ok := src.(Lseg); ok {
+		*l = v
+		return nil
+	}
+	raw, err := geometryText(src, "Lseg")
+	if err == nil {
+		*l, err = ParseLseg(raw)
+	}
+	return
+}
+
+func (l Lseg) Value() (driver.Value, error) {
+	return l.String(), nil
+}
+
+func (l Lseg) MarshalBinary() ([]byte, error) {
+	return appendFloats(nil, l.P1.X, l.P1.Y, l.P2.X, l.P2.Y), nil
+}
+
+func (l *Lseg) UnmarshalBinary(src []byte) error {
+	fs, err := readFloats(src, "lseg", 4)
+	if err == nil {
+		*l = Lseg{Point{fs[0], fs[1]}, Point{fs[2], fs[3]}}
+	}
+	return err
+}
+
+// ParseBox 解析 box 的文本格式，与服务器一致，两个角会被整理为右上角在前
+func ParseBox(src []byte) (Box, error) {
+	fs, err := parseFixedFloats(src, "box", 4)
+	if err != nil {
+		return Box{}, err
+	}
+	return Box{
+		High: Point{math.Max(fs[0], fs[2]), math.Max(fs[1], fs[3])},
+		Low:  Point{math.Min(fs[0], fs[2]), math.Min(fs[1], fs[3])},
+	}, nil
+}
+
+func (b Box) String() string {
+	return b.High.String() + "," + b.Low.String()
+}
+
+func (b *Box) Scan(src interface{}) (err error) {
+	if v, ok := src.(Box); ok {
+		*b = v
+		return nil
+	}
+	raw, err := geometryText(src, "Box")
+	if err == nil {
+		*b, err = ParseBox(raw)
+	}
+	return
+}
+
+func (b Box) Value() (driver.Value, error) {
+	return b.String(), nil
+}
+
+func (b Box) MarshalBinary() ([]byte, error) {
+	return appendFloats(nil, b.High.X, b.High.Y, b.Low.X, b.Low.Y), nil
+}
+
+func (b *Box) UnmarshalBinary(src []byte) error {
+	fs, err := readFloats(src, "box", 4)
+	if err == nil {
+		*b = Box{Point{fs[0], fs[1]}, Point{fs[2], fs[3]}}
+	}
+	return err
+}
+
+// ParsePath 解析 path 的文本格式，以 [ 开头的为开放路径
+func ParsePath(src []byte) (Path, error) {
+	ps, err := parsePoints(src, "path")
+	if err != nil {
+		return Path{}, err
+	}
+	var s = strings.TrimSpace(string(src))
+	return Path{Points: ps, Closed: !strings.HasPrefix(s, "[")}, nil
+}
+
+func (p Path) String() string {
+	if p.Closed {
+		return "(" + formatPoints(p.Points) + ")"
+	}
+	return "[" + formatPoints(p.Points) + "]"
+}
+
+func (p *Path) Scan(src interface{}) (err error) {
+	if v, ok := src.(Path); ok {
+		*p = v
+		return nil
+	}
+	raw, err := geometryText(src, "Path")
+	if err == nil {
+		*p, err = ParsePath(raw)
+	}
+	return
+}
+
+func (p Path) Value() (driver.Value, error) {
+	return p.String(), nil
+}
+
+// MarshalBinary 二进制格式为 1 字节的是否闭合，随后是 4 字节的点数及各点
+func (p Path) MarshalBinary() ([]byte, error) {
+	var b = []byte{0}
+	if p.Closed {
+		b[0] = 1
+	}
+	return appendPoints(b, p.Points), nil
+}
+
+func (p *Path) UnmarshalBinary(src []byte) error {
+	if len(src) < 1 {
+		return fmt.Errorf("pg: invalid binary path length %d", len(src))
+	}
+	ps, err := readPoints(src[1:], "path")
+	if err == nil {
+		*p = Path{Points: ps, Closed: src[0] != 0}
+	}
+	return err
+}
+
+// ParsePolygon 解析 polygon 的文本格式
+func ParsePolygon(src []byte) (Polygon, error) {
+	ps, err := parsePoints(src, "polygon")
+	return Polygon{Points: ps}, err
+}
+
+func (p Polygon) String() string {
+	return "(" + formatPoints(p.Points) + ")"
+}
+
+func (p *Polygon) Scan(src interface{}) (err error) {
+	if v, ok := src.(Polygon); ok {
+		*p = v
+		return nil
+	}
+	raw, err := geometryText(src, "Polygon")
+	if err == nil {
+		*p, err = ParsePolygon(raw)
+	}
+	return
+}
+
+func (p Polygon) Value() (driver.Value, error) {
+	return p.String(), nil
+}
+
+// MarshalBinary 二进制格式为 4 字节的点数及各点
+func (p Polygon) MarshalBinary() ([]byte, error) {
+	return appendPoints(nil, p.Points), nil
+}
+
+func (p *Polygon) UnmarshalBinary(src []byte) error {
+	ps, err := readPoints(src, "polygon")
+	if err == nil {
+		*p = Polygon{Points: ps}
+	}
+	return err
+}
+
+// ParseCircle 解析 circle 的文本格式
+func ParseCircle(src []byte) (Circle, error) {
+	fs, err := parseFixedFloats(src, "circle", 3)
+	if err != nil {
+		return Circle{}, err
+	}
+	if fs[2] < 0 {
+		return Circle{}, errors.New("pg: invalid circle value: radius cannot be negative")
+	}
+	return Circle{Point{fs[0], fs[1]}, fs[2]}, nil
+}
+
+func (c Circle) String() string {
+	return "<" + c.Center.String() + "," + formatFloat(c.Radius) + ">"
+}
+
+func (c *Circle) Scan(src interface{}) (err error) {
+	if v, ok := src.(Circle); ok {
+		*c = v
+		return nil
+	}
+	raw, err := geometryText(src, "Circle")
+	if err == nil {
+		*c, err = ParseCircle(raw)
+	}
+	return
+}
+
+func (c Circle) Value() (driver.Value, error) {
+	return c.String(), nil
+}
+
+func (c Circle) MarshalBinary() ([]byte, error) {
+	return appendFloats(nil, c.Center.X, c.Center.Y, c.Radius), nil
+}
+
+func (c *Circle) UnmarshalBinary(src []byte) error {
+	fs, err := readFloats(src, "circle", 3)
+	if err == nil {
+		*c = Circle{Point{fs[0], fs[1]}, fs[2]}
+	}
+	return err
+}
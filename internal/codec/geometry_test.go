@@ -0,0 +1,121 @@
+package codec
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+)
+
+type geometryValue interface {
+	sql.Scanner
+	driver.Valuer
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}
+
+func TestGeometry(t *testing.T) {
+	var cases = []struct {
+		in   string
+		want geometryValue // 解析结果，同时作为 Scan 及 UnmarshalBinary 的目标类型
+		out  string
+	}{
+		{"(1,2)", &Point{1, 2}, "(1,2)"},
+		{" ( -1.5 , 2e3 ) ", &Point{-1.5, 2000}, "(-1.5,2000)"},
+		{"(Infinity,-Infinity)", &Point{math.Inf(1), math.Inf(-1)}, "(Infinity,-Infinity)"},
+		{"{1,-1,0}", &Line{1, -1, 0}, "{1,-1,0}"},
+		{"[(0,0),(1,1)]", &Lseg{Point{0, 0}, Point{1, 1}}, "[(0,0),(1,1)]"},
+		{"(1,1),(0,0)", &Box{Point{1, 1}, Point{0, 0}}, "(1,1),(0,0)"},
+		{"(0,1),(1,0)", &Box{Point{1, 1}, Point{0, 0}}, "(1,1),(0,0)"},
+		{"[(0,0),(1,1),(2,0)]", &Path{Points: []Point{{0, 0}, {1, 1}, {2, 0}}}, "[(0,0),(1,1),(2,0)]"},
+		{"((0,0),(1,1),(2,0))", &Path{Points: []Point{{0, 0}, {1, 1}, {2, 0}}, Closed: true}, "((0,0),(1,1),(2,0))"},
+		{"((0,0),(0,1),(1,1))", &Polygon{Points: []Point{{0, 0}, {0, 1}, {1, 1}}}, "((0,0),(0,1),(1,1))"},
+		{"<(1,2),3>", &Circle{Point{1, 2}, 3}, "<(1,2),3>"},
+	}
+	for _, c := range cases {
+		var typ = reflect.TypeOf(c.want).Elem()
+		var got = reflect.New(typ).Interface().(geometryValue)
+		if err := got.Scan(c.in); err != nil {
+			t.Errorf("%v.Scan(%q): %v", typ, c.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%v.Scan(%q) = %+v, want %+v", typ, c.in, got, c.want)
+		}
+		if v, err := got.Value(); err != nil || v != c.out {
+			t.Errorf("%v.Value() = %v, %v, want %s", typ, v, err, c.out)
+		}
+		raw, err := got.MarshalBinary()
+		if err != nil {
+			t.Errorf("%v.MarshalBinary(): %v", typ, err)
+			continue
+		}
+		var back = reflect.New(typ).Interface().(geometryValue)
+		if err = back.UnmarshalBinary(raw); err != nil || !reflect.DeepEqual(back, c.want) {
+			t.Errorf("%v binary round trip = %+v, %v, want %+v", typ, back, err, c.want)
+		}
+	}
+}
+
+func TestGeometryBinaryLayout(t *testing.T) {
+	raw, _ := Path{Points: []Point{{1, 2}}, Closed: true}.MarshalBinary()
+	if len(raw) != 1+4+16 || raw[0] != 1 || raw[4] != 1 {
+		t.Errorf("path binary = %x", raw)
+	}
+	raw, _ = Circle{Point{1, 2}, 3}.MarshalBinary()
+	if len(raw) != 24 || math.Float64frombits(binary.BigEndian.Uint64(raw[16:])) != 3 {
+		t.Errorf("circle binary = %x", raw)
+	}
+}
+
+func TestGeometryInvalid(t *testing.T) {
+	var cases = []struct {
+		dst geometryValue
+		in  string
+	}{
+		{new(Point), "(1)"},
+		{new(Point), "(1,x)"},
+		{new(Line), "{0,0,1}"},
+		{new(Line), "{1,2}"},
+		{new(Lseg), "[(0,0)]"},
+		{new(Box), "(1,1)"},
+		{new(Path), "[]"},
+		{new(Path), "[(0,0),(1)]"},
+		{new(Polygon), "((0,0),(1))"},
+		{new(Circle), "<(1,2),-1>"},
+	}
+	for _, c := range cases {
+		if err := c.dst.Scan(c.in); err == nil {
+			t.Errorf("%T.Scan(%q) should fail", c.dst, c.in)
+		}
+	}
+	if err := new(Point).Scan(nil); err == nil {
+		t.Error("Point.Scan(nil) should fail")
+	}
+	for _, dst := range []geometryValue{new(Point), new(Line), new(Lseg), new(Box), new(Path), new(Polygon), new(Circle)} {
+		if err := dst.UnmarshalBinary([]byte{1, 2, 3}); err == nil {
+			t.Errorf("%T.UnmarshalBinary of 3 bytes should fail", dst)
+		}
+	}
+}
+
+func TestBoxArray(t *testing.T) {
+	// box 的文本格式含有逗号，box 数组以 ';' 分隔元素
+	var a = Array{Dims: []ArrayDimension{{2, 1}}, Elements: [][]byte{[]byte("(1,1),(0,0)"), []byte("(3,3),(2,2)")}}
+	var text = string(FormatArray(a, ';'))
+	if text != "{(1,1),(0,0);(3,3),(2,2)}" {
+		t.Fatalf("FormatArray = %s", text)
+	}
+	back, err := ParseArray([]byte(text), ';')
+	if err != nil || !reflect.DeepEqual(back, a) {
+		t.Fatalf("ParseArray(%s) = %+v, %v", text, back, err)
+	}
+	for i, e := range back.Elements {
+		if _, err := ParseBox(e); err != nil {
+			t.Errorf("element %d: %v", i, err)
+		}
+	}
+}
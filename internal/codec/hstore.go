@@ -0,0 +1,219 @@
+// Copyright 2026 YBCZ, Inc. All rights reserved.
+//
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file in the root of the source
+// tree.
+
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// ParseHstore 解析 hstore 的文本格式，如 "a"=>"1", "b"=>NULL，值为 nil 表示 NULL
+func ParseHstore(src []byte) (m map[string]*string, err error) {
+	var p = hstoreParser{src: src}
+	if m, err = p.parse(); err != nil {
+		return nil, fmt.Errorf("pg: invalid hstore value %q: %v", src, err)
+	}
+	return
+}
+
+type hstoreParser struct {
+	src []byte
+	pos int
+}
+
+func (p *hstoreParser) parse() (m map[string]*string, err error) {
+	m = make(map[string]*string)
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			return
+		}
+		var key, value []byte
+		var null bool
+		if key, null, err = p.parseItem(); err != nil {
+			return
+		} else if null {
+			return nil, fmt.Errorf("NULL key at %d", p.pos)
+		}
+		p.skipSpace()
+		if !bytes.HasPrefix(p.src[p.pos:], []byte("=>")) {
+			return nil, fmt.Errorf("expected '=>' at %d", p.pos)
+		}
+		p.pos += 2
+		p.skipSpace()
+		if value, null, err = p.parseItem(); err != nil {
+			return
+		}
+		if null {
+			m[string(key)] = nil
+		} else {
+			var v = string(value)
+			m[string(key)] = &v
+		}
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			return
+		}
+		if p.src[p.pos] != ',' {
+			return nil, fmt.Errorf("expected ',' at %d", p.pos)
+		}
+		p.pos++
+	}
+}
+
+// parseItem 解析键或值，不带引号的 NULL 表示 NULL
+func (p *hstoreParser) parseItem() (item []byte, null bool, err error) {
+	if p.pos < len(p.src) && p.src[p.pos] == '"' {
+		p.pos++
+		item = []byte{}
+		for {
+			if p.pos >= len(p.src) {
+				return nil, false, fmt.Errorf("unexpected end of input")
+			}
+			c := p.src[p.pos]
+			p.pos++
+			switch c {
+			case '\\':
+				if p.pos >= len(p.src) {
+					return nil, false, fmt.Errorf("unexpected end of input")
+				}
+				item = append(item, p.src[p.pos])
+				p.pos++
+			case '"':
+				return item, false, nil
+			default:
+				item = append(item, c)
+			}
+		}
+	}
+	var start = p.pos
+	var escaped bool
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == ',' || c == '=' || c == '"' || isArraySpace(c) {
+			break
+		}
+		if c == '\\' {
+			p.pos++
+			if p.pos >= len(p.src) {
+				return nil, false, fmt.Errorf("unexpected end of input")
+			}
+			c, escaped = p.src[p.pos], true
+		}
+		item = append(item, c)
+		p.pos++
+	}
+	if p.pos == start {
+		return nil, false, fmt.Errorf("unexpected %q at %d", p.peek(), p.pos)
+	}
+	if !escaped && bytes.EqualFold(item, []byte("NULL")) {
+		return nil, true, nil
+	}
+	return item, false, nil
+}
+
+func (p *hstoreParser) peek() byte {
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *hstoreParser) skipSpace() {
+	for p.pos < len(p.src) && isArraySpace(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+// FormatHstore 输出 hstore 的文本格式，键按字典序排列，键和值总是加引号
+func FormatHstore(m map[string]*string) []byte {
+	var b bytes.Buffer
+	for i, k := range sortedKeys(m) {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		writeHstoreItem(&b, k)
+		b.WriteString("=>")
+		if v := m[k]; v == nil {
+			b.WriteString("NULL")
+		} else {
+			writeHstoreItem(&b, *v)
+		}
+	}
+	return b.Bytes()
+}
+
+func writeHstoreItem(b *bytes.Buffer, s string) {
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		if s[i] == '"' || s[i] == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	b.WriteByte('"')
+}
+
+func sortedKeys(m map[string]*string) []string {
+	var keys = make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ParseHstoreBinary 解析 hstore 的二进制格式：4 字节的键值对个数，随后是各自带 4 字节长度的键和值，值的长度为 -1 时表示 NULL
+func ParseHstoreBinary(src []byte) (m map[string]*string, err error) {
+	if len(src) < 4 {
+		return nil, fmt.Errorf("pg: invalid binary hstore value: too short")
+	}
+	var count = int(int32(binary.BigEndian.Uint32(src)))
+	if count < 0 {
+		return nil, fmt.Errorf("pg: invalid binary hstore pair count %d", count)
+	}
+	src = src[4:]
+	m = make(map[string]*string, count)
+	for i := 0; i < count; i++ {
+		var key, value []byte
+		if key, src, err = readLengthPrefixed(src); err != nil {
+			return nil, err
+		}
+		if key == nil {
+			return nil, fmt.Errorf("pg: invalid binary hstore value: NULL key")
+		}
+		if value, src, err = readLengthPrefixed(src); err != nil {
+			return nil, err
+		}
+		if value == nil {
+			m[string(key)] = nil
+		} else {
+			var v = string(value)
+			m[string(key)] = &v
+		}
+	}
+	if len(src) != 0 {
+		return nil, fmt.Errorf("pg: invalid binary hstore value: %d extra bytes", len(src))
+	}
+	return
+}
+
+// FormatHstoreBinary 输出 hstore 的二进制格式
+func FormatHstoreBinary(m map[string]*string) []byte {
+	var b = binary.BigEndian.AppendUint32(nil, uint32(len(m)))
+	for _, k := range sortedKeys(m) {
+		b = appendLengthPrefixed(b, []byte(k))
+		if v := m[k]; v == nil {
+			b = appendLengthPrefixed(b, nil)
+		} else {
+			b = appendLengthPrefixed(b, []byte(*v))
+		}
+	}
+	return b
+}
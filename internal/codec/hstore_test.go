@@ -0,0 +1,64 @@
+package codec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func str(s string) *string {
+	return &s
+}
+
+func TestParseHstore(t *testing.T) {
+	var cases = []struct {
+		in   string
+		want map[string]*string
+	}{
+		{``, map[string]*string{}},
+		{`"a"=>"1"`, map[string]*string{"a": str("1")}},
+		{`a=>1, b => 2`, map[string]*string{"a": str("1"), "b": str("2")}},
+		{`"a"=>NULL, "b"=>"NULL", c=>null`, map[string]*string{"a": nil, "b": str("NULL"), "c": nil}},
+		{`"k\"q"=>"v\\s", "x y"=>""`, map[string]*string{`k"q`: str(`v\s`), "x y": str("")}},
+		{`"=>"=>",", "中文"=>"值"`, map[string]*string{"=>": str(","), "中文": str("值")}},
+	}
+	for _, c := range cases {
+		m, err := ParseHstore([]byte(c.in))
+		if err != nil {
+			t.Errorf("ParseHstore(%s): %v", c.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(m, c.want) {
+			t.Errorf("ParseHstore(%s) = %v, want %v", c.in, m, c.want)
+		}
+		back, err := ParseHstore(FormatHstore(m))
+		if err != nil || !reflect.DeepEqual(back, m) {
+			t.Errorf("text round trip of %s = %v, %v", c.in, back, err)
+		}
+		back, err = ParseHstoreBinary(FormatHstoreBinary(m))
+		if err != nil || !reflect.DeepEqual(back, m) {
+			t.Errorf("binary round trip of %s = %v, %v", c.in, back, err)
+		}
+	}
+	for _, in := range []string{`NULL=>"1"`, `"a"`, `"a"=>`, `"a"=>"1" "b"=>"2"`, `"a=>"1"`} {
+		if _, err := ParseHstore([]byte(in)); err == nil {
+			t.Errorf("ParseHstore(%s) should fail", in)
+		}
+	}
+}
+
+func TestFormatHstore(t *testing.T) {
+	var m = map[string]*string{"b": nil, "a": str(`say "hi"\`), "": str("")}
+	if out := string(FormatHstore(m)); out != `""=>"", "a"=>"say \"hi\"\\", "b"=>NULL` {
+		t.Errorf("FormatHstore = %s", out)
+	}
+	var raw = FormatHstoreBinary(map[string]*string{"a": nil, "b": str("1")})
+	var want = []byte{0, 0, 0, 2, 0, 0, 0, 1, 'a', 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 1, 'b', 0, 0, 0, 1, '1'}
+	if !reflect.DeepEqual(raw, want) {
+		t.Errorf("FormatHstoreBinary = %v", raw)
+	}
+	for _, bad := range [][]byte{{0, 0}, {0xff, 0xff, 0xff, 0xff}, {0, 0, 0, 1, 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}, {0, 0, 0, 1, 0, 0, 0, 1, 'a'}, append(want, 0)} {
+		if _, err := ParseHstoreBinary(bad); err == nil {
+			t.Errorf("ParseHstoreBinary(%v) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,340 @@
+// Copyright 2026 YBCZ, Inc. All rights reserved.
+//
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file in the root of the source
+// tree.
+
+package codec
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	usPerSecond = int64(1000000)
+	usPerMinute = 60 * usPerSecond
+	usPerHour   = 60 * usPerMinute
+)
+
+// ParseInterval 解析 interval 的文本格式
+// 支持 IntervalStyle 为 postgres、postgres_verbose、sql_standard、iso_8601 时的输出
+func ParseInterval(str string) (months, days int32, us int64, err error) {
+	var s = strings.TrimSpace(str)
+	if strings.HasPrefix(s, "P") {
+		months, days, us, err = parseISOInterval(s)
+	} else {
+		months, days, us, err = parsePostgresInterval(s)
+	}
+	if err != nil {
+		err = fmt.Errorf("pg: invalid interval value %q: %v", str, err)
+	}
+	return
+}
+
+func parsePostgresInterval(s string) (months, days int32, us int64, err error) {
+	var fields = strings.Fields(s)
+	var ago bool
+	for i := 0; i < len(fields); i++ {
+		var f = fields[i]
+		switch {
+		case f == "@":
+			continue
+		case f == "ago":
+			ago = true
+			continue
+		case strings.Contains(f, ":"):
+			var t int64
+			if t, err = parseClock(f); err != nil {
+				return
+			}
+			us += t
+			continue
+		case strings.LastIndexByte(f, '-') > 0:
+			// sql_standard 的年-月，如 1-2、-1-2
+			var y, m int64
+			p := strings.LastIndexByte(f, '-')
+			if y, err = strconv.ParseInt(f[:p], 10, 32); err != nil {
+				return
+			}
+			if m, err = strconv.ParseInt(f[p+1:], 10, 32); err != nil {
+				return
+			}
+			if strings.HasPrefix(f, "-") {
+				// 如 -0-3 表示负 3 个月，不能依据 y 的符号判断
+				m = -m
+			}
+			months += int32(y*12 + m)
+			continue
+		}
+
+		var unit string
+		if i+1 < len(fields) && isIntervalUnit(fields[i+1]) {
+			unit = fields[i+1]
+			i++
+		} else if p := strings.IndexFunc(f, func(r rune) bool { return r >= 'a' && r <= 'z' }); p > 0 {
+			f, unit = f[:p], f[p:]
+		} else {
+			// sql_standard 中不带单位的数值表示天数
+			unit = "day"
+		}
+		if err = addIntervalUnit(f, unit, &months, &days, &us); err != nil {
+			return
+		}
+	}
+	if ago {
+		months, days, us = -months, -days, -us
+	}
+	return
+}
+
+func isIntervalUnit(s string) bool {
+	_, ok := intervalUnit(s)
+	return ok
+}
+
+// intervalUnit 把单位统一为 year、month、week、day、hour、minute、second、millisecond、microsecond
+func intervalUnit(s string) (string, bool) {
+	switch strings.ToLower(strings.TrimSuffix(s, ",")) {
+	case "y", "yr", "yrs", "year", "years":
+		return "year", true
+	case "mon", "mons", "month", "months":
+		return "month", true
+	case "w", "week", "weeks":
+		return "week", true
+	case "d", "day", "days":
+		return "day", true
+	case "h", "hr", "hrs", "hour", "hours":
+		return "hour", true
+	case "m", "min", "mins", "minute", "minutes":
+		return "minute", true
+	case "s", "sec", "secs", "second", "seconds":
+		return "second", true
+	case "ms", "msec", "msecs", "millisecond", "milliseconds":
+		return "millisecond", true
+	case "us", "usec", "usecs", "microsecond", "microseconds":
+		return "microsecond", true
+	case "decade", "decades":
+		return "decade", true
+	case "century", "centuries":
+		return "century", true
+	case "millennium", "millennia", "millenniums":
+		return "millennium", true
+	}
+	return "", false
+}
+
+func addIntervalUnit(num, unitStr string, months, days *int32, us *int64) error {
+	unit, ok := intervalUnit(unitStr)
+	if !ok {
+		return fmt.Errorf("unknown unit %q", unitStr)
+	}
+	switch unit {
+	case "second":
+		v, err := parseSeconds(num)
+		*us += v
+		return err
+	case "millisecond", "microsecond":
+		v, err := strconv.ParseFloat(num, 64)
+		if err != nil {
+			return err
+		}
+		if unit == "millisecond" {
+			v *= 1000
+		}
+		*us += int64(math.Round(v))
+		return nil
+	}
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil {
+		return err
+	}
+	switch unit {
+	case "millennium":
+		*months += int32(n * 12000)
+	case "century":
+		*months += int32(n * 1200)
+	case "decade":
+		*months += int32(n * 120)
+	case "year":
+		*months += int32(n * 12)
+	case "month":
+		*months += int32(n)
+	case "week":
+		*days += int32(n * 7)
+	case "day":
+		*days += int32(n)
+	case "hour":
+		*us += n * usPerHour
+	case "minute":
+		*us += n * usPerMinute
+	}
+	return nil
+}
+
+// parseClock 解析 [+-]HH:MM[:SS[.ffffff]]
+func parseClock(s string) (us int64, err error) {
+	var neg bool
+	if strings.HasPrefix(s, "-") {
+		neg, s = true, s[1:]
+	} else {
+		s = strings.TrimPrefix(s, "+")
+	}
+	var parts = strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	h, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return
+	}
+	m, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return
+	}
+	us = h*usPerHour + m*usPerMinute
+	if len(parts) == 3 {
+		var sec int64
+		if sec, err = parseSeconds(parts[2]); err != nil {
+			return
+		}
+		us += sec
+	}
+	if neg {
+		us = -us
+	}
+	return
+}
+
+// parseSeconds 精确解析带小数的秒数，返回微秒，超过 6 位的小数四舍五入
+func parseSeconds(s string) (us int64, err error) {
+	var neg bool
+	if strings.HasPrefix(s, "-") {
+		neg, s = true, s[1:]
+	} else {
+		s = strings.TrimPrefix(s, "+")
+	}
+	var intPart, fracPart = s, ""
+	if p := strings.IndexByte(s, '.'); p >= 0 {
+		intPart, fracPart = s[:p], s[p+1:]
+	}
+	if intPart == "" && fracPart == "" {
+		return 0, fmt.Errorf("invalid seconds %q", s)
+	}
+	var sec, frac int64
+	if intPart != "" {
+		if sec, err = strconv.ParseInt(intPart, 10, 64); err != nil {
+			return
+		}
+	}
+	if fracPart != "" {
+		var round bool
+		if len(fracPart) > 6 {
+			round = fracPart[6] >= '5'
+			fracPart = fracPart[:6]
+		}
+		fracPart += strings.Repeat("0", 6-len(fracPart))
+		if frac, err = strconv.ParseInt(fracPart, 10, 64); err != nil {
+			return
+		}
+		if round {
+			frac++
+		}
+	}
+	us = sec*usPerSecond + frac
+	if neg {
+		us = -us
+	}
+	return
+}
+
+// parseISOInterval 解析 ISO 8601 的带标识符格式，如 P1Y2M3DT4H5M6.5S、P-1Y-2M3DT-4H
+func parseISOInterval(s string) (months, days int32, us int64, err error) {
+	var inTime bool
+	var num strings.Builder
+	for _, r := range s[1:] {
+		switch {
+		case r == 'T':
+			inTime = true
+		case r == '-' || r == '+' || r == '.' || r >= '0' && r <= '9':
+			num.WriteRune(r)
+		default:
+			var unit string
+			switch {
+			case r == 'Y':
+				unit = "year"
+			case r == 'M' && !inTime:
+				unit = "month"
+			case r == 'W':
+				unit = "week"
+			case r == 'D':
+				unit = "day"
+			case r == 'H':
+				unit = "hour"
+			case r == 'M':
+				unit = "minute"
+			case r == 'S':
+				unit = "second"
+			default:
+				return 0, 0, 0, fmt.Errorf("unexpected %q", r)
+			}
+			if num.Len() == 0 {
+				return 0, 0, 0, fmt.Errorf("missing number before %q", r)
+			}
+			if err = addIntervalUnit(num.String(), unit, &months, &days, &us); err != nil {
+				return
+			}
+			num.Reset()
+		}
+	}
+	if num.Len() > 0 {
+		err = fmt.Errorf("missing unit after %q", num.String())
+	}
+	return
+}
+
+// FormatInterval 按 ISO 8601 的带标识符格式输出，与 IntervalStyle=iso_8601 时服务器的输出一致
+func FormatInterval(months, days int32, us int64) string {
+	if months == 0 && days == 0 && us == 0 {
+		return "PT0S"
+	}
+	var b strings.Builder
+	b.WriteByte('P')
+	if y := months / 12; y != 0 {
+		b.WriteString(strconv.FormatInt(int64(y), 10) + "Y")
+	}
+	if m := months % 12; m != 0 {
+		b.WriteString(strconv.FormatInt(int64(m), 10) + "M")
+	}
+	if days != 0 {
+		b.WriteString(strconv.FormatInt(int64(days), 10) + "D")
+	}
+	if us != 0 {
+		b.WriteByte('T')
+		if h := us / usPerHour; h != 0 {
+			b.WriteString(strconv.FormatInt(h, 10) + "H")
+		}
+		if m := us % usPerHour / usPerMinute; m != 0 {
+			b.WriteString(strconv.FormatInt(m, 10) + "M")
+		}
+		if s := us % usPerMinute; s != 0 {
+			b.WriteString(formatSeconds(s) + "S")
+		}
+	}
+	return b.String()
+}
+
+// formatSeconds 把微秒输出为带小数的秒数，去掉多余的 0
+func formatSeconds(us int64) string {
+	var sign string
+	if us < 0 {
+		sign, us = "-", -us
+	}
+	var s = sign + strconv.FormatInt(us/usPerSecond, 10)
+	if frac := us % usPerSecond; frac != 0 {
+		s += strings.TrimRight(fmt.Sprintf(".%06d", frac), "0")
+	}
+	return s
+}
@@ -0,0 +1,92 @@
+package codec
+
+import (
+	"testing"
+)
+
+func TestParseInterval(t *testing.T) {
+	const h, m, s = usPerHour, usPerMinute, usPerSecond
+	var cases = []struct {
+		in     string
+		months int32
+		days   int32
+		us     int64
+	}{
+		// IntervalStyle = postgres
+		{"00:00:00", 0, 0, 0},
+		{"1 year 2 mons 3 days 04:05:06.5", 14, 3, 4*h + 5*m + 6*s + 500000},
+		{"-1 days +02:03:00", 0, -1, 2*h + 3*m},
+		{"-00:00:00.000001", 0, 0, -1},
+		{"1 mon -00:01:00", 1, 0, -m},
+		// IntervalStyle = postgres_verbose
+		{"@ 0", 0, 0, 0},
+		{"@ 1 year 2 mons 3 days 4 hours 5 mins 6.5 secs", 14, 3, 4*h + 5*m + 6*s + 500000},
+		{"@ 1 day 2 hours ago", 0, -1, -2 * h},
+		{"@ 1 min 30.25 secs ago", 0, 0, -(m + 30*s + 250000)},
+		{"@ 1 day -2 hours", 0, 1, -2 * h},
+		// IntervalStyle = sql_standard
+		{"0", 0, 0, 0},
+		{"1-2", 14, 0, 0},
+		{"-1-2", -14, 0, 0},
+		{"-0-3", -3, 0, 0},
+		{"3 4:05:06.5", 0, 3, 4*h + 5*m + 6*s + 500000},
+		{"-1-2 -3 -4:05:06", -14, -3, -(4*h + 5*m + 6*s)},
+		{"+1-2 -3 +4:05:06", 14, -3, 4*h + 5*m + 6*s},
+		{"1:02", 0, 0, h + 2*m},
+		// IntervalStyle = iso_8601
+		{"PT0S", 0, 0, 0},
+		{"P1Y2M3DT4H5M6.5S", 14, 3, 4*h + 5*m + 6*s + 500000},
+		{"P-1Y-2M3DT-4H-5M-6S", -14, 3, -(4*h + 5*m + 6*s)},
+		{"PT-0.000001S", 0, 0, -1},
+		{"P2W", 0, 14, 0},
+		{"PT0.0000005S", 0, 0, 1},
+		// 其他输入
+		{"1 decade 1 century", 1320, 0, 0},
+		{"1.5 secs", 0, 0, 1500000},
+		{"10 ms 5 us", 0, 0, 10005},
+	}
+	for _, c := range cases {
+		months, days, us, err := ParseInterval(c.in)
+		if err != nil {
+			t.Errorf("ParseInterval(%q): %v", c.in, err)
+			continue
+		}
+		if months != c.months || days != c.days || us != c.us {
+			t.Errorf("ParseInterval(%q) = %d, %d, %d, want %d, %d, %d", c.in, months, days, us, c.months, c.days, c.us)
+		}
+	}
+
+	for _, in := range []string{"P1X", "P1", "1 fortnight", "1:2:3:4", "abc secs"} {
+		if _, _, _, err := ParseInterval(in); err == nil {
+			t.Errorf("ParseInterval(%q) should fail", in)
+		}
+	}
+}
+
+func TestFormatInterval(t *testing.T) {
+	var cases = []struct {
+		months int32
+		days   int32
+		us     int64
+		out    string
+	}{
+		{0, 0, 0, "PT0S"},
+		{14, 3, 4*usPerHour + 5*usPerMinute + 6*usPerSecond + 500000, "P1Y2M3DT4H5M6.5S"},
+		{-14, 3, -(4*usPerHour + 5*usPerMinute + 6*usPerSecond), "P-1Y-2M3DT-4H-5M-6S"},
+		{0, 0, -1, "PT-0.000001S"},
+		{12, 0, 0, "P1Y"},
+		{0, 0, usPerHour, "PT1H"},
+		{0, -7, 0, "P-7D"},
+	}
+	for _, c := range cases {
+		var out = FormatInterval(c.months, c.days, c.us)
+		if out != c.out {
+			t.Errorf("FormatInterval(%d, %d, %d) = %q, want %q", c.months, c.days, c.us, out, c.out)
+			continue
+		}
+		months, days, us, err := ParseInterval(out)
+		if err != nil || months != c.months || days != c.days || us != c.us {
+			t.Errorf("ParseInterval(%q) = %d, %d, %d, %v, want %d, %d, %d", out, months, days, us, err, c.months, c.days, c.us)
+		}
+	}
+}
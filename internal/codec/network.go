@@ -0,0 +1,95 @@
+// Copyright 2026 YBCZ, Inc. All rights reserved.
+//
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file in the root of the source
+// tree.
+
+package codec
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// inet、cidr 二进制格式中的地址族，与服务器的 PGSQL_AF_INET、PGSQL_AF_INET6 一致
+const (
+	pgAFInet  = 2
+	pgAFInet6 = 3
+)
+
+// ParseInet 解析 inet、cidr 的文本格式，如 192.168.1.5/24、::1
+// inet 省略网络掩码时表示单个地址，返回的前缀长度为地址的位数；主机位会被保留
+func ParseInet(src []byte) (p netip.Prefix, err error) {
+	var s = string(src)
+	if strings.IndexByte(s, '/') < 0 {
+		var addr netip.Addr
+		if addr, err = netip.ParseAddr(s); err != nil {
+			return p, fmt.Errorf("pg: invalid inet value %q", s)
+		}
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	if p, err = netip.ParsePrefix(s); err != nil {
+		return p, fmt.Errorf("pg: invalid inet value %q", s)
+	}
+	return
+}
+
+// FormatInet 输出 inet 的文本格式，前缀长度等于地址位数时省略
+func FormatInet(p netip.Prefix) string {
+	if p.Bits() == p.Addr().BitLen() {
+		return p.Addr().String()
+	}
+	return p.String()
+}
+
+// ParseInetBinary 解析 inet、cidr 的二进制格式：地址族、前缀长度、是否为 cidr、地址长度各 1 字节，随后是地址
+func ParseInetBinary(src []byte) (p netip.Prefix, err error) {
+	if len(src) < 4 || len(src) != 4+int(src[3]) {
+		return p, fmt.Errorf("pg: invalid binary inet length %d", len(src))
+	}
+	var addr netip.Addr
+	switch {
+	case src[0] == pgAFInet && src[3] == 4:
+		addr = netip.AddrFrom4([4]byte(src[4:8]))
+	case src[0] == pgAFInet6 && src[3] == 16:
+		addr = netip.AddrFrom16([16]byte(src[4:20]))
+	default:
+		return p, fmt.Errorf("pg: invalid binary inet address family %d", src[0])
+	}
+	if int(src[1]) > addr.BitLen() {
+		return p, fmt.Errorf("pg: invalid binary inet prefix length %d", src[1])
+	}
+	return netip.PrefixFrom(addr, int(src[1])), nil
+}
+
+// FormatInetBinary 输出 inet（cidr 为 false）或 cidr 的二进制格式
+func FormatInetBinary(p netip.Prefix, cidr bool) []byte {
+	var addr = p.Addr()
+	var b = []byte{pgAFInet, byte(p.Bits()), 0, 4}
+	if addr.Is6() {
+		b[0], b[3] = pgAFInet6, 16
+	}
+	if cidr {
+		b[2] = 1
+	}
+	return append(b, addr.AsSlice()...)
+}
+
+// ParseMacaddr 解析 macaddr、macaddr8 的文本格式
+func ParseMacaddr(src []byte) (net.HardwareAddr, error) {
+	hw, err := net.ParseMAC(string(src))
+	if err != nil || (len(hw) != 6 && len(hw) != 8) {
+		return nil, fmt.Errorf("pg: invalid macaddr value %q", src)
+	}
+	return hw, nil
+}
+
+// ParseMacaddrBinary 解析 macaddr（6 字节）、macaddr8（8 字节）的二进制格式
+func ParseMacaddrBinary(src []byte) (net.HardwareAddr, error) {
+	if len(src) != 6 && len(src) != 8 {
+		return nil, fmt.Errorf("pg: invalid binary macaddr length %d", len(src))
+	}
+	return append(net.HardwareAddr{}, src...), nil
+}
@@ -0,0 +1,89 @@
+package codec
+
+import (
+	"bytes"
+	"net/netip"
+	"testing"
+)
+
+func TestParseInet(t *testing.T) {
+	var cases = []struct {
+		in   string
+		want string // netip.Prefix 的字符串形式
+		out  string // FormatInet 的输出
+	}{
+		{"192.168.1.5", "192.168.1.5/32", "192.168.1.5"},
+		{"192.168.1.5/32", "192.168.1.5/32", "192.168.1.5"},
+		{"192.168.1.5/24", "192.168.1.5/24", "192.168.1.5/24"},
+		{"10.0.0.0/8", "10.0.0.0/8", "10.0.0.0/8"},
+		{"0.0.0.0/0", "0.0.0.0/0", "0.0.0.0/0"},
+		{"::1", "::1/128", "::1"},
+		{"::1/128", "::1/128", "::1"},
+		{"2001:db8::1/64", "2001:db8::1/64", "2001:db8::1/64"},
+		{"::ffff:1.2.3.4", "::ffff:1.2.3.4/128", "::ffff:1.2.3.4"},
+	}
+	for _, c := range cases {
+		p, err := ParseInet([]byte(c.in))
+		if err != nil {
+			t.Errorf("ParseInet(%s): %v", c.in, err)
+			continue
+		}
+		if p.String() != c.want {
+			t.Errorf("ParseInet(%s) = %s, want %s", c.in, p, c.want)
+		}
+		if out := FormatInet(p); out != c.out {
+			t.Errorf("FormatInet(%s) = %s, want %s", p, out, c.out)
+		}
+		for _, cidr := range []bool{false, true} {
+			raw := FormatInetBinary(p, cidr)
+			if (raw[2] == 1) != cidr {
+				t.Errorf("FormatInetBinary(%s, %v) has cidr flag %d", p, cidr, raw[2])
+			}
+			back, err := ParseInetBinary(raw)
+			if err != nil || back != p {
+				t.Errorf("binary round trip of %s = %s, %v", p, back, err)
+			}
+		}
+	}
+	for _, in := range []string{"", "1.2.3", "1.2.3.4/33", "::1/129", "host", "1.2.3.4/x"} {
+		if _, err := ParseInet([]byte(in)); err == nil {
+			t.Errorf("ParseInet(%s) should fail", in)
+		}
+	}
+}
+
+func TestInetBinaryLayout(t *testing.T) {
+	var v4 = FormatInetBinary(netip.MustParsePrefix("192.168.1.0/24"), true)
+	if !bytes.Equal(v4, []byte{pgAFInet, 24, 1, 4, 192, 168, 1, 0}) {
+		t.Errorf("cidr 192.168.1.0/24 = %v", v4)
+	}
+	var v6 = FormatInetBinary(netip.MustParsePrefix("::1/128"), false)
+	if len(v6) != 20 || v6[0] != pgAFInet6 || v6[1] != 128 || v6[3] != 16 || v6[19] != 1 {
+		t.Errorf("inet ::1 = %v", v6)
+	}
+	for _, bad := range [][]byte{nil, {pgAFInet, 24, 0, 4, 1, 2, 3}, {pgAFInet, 33, 0, 4, 1, 2, 3, 4}, {9, 8, 0, 4, 1, 2, 3, 4}, {pgAFInet6, 64, 0, 4, 1, 2, 3, 4}} {
+		if _, err := ParseInetBinary(bad); err == nil {
+			t.Errorf("ParseInetBinary(%v) should fail", bad)
+		}
+	}
+}
+
+func TestParseMacaddr(t *testing.T) {
+	for _, in := range []string{"08:00:2b:01:02:03", "08-00-2b-01-02-03", "08:00:2b:01:02:03:04:05"} {
+		hw, err := ParseMacaddr([]byte(in))
+		if err != nil {
+			t.Errorf("ParseMacaddr(%s): %v", in, err)
+			continue
+		}
+		back, err := ParseMacaddrBinary(hw)
+		if err != nil || back.String() != hw.String() {
+			t.Errorf("ParseMacaddrBinary(%x) = %s, %v", []byte(hw), back, err)
+		}
+	}
+	if _, err := ParseMacaddr([]byte("08:00:2b")); err == nil {
+		t.Error("ParseMacaddr of 3 bytes should fail")
+	}
+	if _, err := ParseMacaddrBinary([]byte{1, 2, 3}); err == nil {
+		t.Error("ParseMacaddrBinary of 3 bytes should fail")
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2026 YBCZ, Inc. All rights reserved.
+//
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file in the root of the source
+// tree.
+
+// Package codec 实现 PostgreSQL 各数据类型文本格式与二进制格式的编解码
+package codec
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var bigTen = big.NewInt(10)
+
+// 与 PostgreSQL 的 numeric 保持一致的范围限制，避免如 "1e2000000000" 的输入耗尽内存
+const (
+	numericMaxExponent  = 1000   // 输入时科学计数法的指数范围
+	numericMaxIntDigits = 131072 // 小数点前最多的位数
+	numericMaxScale     = 16383  // 小数点后最多的位数
+)
+
+// ParseDecimal 解析十进制数，返回去掉小数点后的整数及小数位数，value = i / 10^scale
+// 除 PostgreSQL 的输出格式外，也接受科学计数法，如 "1.5e3"；超出 numeric 范围时返回错误
+func ParseDecimal(str string) (i *big.Int, scale int32, err error) {
+	var s = strings.TrimSpace(str)
+	var exp int64
+	if p := strings.IndexAny(s, "eE"); p >= 0 {
+		if exp, err = strconv.ParseInt(s[p+1:], 10, 32); err != nil {
+			return nil, 0, fmt.Errorf("pg: invalid numeric value %q", str)
+		}
+		if exp > numericMaxExponent || exp < -numericMaxExponent {
+			return nil, 0, fmt.Errorf("pg: numeric exponent of %q is out of range", str)
+		}
+		s = s[:p]
+	}
+	var intPart, fracPart = s, ""
+	if p := strings.IndexByte(s, '.'); p >= 0 {
+		intPart, fracPart = s[:p], s[p+1:]
+	}
+	var digits = intPart + fracPart
+	if digits == "" || digits == "+" || digits == "-" || strings.ContainsAny(digits[1:], "+-") {
+		return nil, 0, fmt.Errorf("pg: invalid numeric value %q", str)
+	}
+	var intDigits = int64(len(strings.TrimLeft(intPart, "+-0"))) + exp
+	var sc = int64(len(fracPart)) - exp
+	if intDigits > numericMaxIntDigits || sc > numericMaxScale {
+		return nil, 0, fmt.Errorf("pg: numeric value %q is out of range", str)
+	}
+	i, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return nil, 0, fmt.Errorf("pg: invalid numeric value %q", str)
+	}
+	if sc < 0 {
+		i.Mul(i, new(big.Int).Exp(bigTen, big.NewInt(-sc), nil))
+		sc = 0
+	}
+	return i, int32(sc), nil
+}
+
+// FormatDecimal 是 ParseDecimal 的逆操作，输出与 PostgreSQL 相同的定点格式
+func FormatDecimal(i *big.Int, scale int32) string {
+	if i == nil {
+		i = new(big.Int)
+	}
+	var digits = new(big.Int).Abs(i).String()
+	if scale > 0 {
+		if pad := int(scale) + 1 - len(digits); pad > 0 {
+			digits = strings.Repeat("0", pad) + digits
+		}
+		digits = digits[:len(digits)-int(scale)] + "." + digits[len(digits)-int(scale):]
+	} else if scale < 0 {
+		digits += strings.Repeat("0", int(-scale))
+	}
+	if i.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// RatToDecimal 把有理数转为十进制定点数，无法用有限位小数表示时返回错误
+func RatToDecimal(r *big.Rat) (i *big.Int, scale int32, err error) {
+	var d = new(big.Int).Set(r.Denom())
+	var twos = int32(d.TrailingZeroBits())
+	d.Rsh(d, uint(twos))
+	var fives int32
+	var q, m, five = new(big.Int), new(big.Int), big.NewInt(5)
+	for fives <= numericMaxScale {
+		if q.QuoRem(d, five, m); m.Sign() != 0 {
+			break
+		}
+		d.Set(q)
+		fives++
+	}
+	if twos > numericMaxScale || fives > numericMaxScale {
+		return nil, 0, fmt.Errorf("pg: %s needs more than %d decimal places", r.String(), numericMaxScale)
+	}
+	if d.Cmp(big.NewInt(1)) != 0 {
+		return nil, 0, fmt.Errorf("pg: %s has no finite decimal representation", r.String())
+	}
+	scale = twos
+	if fives > scale {
+		scale = fives
+	}
+	i = new(big.Int).Exp(bigTen, big.NewInt(int64(scale)), nil)
+	i.Mul(i, r.Num())
+	i.Quo(i, r.Denom())
+	return
+}
+
+// NumericTypmod 从 RowDescription 中的类型修饰符解析 numeric 的精度和小数位数
+// typmod = ((precision << 16) | (scale & 0x7ff)) + 4，PostgreSQL 15 起 scale 可以为负数
+func NumericTypmod(typmod int32) (precision, scale int64, ok bool) {
+	if typmod < 4 {
+		return 0, 0, false
+	}
+	typmod -= 4
+	precision = int64((typmod >> 16) & 0xffff)
+	scale = int64(((typmod & 0x7ff) ^ 1024) - 1024)
+	return precision, scale, true
+}
@@ -0,0 +1,167 @@
+package codec
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	var cases = []struct {
+		in    string
+		i     string
+		scale int32
+		err   string
+	}{
+		{in: "0", i: "0", scale: 0},
+		{in: "12.340", i: "12340", scale: 3},
+		{in: "-0.05", i: "-5", scale: 2},
+		{in: "+7", i: "7", scale: 0},
+		{in: ".5", i: "5", scale: 1},
+		{in: " 3.14 ", i: "314", scale: 2},
+		{in: "1.5e3", i: "1500", scale: 0},
+		{in: "15E-3", i: "15", scale: 3},
+		{in: "-2.5e-2", i: "-25", scale: 3},
+		{in: "1e1000", i: "1" + strings.Repeat("0", 1000), scale: 0},
+		{in: "1e2000000000", err: "exponent"},
+		{in: "1e-1001", err: "exponent"},
+		{in: "1e99999999999", err: "invalid"},
+		{in: "0." + strings.Repeat("1", 16384), err: "out of range"},
+		{in: "1" + strings.Repeat("0", 131072), err: "out of range"},
+		{in: "", err: "invalid"},
+		{in: "-", err: "invalid"},
+		{in: "1-2", err: "invalid"},
+		{in: "abc", err: "invalid"},
+		{in: "1e", err: "invalid"},
+	}
+	for _, c := range cases {
+		i, scale, err := ParseDecimal(c.in)
+		if c.err != "" {
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("ParseDecimal(%.20q) = %v, want an error containing %q", c.in, err, c.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseDecimal(%.20q): %v", c.in, err)
+			continue
+		}
+		if i.String() != c.i || scale != c.scale {
+			t.Errorf("ParseDecimal(%.20q) = %.20s, %d, want %.20s, %d", c.in, i, scale, c.i, c.scale)
+		}
+	}
+}
+
+func TestFormatDecimal(t *testing.T) {
+	var cases = []struct {
+		i     int64
+		scale int32
+		out   string
+	}{
+		{0, 0, "0"},
+		{0, 2, "0.00"},
+		{12340, 3, "12.340"},
+		{-5, 2, "-0.05"},
+		{5, 1, "0.5"},
+		{-12, 0, "-12"},
+		{12, -3, "12000"},
+		{-12, -2, "-1200"},
+	}
+	for _, c := range cases {
+		if out := FormatDecimal(big.NewInt(c.i), c.scale); out != c.out {
+			t.Errorf("FormatDecimal(%d, %d) = %q, want %q", c.i, c.scale, out, c.out)
+		}
+	}
+	if out := FormatDecimal(nil, 1); out != "0.0" {
+		t.Errorf("FormatDecimal(nil, 1) = %q, want 0.0", out)
+	}
+}
+
+func TestRatToDecimal(t *testing.T) {
+	var cases = []struct {
+		in  *big.Rat
+		out string
+		err string
+	}{
+		{in: big.NewRat(1, 8), out: "0.125"},
+		{in: big.NewRat(-3, 4), out: "-0.75"},
+		{in: big.NewRat(7, 1), out: "7"},
+		{in: big.NewRat(1, 50), out: "0.02"},
+		{in: big.NewRat(1, 3), err: "no finite decimal representation"},
+		{in: big.NewRat(1, 6), err: "no finite decimal representation"},
+		{in: new(big.Rat).SetFrac(big.NewInt(1), new(big.Int).Lsh(big.NewInt(1), 20000)), err: "decimal places"},
+		{in: new(big.Rat).SetFrac(big.NewInt(1), new(big.Int).Exp(big.NewInt(5), big.NewInt(20000), nil)), err: "decimal places"},
+	}
+	for _, c := range cases {
+		i, scale, err := RatToDecimal(c.in)
+		if c.err != "" {
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("RatToDecimal(%.20s) = %v, want an error containing %q", c.in, err, c.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("RatToDecimal(%s): %v", c.in, err)
+			continue
+		}
+		if out := FormatDecimal(i, scale); out != c.out {
+			t.Errorf("RatToDecimal(%s) = %q, want %q", c.in, out, c.out)
+		}
+	}
+}
+
+func TestNumericTypmod(t *testing.T) {
+	var typmod = func(precision, scale int32) int32 {
+		return (precision<<16 | scale&0x7ff) + 4
+	}
+	var cases = []struct {
+		typmod    int32
+		precision int64
+		scale     int64
+		ok        bool
+	}{
+		{-1, 0, 0, false},
+		{3, 0, 0, false},
+		{typmod(10, 2), 10, 2, true},
+		{typmod(5, 0), 5, 0, true},
+		{typmod(5, -2), 5, -2, true},
+		{typmod(3, 5), 3, 5, true},
+		{typmod(1000, 1000), 1000, 1000, true},
+		{typmod(1000, -1000), 1000, -1000, true},
+	}
+	for _, c := range cases {
+		precision, scale, ok := NumericTypmod(c.typmod)
+		if precision != c.precision || scale != c.scale || ok != c.ok {
+			t.Errorf("NumericTypmod(%d) = %d, %d, %v, want %d, %d, %v", c.typmod, precision, scale, ok, c.precision, c.scale, c.ok)
+		}
+	}
+}
+
+func TestNumericBinary(t *testing.T) {
+	for _, s := range []string{"0", "1", "-1", "12.340", "-0.05", "10000", "123456789.000000001", "0.0001", "99990000"} {
+		i, scale, err := ParseDecimal(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := ParseNumericBinary(FormatNumericBinary(i, scale))
+		if err != nil {
+			t.Errorf("%s: %v", s, err)
+		} else if out != s {
+			t.Errorf("binary round trip of %s = %s", s, out)
+		}
+	}
+	if out, err := ParseNumericBinary(FormatNumericBinary(big.NewInt(12), -3)); err != nil || out != "12000" {
+		t.Errorf("binary 12e3 = %q, %v", out, err)
+	}
+
+	for inf, want := range map[int8]string{0: "NaN", 1: "Infinity", -1: "-Infinity"} {
+		if out, err := ParseNumericBinary(FormatNumericSpecialBinary(inf)); err != nil || out != want {
+			t.Errorf("FormatNumericSpecialBinary(%d) = %q, %v, want %s", inf, out, err, want)
+		}
+	}
+	for _, src := range [][]byte{nil, {0, 1, 0, 0, 0, 0, 0, 0}, {0, 0, 0, 0, 0x12, 0x34, 0, 0}} {
+		if _, err := ParseNumericBinary(src); err == nil {
+			t.Errorf("ParseNumericBinary(%x) should fail", src)
+		}
+	}
+}
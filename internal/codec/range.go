@@ -0,0 +1,378 @@
+// Copyright 2026 YBCZ, Inc. All rights reserved.
+//
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file in the root of the source
+// tree.
+
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// 范围类型二进制格式中的标志位，与 PostgreSQL 的 rangetypes.h 一致
+const (
+	rangeEmpty = 0x01
+	rangeLBInc = 0x02
+	rangeUBInc = 0x04
+	rangeLBInf = 0x08
+	rangeUBInf = 0x10
+)
+
+// Range 范围类型的一般形式，上下界为元素的文本或二进制格式，nil 表示无界
+type Range struct {
+	Lower, Upper   []byte
+	LowerInclusive bool
+	UpperInclusive bool
+	Empty          bool
+}
+
+// RangeValue 查询结果中范围类型的值，上下界已按范围的子类型解码为 Go 的值，nil 表示无界
+// 驱动以此把范围列交给 pg.Range 的 Scan，date、timestamp 等边界的时区因此与普通列一致
+type RangeValue struct {
+	Lower, Upper   interface{}
+	LowerInclusive bool
+	UpperInclusive bool
+	Empty          bool
+}
+
+// ParseRange 解析范围的文本格式，如 [1,10)、(,5]、["2020-01-01 00:00:00+08",infinity)、empty
+func ParseRange(src []byte) (r Range, err error) {
+	var p = rangeParser{src: src}
+	if r, err = p.parse(); err == nil && p.pos != len(src) {
+		err = fmt.Errorf("junk after right parenthesis or bracket at %d", p.pos)
+	}
+	if err != nil {
+		return r, fmt.Errorf("pg: invalid range value %q: %v", src, err)
+	}
+	return
+}
+
+type rangeParser struct {
+	src []byte
+	pos int
+}
+
+func (p *rangeParser) parse() (r Range, err error) {
+	p.skipSpace()
+	if len(p.src)-p.pos >= 5 && bytes.EqualFold(p.src[p.pos:p.pos+5], []byte("empty")) {
+		p.pos += 5
+		p.skipSpace()
+		return Range{Empty: true}, nil
+	}
+	switch p.peek() {
+	case '[':
+		r.LowerInclusive = true
+	case '(':
+	default:
+		return r, fmt.Errorf("missing left parenthesis or bracket")
+	}
+	p.pos++
+	if r.Lower, err = p.parseBound(); err != nil {
+		return
+	}
+	if p.peek() != ',' {
+		return r, fmt.Errorf("missing comma after lower bound")
+	}
+	p.pos++
+	if r.Upper, err = p.parseBound(); err != nil {
+		return
+	}
+	switch p.peek() {
+	case ']':
+		r.UpperInclusive = true
+	case ')':
+	default:
+		return r, fmt.Errorf("missing right parenthesis or bracket")
+	}
+	p.pos++
+	p.skipSpace()
+	// 无界的一端总是开区间
+	r.LowerInclusive = r.LowerInclusive && r.Lower != nil
+	r.UpperInclusive = r.UpperInclusive && r.Upper != nil
+	return
+}
+
+// parseBound 解析一个边界，空白属于边界值本身；什么都没有时表示无界，返回 nil
+func (p *rangeParser) parseBound() (bound []byte, err error) {
+	var quoted bool
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '"':
+			quoted = true
+			p.pos++
+			for {
+				if p.pos >= len(p.src) {
+					return nil, fmt.Errorf("unexpected end of input")
+				}
+				c = p.src[p.pos]
+				p.pos++
+				if c == '\\' {
+					if p.pos >= len(p.src) {
+						return nil, fmt.Errorf("unexpected end of input")
+					}
+					bound = append(bound, p.src[p.pos])
+					p.pos++
+				} else if c == '"' {
+					// 引号内连续两个双引号表示一个双引号
+					if p.peek() != '"' {
+						break
+					}
+					bound = append(bound, '"')
+					p.pos++
+				} else {
+					bound = append(bound, c)
+				}
+			}
+		case c == '\\':
+			p.pos++
+			if p.pos >= len(p.src) {
+				return nil, fmt.Errorf("unexpected end of input")
+			}
+			bound = append(bound, p.src[p.pos])
+			p.pos++
+		case c == ',' || c == ')' || c == ']':
+			if bound == nil && quoted {
+				bound = []byte{}
+			}
+			return bound, nil
+		case c == '(' || c == '[':
+			return nil, fmt.Errorf("unexpected %q at %d", c, p.pos)
+		default:
+			bound = append(bound, c)
+			p.pos++
+		}
+	}
+	return nil, fmt.Errorf("unexpected end of input")
+}
+
+func (p *rangeParser) peek() byte {
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *rangeParser) skipSpace() {
+	for p.pos < len(p.src) && isArraySpace(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+// FormatRange 输出范围的文本格式，必要时为边界加引号
+func FormatRange(r Range) []byte {
+	if r.Empty {
+		return []byte("empty")
+	}
+	var b bytes.Buffer
+	if r.LowerInclusive && r.Lower != nil {
+		b.WriteByte('[')
+	} else {
+		b.WriteByte('(')
+	}
+	writeRangeBound(&b, r.Lower)
+	b.WriteByte(',')
+	writeRangeBound(&b, r.Upper)
+	if r.UpperInclusive && r.Upper != nil {
+		b.WriteByte(']')
+	} else {
+		b.WriteByte(')')
+	}
+	return b.Bytes()
+}
+
+func writeRangeBound(b *bytes.Buffer, bound []byte) {
+	if bound == nil {
+		return
+	}
+	var quote = len(bound) == 0
+	for _, c := range bound {
+		if c == '"' || c == '\\' || c == '(' || c == ')' || c == '[' || c == ']' || c == ',' || isArraySpace(c) {
+			quote = true
+			break
+		}
+	}
+	if !quote {
+		b.Write(bound)
+		return
+	}
+	b.WriteByte('"')
+	for _, c := range bound {
+		if c == '"' || c == '\\' {
+			b.WriteByte(c)
+		}
+		b.WriteByte(c)
+	}
+	b.WriteByte('"')
+}
+
+// ParseMultirange 解析多范围的文本格式，如 {[1,3),[5,7)}
+func ParseMultirange(src []byte) (ranges []Range, err error) {
+	var p = rangeParser{src: src}
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("pg: invalid multirange value %q: %v", src, err)
+		}
+	}()
+	p.skipSpace()
+	if p.peek() != '{' {
+		return nil, fmt.Errorf("missing left brace")
+	}
+	p.pos++
+	ranges = []Range{}
+	p.skipSpace()
+	if p.peek() == '}' {
+		p.pos++
+	} else {
+		for {
+			var r Range
+			if r, err = p.parse(); err != nil {
+				return
+			}
+			ranges = append(ranges, r)
+			if p.peek() == ',' {
+				p.pos++
+				continue
+			} else if p.peek() == '}' {
+				p.pos++
+				break
+			}
+			return nil, fmt.Errorf("unexpected %q at %d", p.peek(), p.pos)
+		}
+	}
+	p.skipSpace()
+	if p.pos != len(src) {
+		return nil, fmt.Errorf("junk after right brace at %d", p.pos)
+	}
+	return
+}
+
+// FormatMultirange 输出多范围的文本格式
+func FormatMultirange(ranges []Range) []byte {
+	var b = []byte{'{'}
+	for i, r := range ranges {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = append(b, FormatRange(r)...)
+	}
+	return append(b, '}')
+}
+
+// ParseRangeBinary 解析范围的二进制格式：1 字节标志位，随后是存在的边界，各自以 4 字节长度开头
+func ParseRangeBinary(src []byte) (r Range, err error) {
+	if len(src) < 1 {
+		return r, fmt.Errorf("pg: invalid binary range value: too short")
+	}
+	var flags = src[0]
+	src = src[1:]
+	if flags&rangeEmpty != 0 {
+		return Range{Empty: true}, nil
+	}
+	r.LowerInclusive = flags&rangeLBInc != 0
+	r.UpperInclusive = flags&rangeUBInc != 0
+	if flags&rangeLBInf == 0 {
+		if r.Lower, src, err = readLengthPrefixed(src); err != nil {
+			return
+		}
+	}
+	if flags&rangeUBInf == 0 {
+		if r.Upper, src, err = readLengthPrefixed(src); err != nil {
+			return
+		}
+	}
+	if len(src) != 0 {
+		err = fmt.Errorf("pg: invalid binary range value: %d extra bytes", len(src))
+	}
+	return
+}
+
+// FormatRangeBinary 输出范围的二进制格式，边界须为元素的二进制格式
+func FormatRangeBinary(r Range) []byte {
+	if r.Empty {
+		return []byte{rangeEmpty}
+	}
+	var flags byte
+	if r.Lower == nil {
+		flags |= rangeLBInf
+	} else if r.LowerInclusive {
+		flags |= rangeLBInc
+	}
+	if r.Upper == nil {
+		flags |= rangeUBInf
+	} else if r.UpperInclusive {
+		flags |= rangeUBInc
+	}
+	var b = []byte{flags}
+	if r.Lower != nil {
+		b = appendLengthPrefixed(b, r.Lower)
+	}
+	if r.Upper != nil {
+		b = appendLengthPrefixed(b, r.Upper)
+	}
+	return b
+}
+
+// ParseMultirangeBinary 解析多范围的二进制格式：4 字节的范围个数，随后是各个带 4 字节长度的范围
+func ParseMultirangeBinary(src []byte) (ranges []Range, err error) {
+	if len(src) < 4 {
+		return nil, fmt.Errorf("pg: invalid binary multirange value: too short")
+	}
+	var count = binary.BigEndian.Uint32(src)
+	src = src[4:]
+	ranges = make([]Range, 0, count)
+	for i := uint32(0); i < count; i++ {
+		var raw []byte
+		if raw, src, err = readLengthPrefixed(src); err != nil {
+			return
+		}
+		if raw == nil {
+			return nil, fmt.Errorf("pg: invalid binary multirange value: NULL range")
+		}
+		var r Range
+		if r, err = ParseRangeBinary(raw); err != nil {
+			return
+		}
+		ranges = append(ranges, r)
+	}
+	if len(src) != 0 {
+		err = fmt.Errorf("pg: invalid binary multirange value: %d extra bytes", len(src))
+	}
+	return
+}
+
+// FormatMultirangeBinary 输出多范围的二进制格式
+func FormatMultirangeBinary(ranges []Range) []byte {
+	var b = binary.BigEndian.AppendUint32(nil, uint32(len(ranges)))
+	for _, r := range ranges {
+		b = appendLengthPrefixed(b, FormatRangeBinary(r))
+	}
+	return b
+}
+
+// readLengthPrefixed 读取以 4 字节长度开头的值，长度为 -1 时返回 nil
+func readLengthPrefixed(src []byte) (value, rest []byte, err error) {
+	if len(src) < 4 {
+		return nil, nil, fmt.Errorf("pg: unexpected end of binary value")
+	}
+	var l = int32(binary.BigEndian.Uint32(src))
+	src = src[4:]
+	if l < 0 {
+		return nil, src, nil
+	}
+	if int(l) > len(src) {
+		return nil, nil, fmt.Errorf("pg: unexpected end of binary value")
+	}
+	return src[:l:l], src[l:], nil
+}
+
+func appendLengthPrefixed(b, value []byte) []byte {
+	if value == nil {
+		return binary.BigEndian.AppendUint32(b, 0xFFFFFFFF)
+	}
+	b = binary.BigEndian.AppendUint32(b, uint32(len(value)))
+	return append(b, value...)
+}
@@ -0,0 +1,102 @@
+package codec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRange(t *testing.T) {
+	var b = func(s string) []byte { return []byte(s) }
+	var cases = []struct {
+		in   string
+		want Range
+		out  string
+	}{
+		{"empty", Range{Empty: true}, "empty"},
+		{" EMPTY ", Range{Empty: true}, "empty"},
+		{"[1,10)", Range{Lower: b("1"), Upper: b("10"), LowerInclusive: true}, "[1,10)"},
+		{"(,5]", Range{Upper: b("5"), UpperInclusive: true}, "(,5]"},
+		{"[,)", Range{}, "(,)"},
+		{`["2020-01-01 00:00:00+08",infinity)`, Range{Lower: b("2020-01-01 00:00:00+08"), Upper: b("infinity"), LowerInclusive: true}, `["2020-01-01 00:00:00+08",infinity)`},
+		{`("a""b","c\\d"]`, Range{Lower: b(`a"b`), Upper: b(`c\d`), UpperInclusive: true}, `("a""b","c\\d"]`},
+		{`("",x)`, Range{Lower: b(""), Upper: b("x")}, `("",x)`},
+		{`(a\,b,c)`, Range{Lower: b("a,b"), Upper: b("c")}, `("a,b",c)`},
+	}
+	for _, c := range cases {
+		got, err := ParseRange([]byte(c.in))
+		if err != nil {
+			t.Errorf("ParseRange(%s): %v", c.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("ParseRange(%s) = %+v, want %+v", c.in, got, c.want)
+		}
+		if out := string(FormatRange(got)); out != c.out {
+			t.Errorf("FormatRange(%+v) = %s, want %s", got, out, c.out)
+		}
+		back, err := ParseRangeBinary(FormatRangeBinary(got))
+		if err != nil || !reflect.DeepEqual(back, got) {
+			t.Errorf("binary round trip of %s = %+v, %v", c.in, back, err)
+		}
+	}
+	for _, in := range []string{"", "[1,2", "1,2)", "[1 2)", "[1,2)x", "[(1,2)", "emptyx", `["a,b)`} {
+		if _, err := ParseRange([]byte(in)); err == nil {
+			t.Errorf("ParseRange(%s) should fail", in)
+		}
+	}
+}
+
+func TestParseMultirange(t *testing.T) {
+	var cases = []struct {
+		in  string
+		n   int
+		out string
+	}{
+		{"{}", 0, "{}"},
+		{"{[1,3),[5,7)}", 2, "{[1,3),[5,7)}"},
+		{" { [1,3) , (,0] } ", 2, "{[1,3),(,0]}"},
+		{"{empty}", 1, "{empty}"},
+	}
+	for _, c := range cases {
+		ranges, err := ParseMultirange([]byte(c.in))
+		if err != nil {
+			t.Errorf("ParseMultirange(%s): %v", c.in, err)
+			continue
+		}
+		if len(ranges) != c.n {
+			t.Errorf("ParseMultirange(%s) has %d ranges, want %d", c.in, len(ranges), c.n)
+		}
+		if out := string(FormatMultirange(ranges)); out != c.out {
+			t.Errorf("FormatMultirange(%s) = %s, want %s", c.in, out, c.out)
+		}
+		back, err := ParseMultirangeBinary(FormatMultirangeBinary(ranges))
+		if err != nil || !reflect.DeepEqual(back, ranges) {
+			t.Errorf("binary round trip of %s = %+v, %v", c.in, back, err)
+		}
+	}
+	for _, in := range []string{"", "[1,2)", "{[1,2)", "{[1,2);[3,4)}", "{}x"} {
+		if _, err := ParseMultirange([]byte(in)); err == nil {
+			t.Errorf("ParseMultirange(%s) should fail", in)
+		}
+	}
+}
+
+func TestParseRangeBinary(t *testing.T) {
+	// int4range '[1,10)'：标志位 0x02，随后是两个 4 字节长度及 int4
+	var src = []byte{0x02, 0, 0, 0, 4, 0, 0, 0, 1, 0, 0, 0, 4, 0, 0, 0, 10}
+	r, err := ParseRangeBinary(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.LowerInclusive || r.UpperInclusive || !reflect.DeepEqual(r.Lower, []byte{0, 0, 0, 1}) || !reflect.DeepEqual(r.Upper, []byte{0, 0, 0, 10}) {
+		t.Errorf("ParseRangeBinary = %+v", r)
+	}
+	if got := FormatRangeBinary(r); !reflect.DeepEqual(got, src) {
+		t.Errorf("FormatRangeBinary = %x, want %x", got, src)
+	}
+	for _, bad := range [][]byte{nil, {0x02}, {0x02, 0, 0, 0, 4, 0, 0}, append(append([]byte{}, src...), 0)} {
+		if _, err := ParseRangeBinary(bad); err == nil {
+			t.Errorf("ParseRangeBinary(%x) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,332 @@
+// Copyright 2026 YBCZ, Inc. All rights reserved.
+//
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file in the root of the source
+// tree.
+
+package codec
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ParseVector 解析 pgvector 中 vector、halfvec 的文本格式，如 [1,2.5,-3]
+func ParseVector(src []byte) ([]float32, error) {
+	var s = strings.TrimSpace(string(src))
+	if len(s) < 2 || s[0] != '[' || s[len(s)-1] != ']' {
+		return nil, fmt.Errorf("pg: invalid vector value %q", src)
+	}
+	s = strings.TrimSpace(s[1 : len(s)-1])
+	if s == "" {
+		return []float32{}, nil
+	}
+	var fields = strings.Split(s, ",")
+	var v = make([]float32, len(fields))
+	for i, f := range fields {
+		n, err := strconv.ParseFloat(strings.TrimSpace(f), 32)
+		if err != nil {
+			return nil, fmt.Errorf("pg: invalid vector value %q", src)
+		}
+		v[i] = float32(n)
+	}
+	return v, nil
+}
+
+// FormatVector 输出 vector、halfvec 的文本格式
+func FormatVector(v []float32) []byte {
+	var b = make([]byte, 0, 2+len(v)*8)
+	b = append(b, '[')
+	for i, f := range v {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = strconv.AppendFloat(b, float64(f), 'g', -1, 32)
+	}
+	return append(b, ']')
+}
+
+// ParseVectorBinary 解析 vector 的二进制格式：2 字节的维数、2 字节的保留字段，随后是各维的 float4
+func ParseVectorBinary(src []byte) ([]float32, error) {
+	if len(src) < 4 {
+		return nil, fmt.Errorf("pg: invalid binary vector length %d", len(src))
+	}
+	var dim = int(binary.BigEndian.Uint16(src))
+	if len(src) != 4+dim*4 {
+		return nil, fmt.Errorf("pg: invalid binary vector length %d for %d dimensions", len(src), dim)
+	}
+	var v = make([]float32, dim)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.BigEndian.Uint32(src[4+i*4:]))
+	}
+	return v, nil
+}
+
+// FormatVectorBinary 输出 vector 的二进制格式
+func FormatVectorBinary(v []float32) []byte {
+	var b = make([]byte, 0, 4+len(v)*4)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(v)))
+	b = binary.BigEndian.AppendUint16(b, 0)
+	for _, f := range v {
+		b = binary.BigEndian.AppendUint32(b, math.Float32bits(f))
+	}
+	return b
+}
+
+// ParseHalfvecBinary 解析 halfvec 的二进制格式，与 vector 相同，只是各维为 2 字节的半精度浮点数
+func ParseHalfvecBinary(src []byte) ([]float32, error) {
+	if len(src) < 4 {
+		return nil, fmt.Errorf("pg: invalid binary halfvec length %d", len(src))
+	}
+	var dim = int(binary.BigEndian.Uint16(src))
+	if len(src) != 4+dim*2 {
+		return nil, fmt.Errorf("pg: invalid binary halfvec length %d for %d dimensions", len(src), dim)
+	}
+	var v = make([]float32, dim)
+	for i := range v {
+		v[i] = halfToFloat32(binary.BigEndian.Uint16(src[4+i*2:]))
+	}
+	return v, nil
+}
+
+// FormatHalfvecBinary 输出 halfvec 的二进制格式，各维按就近舍入转为半精度，超出范围时为无穷大，由服务器报错
+func FormatHalfvecBinary(v []float32) []byte {
+	var b = make([]byte, 0, 4+len(v)*2)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(v)))
+	b = binary.BigEndian.AppendUint16(b, 0)
+	for _, f := range v {
+		b = binary.BigEndian.AppendUint16(b, float32ToHalf(f))
+	}
+	return b
+}
+
+func halfToFloat32(h uint16) float32 {
+	var sign = uint32(h&0x8000) << 16
+	var exp = uint32(h>>10) & 0x1f
+	var mant = uint32(h & 0x3ff)
+	switch exp {
+	case 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	case 0:
+		// 非规格化数为 mant * 2^-24
+		var f = float32(mant) / (1 << 24)
+		if sign != 0 {
+			f = -f
+		}
+		return f
+	}
+	return math.Float32frombits(sign | (exp+127-15)<<23 | mant<<13)
+}
+
+func float32ToHalf(f float32) uint16 {
+	var bits = math.Float32bits(f)
+	var sign = uint16(bits>>16) & 0x8000
+	var exp = int(bits>>23&0xff) - 127 + 15
+	var mant = bits & 0x7fffff
+	switch {
+	case bits&0x7fffffff == 0:
+		return sign
+	case bits>>23&0xff == 0xff:
+		if mant != 0 {
+			return sign | 0x7e00
+		}
+		return sign | 0x7c00
+	case exp >= 0x1f:
+		return sign | 0x7c00
+	case exp <= 0:
+		if exp < -10 {
+			return sign
+		}
+		// 转为非规格化数
+		mant |= 0x800000
+		var shift = uint(14 - exp)
+		var h = uint16(mant >> shift)
+		var rem, half = mant & (1<<shift - 1), uint32(1) << (shift - 1)
+		if rem > half || (rem == half && h&1 == 1) {
+			h++
+		}
+		return sign | h
+	}
+	var h = uint16(exp)<<10 | uint16(mant>>13)
+	// 就近舍入，进位可能使指数加 1，结果仍然正确
+	if rem := mant & 0x1fff; rem > 0x1000 || (rem == 0x1000 && h&1 == 1) {
+		h++
+	}
+	return sign | h
+}
+
+// SparseVector 对应 pgvector 的 sparsevec，Indices 从 0 开始且递增，Values 为对应位置的非零值
+// 文本格式为 {1:1.5,3:2}/5，其中的下标从 1 开始
+type SparseVector struct {
+	Dim     int32
+	Indices []int32
+	Values  []float32
+}
+
+// NewSparseVector 由稠密向量构造 SparseVector，只保留非零值
+func NewSparseVector(v []float32) SparseVector {
+	var s = SparseVector{Dim: int32(len(v)), Indices: []int32{}, Values: []float32{}}
+	for i, f := range v {
+		if f != 0 {
+			s.Indices = append(s.Indices, int32(i))
+			s.Values = append(s.Values, f)
+		}
+	}
+	return s
+}
+
+// Dense 转为稠密向量
+func (s SparseVector) Dense() []float32 {
+	var v = make([]float32, s.Dim)
+	for i, idx := range s.Indices {
+		if idx >= 0 && idx < s.Dim && i < len(s.Values) {
+			v[idx] = s.Values[i]
+		}
+	}
+	return v
+}
+
+// ParseSparseVector 解析 sparsevec 的文本格式
+func ParseSparseVector(src []byte) (s SparseVector, err error) {
+	var str = strings.TrimSpace(string(src))
+	var p = strings.LastIndexByte(str, '/')
+	var braces = ""
+	if p >= 0 {
+		braces = strings.TrimSpace(str[:p])
+	}
+	if len(braces) < 2 || braces[0] != '{' || braces[len(braces)-1] != '}' {
+		return s, fmt.Errorf("pg: invalid sparsevec value %q", src)
+	}
+	dim, err := strconv.ParseInt(strings.TrimSpace(str[p+1:]), 10, 32)
+	if err != nil {
+		return s, fmt.Errorf("pg: invalid sparsevec value %q", src)
+	}
+	s = SparseVector{Dim: int32(dim), Indices: []int32{}, Values: []float32{}}
+	if body := strings.TrimSpace(braces[1 : len(braces)-1]); body != "" {
+		for _, elem := range strings.Split(body, ",") {
+			var kv = strings.SplitN(elem, ":", 2)
+			if len(kv) != 2 {
+				return SparseVector{}, fmt.Errorf("pg: invalid sparsevec value %q", src)
+			}
+			idx, err := strconv.ParseInt(strings.TrimSpace(kv[0]), 10, 32)
+			if err != nil {
+				return SparseVector{}, fmt.Errorf("pg: invalid sparsevec value %q", src)
+			}
+			f, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 32)
+			if err != nil {
+				return SparseVector{}, fmt.Errorf("pg: invalid sparsevec value %q", src)
+			}
+			s.Indices = append(s.Indices, int32(idx-1))
+			s.Values = append(s.Values, float32(f))
+		}
+	}
+	if err = s.validate(); err != nil {
+		return SparseVector{}, err
+	}
+	return s, nil
+}
+
+// validate 与 pgvector 一样要求维数不为负、下标在维数以内且严格递增
+func (s SparseVector) validate() error {
+	if s.Dim < 0 {
+		return fmt.Errorf("pg: sparsevec must not have negative dimensions %d", s.Dim)
+	}
+	if len(s.Indices) != len(s.Values) {
+		return fmt.Errorf("pg: sparsevec has %d indices but %d values", len(s.Indices), len(s.Values))
+	}
+	for i, idx := range s.Indices {
+		if idx < 0 || idx >= s.Dim {
+			return fmt.Errorf("pg: sparsevec index %d out of bounds for %d dimensions", int64(idx)+1, s.Dim)
+		}
+		if i > 0 && idx <= s.Indices[i-1] {
+			return fmt.Errorf("pg: sparsevec indices must be strictly increasing")
+		}
+	}
+	return nil
+}
+
+func (s SparseVector) String() string {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, idx := range s.Indices {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatInt(int64(idx)+1, 10))
+		b.WriteByte(':')
+		if i < len(s.Values) {
+			b.WriteString(strconv.FormatFloat(float64(s.Values[i]), 'g', -1, 32))
+		}
+	}
+	b.WriteString("}/")
+	b.WriteString(strconv.FormatInt(int64(s.Dim), 10))
+	return b.String()
+}
+
+func (s *SparseVector) Scan(src interface{}) (err error) {
+	switch v := src.(type) {
+	case SparseVector:
+		*s = v
+		return nil
+	case []float32:
+		*s = NewSparseVector(v)
+		return nil
+	case string:
+		*s, err = ParseSparseVector([]byte(v))
+	case []byte:
+		*s, err = ParseSparseVector(v)
+	case nil:
+		err = fmt.Errorf("pg: cannot scan NULL into SparseVector, use *SparseVector instead")
+	default:
+		err = fmt.Errorf("pg: cannot scan %T into SparseVector", src)
+	}
+	return
+}
+
+func (s SparseVector) Value() (driver.Value, error) {
+	return s.String(), nil
+}
+
+// MarshalBinary 输出 sparsevec 的二进制格式：4 字节的维数、非零值个数及保留字段，随后是各下标及各值
+func (s SparseVector) MarshalBinary() ([]byte, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	var b = make([]byte, 0, 12+len(s.Indices)*8)
+	b = binary.BigEndian.AppendUint32(b, uint32(s.Dim))
+	b = binary.BigEndian.AppendUint32(b, uint32(len(s.Indices)))
+	b = binary.BigEndian.AppendUint32(b, 0)
+	for _, idx := range s.Indices {
+		b = binary.BigEndian.AppendUint32(b, uint32(idx))
+	}
+	for _, f := range s.Values {
+		b = binary.BigEndian.AppendUint32(b, math.Float32bits(f))
+	}
+	return b, nil
+}
+
+// UnmarshalBinary 解析 sparsevec 的二进制格式
+func (s *SparseVector) UnmarshalBinary(src []byte) error {
+	if len(src) < 12 {
+		return fmt.Errorf("pg: invalid binary sparsevec length %d", len(src))
+	}
+	var nnz = int(binary.BigEndian.Uint32(src[4:]))
+	if nnz > (len(src)-12)/8 || len(src) != 12+nnz*8 {
+		return fmt.Errorf("pg: invalid binary sparsevec length %d for %d elements", len(src), nnz)
+	}
+	var v = SparseVector{Dim: int32(binary.BigEndian.Uint32(src)), Indices: make([]int32, nnz), Values: make([]float32, nnz)}
+	for i := 0; i < nnz; i++ {
+		v.Indices[i] = int32(binary.BigEndian.Uint32(src[12+i*4:]))
+		v.Values[i] = math.Float32frombits(binary.BigEndian.Uint32(src[12+nnz*4+i*4:]))
+	}
+	if err := v.validate(); err != nil {
+		return err
+	}
+	*s = v
+	return nil
+}
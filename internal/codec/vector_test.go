@@ -0,0 +1,192 @@
+package codec
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseVector(t *testing.T) {
+	var cases = []struct {
+		in   string
+		want []float32
+	}{
+		{"[]", []float32{}},
+		{"[1,2.5,-3]", []float32{1, 2.5, -3}},
+		{" [ 1 , 1e-3 ] ", []float32{1, 0.001}},
+		{"[3.4028235e+38]", []float32{math.MaxFloat32}},
+	}
+	for _, c := range cases {
+		v, err := ParseVector([]byte(c.in))
+		if err != nil || !reflect.DeepEqual(v, c.want) {
+			t.Errorf("ParseVector(%s) = %v, %v, want %v", c.in, v, err, c.want)
+			continue
+		}
+		if back, err := ParseVector(FormatVector(v)); err != nil || !reflect.DeepEqual(back, v) {
+			t.Errorf("text round trip of %s = %v, %v", c.in, back, err)
+		}
+		if back, err := ParseVectorBinary(FormatVectorBinary(v)); err != nil || !reflect.DeepEqual(back, v) {
+			t.Errorf("binary round trip of %s = %v, %v", c.in, back, err)
+		}
+	}
+	for _, in := range []string{"", "[", "1,2", "[1,,2]", "[1;2]", "[a]", "{1,2}"} {
+		if _, err := ParseVector([]byte(in)); err == nil {
+			t.Errorf("ParseVector(%q) should fail", in)
+		}
+	}
+	if out := string(FormatVector([]float32{1, 0.1, -2.5e-7})); out != "[1,0.1,-2.5e-07]" {
+		t.Errorf("FormatVector = %s", out)
+	}
+}
+
+func TestVectorBinary(t *testing.T) {
+	var raw = FormatVectorBinary([]float32{1, -2})
+	var want = []byte{0, 2, 0, 0, 0x3f, 0x80, 0, 0, 0xc0, 0, 0, 0}
+	if !reflect.DeepEqual(raw, want) {
+		t.Errorf("FormatVectorBinary = %v", raw)
+	}
+	for _, bad := range [][]byte{nil, {0, 1, 0}, {0, 1, 0, 0}, {0, 1, 0, 0, 1, 2, 3}, append(want, 0)} {
+		if _, err := ParseVectorBinary(bad); err == nil {
+			t.Errorf("ParseVectorBinary(%v) should fail", bad)
+		}
+		if _, err := ParseHalfvecBinary(bad); err == nil {
+			t.Errorf("ParseHalfvecBinary(%v) should fail", bad)
+		}
+	}
+}
+
+func TestHalfvec(t *testing.T) {
+	var inf = float32(math.Inf(1))
+	var cases = []struct {
+		in   float32
+		half uint16
+		out  float32
+	}{
+		{0, 0x0000, 0},
+		{float32(math.Copysign(0, -1)), 0x8000, float32(math.Copysign(0, -1))},
+		{1, 0x3c00, 1},
+		{-2, 0xc000, -2},
+		{0.1, 0x2e66, 0.0999755859375},
+		{65504, 0x7bff, 65504},                                 // 最大的有限值
+		{65519, 0x7bff, 65504},                                 // 舍入后仍为有限值
+		{65520, 0x7c00, inf},                                   // 舍入进位后溢出为无穷大
+		{1e10, 0x7c00, inf},                                    // 超出范围
+		{inf, 0x7c00, inf},                                     // 无穷大
+		{-inf, 0xfc00, -inf},                                   // 负无穷大
+		{6.103515625e-05, 0x0400, 6.103515625e-05},             // 最小的规格化数
+		{6.1e-05, 0x03ff, 6.097555160522461e-05},               // 舍入为非规格化数
+		{5.960464477539063e-08, 0x0001, 5.960464477539063e-08}, // 最小的非规格化数
+		{2.98023223876953125e-08, 0x0000, 0},                   // 2^-25 恰在中间，向偶数舍入为 0
+		{2.99e-08, 0x0001, 5.960464477539063e-08},              // 略大于 2^-25，向上舍入
+		{1e-10, 0x0000, 0},                                     // 下溢为 0
+		{1.00048828125, 0x3c00, 1},                             // 恰在中间，向偶数舍入
+		{1.00146484375, 0x3c02, 1.001953125},                   // 恰在中间，向偶数进位
+		{6.1005353927612305e-05, 0x0400, 6.103515625e-05},      // 非规格化数进位为最小的规格化数
+	}
+	for _, c := range cases {
+		if h := float32ToHalf(c.in); h != c.half {
+			t.Errorf("float32ToHalf(%g) = %#04x, want %#04x", c.in, h, c.half)
+		}
+		if f := halfToFloat32(c.half); math.Float32bits(f) != math.Float32bits(c.out) {
+			t.Errorf("halfToFloat32(%#04x) = %g, want %g", c.half, f, c.out)
+		}
+	}
+	var nan = halfToFloat32(float32ToHalf(float32(math.NaN())))
+	if !math.IsNaN(float64(nan)) {
+		t.Errorf("NaN round trip = %g", nan)
+	}
+	// 所有有限的半精度值经 float32 转换后应原样还原
+	for h := 0; h < 0x10000; h++ {
+		if h&0x7c00 == 0x7c00 {
+			continue
+		}
+		if back := float32ToHalf(halfToFloat32(uint16(h))); back != uint16(h) {
+			t.Fatalf("round trip of %#04x = %#04x", h, back)
+		}
+	}
+
+	var v = []float32{1, -0.5, 65504}
+	back, err := ParseHalfvecBinary(FormatHalfvecBinary(v))
+	if err != nil || !reflect.DeepEqual(back, v) {
+		t.Errorf("halfvec round trip = %v, %v", back, err)
+	}
+	if raw := FormatHalfvecBinary([]float32{1, -2}); !reflect.DeepEqual(raw, []byte{0, 2, 0, 0, 0x3c, 0, 0xc0, 0}) {
+		t.Errorf("FormatHalfvecBinary = %v", raw)
+	}
+}
+
+func TestSparseVector(t *testing.T) {
+	var cases = []struct {
+		in   string
+		want SparseVector
+	}{
+		{"{}/3", SparseVector{Dim: 3, Indices: []int32{}, Values: []float32{}}},
+		{"{1:1.5,3:2}/5", SparseVector{Dim: 5, Indices: []int32{0, 2}, Values: []float32{1.5, 2}}},
+		{" { 2 : -1 } / 2 ", SparseVector{Dim: 2, Indices: []int32{1}, Values: []float32{-1}}},
+	}
+	for _, c := range cases {
+		s, err := ParseSparseVector([]byte(c.in))
+		if err != nil || !reflect.DeepEqual(s, c.want) {
+			t.Errorf("ParseSparseVector(%s) = %+v, %v, want %+v", c.in, s, err, c.want)
+			continue
+		}
+		if back, err := ParseSparseVector([]byte(s.String())); err != nil || !reflect.DeepEqual(back, s) {
+			t.Errorf("text round trip of %s = %+v, %v", c.in, back, err)
+		}
+		raw, err := s.MarshalBinary()
+		if err != nil {
+			t.Errorf("MarshalBinary(%s): %v", c.in, err)
+			continue
+		}
+		var back SparseVector
+		if err = back.UnmarshalBinary(raw); err != nil || !reflect.DeepEqual(back, s) {
+			t.Errorf("binary round trip of %s = %+v, %v", c.in, back, err)
+		}
+	}
+	if s := NewSparseVector([]float32{0, 1.5, 0, 2}); s.String() != "{2:1.5,4:2}/4" || !reflect.DeepEqual(s.Dense(), []float32{0, 1.5, 0, 2}) {
+		t.Errorf("NewSparseVector = %s, dense %v", s, s.Dense())
+	}
+
+	var bad = []struct {
+		in, err string
+	}{
+		{"{1:1}", "invalid"},
+		{"1:1/2", "invalid"},
+		{"{1:1}/x", "invalid"},
+		{"{1}/2", "invalid"},
+		{"{a:1}/2", "invalid"},
+		{"{1:a}/2", "invalid"},
+		{"{0:1}/2", "out of bounds"},
+		{"{3:1}/2", "out of bounds"},
+		{"{2:1,1:1}/2", "strictly increasing"},
+		{"{1:1,1:2}/2", "strictly increasing"},
+		{"{}/-1", "negative"},
+	}
+	for _, c := range bad {
+		if _, err := ParseSparseVector([]byte(c.in)); err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("ParseSparseVector(%s) = %v, want an error about %s", c.in, err, c.err)
+		}
+	}
+	for _, s := range []SparseVector{
+		{Dim: 2, Indices: []int32{0}, Values: []float32{}},
+		{Dim: 2, Indices: []int32{2}, Values: []float32{1}},
+		{Dim: 3, Indices: []int32{1, 0}, Values: []float32{1, 2}},
+	} {
+		if _, err := s.MarshalBinary(); err == nil {
+			t.Errorf("MarshalBinary(%+v) should fail", s)
+		}
+	}
+
+	var valid, _ = SparseVector{Dim: 5, Indices: []int32{0, 2}, Values: []float32{1, 2}}.MarshalBinary()
+	var outOfBounds = append([]byte{}, valid...)
+	outOfBounds[19] = 5 // 第二个下标改为 5
+	var unordered = append([]byte{}, valid...)
+	unordered[19] = 0
+	for _, raw := range [][]byte{nil, valid[:11], valid[:len(valid)-1], append(valid, 0), {0, 0, 0, 1, 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}, outOfBounds, unordered} {
+		var s SparseVector
+		if err := s.UnmarshalBinary(raw); err == nil {
+			t.Errorf("UnmarshalBinary(%v) should fail", raw)
+		}
+	}
+}
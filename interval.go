@@ -0,0 +1,71 @@
+package pg
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/blusewang/pg/v2/internal/codec"
+	"math"
+	"time"
+)
+
+// Interval 对应 PostgreSQL 的 interval 类型，三个字段与服务器内部的存储方式一致
+// 月和天的实际长度取决于日历及时区，因此不会折算为微秒；NULL 不能扫描到 Interval，可以的列请使用 *Interval
+type Interval struct {
+	Months       int32
+	Days         int32
+	Microseconds int64
+}
+
+// NewIntervalFromDuration 由 time.Duration 构造 Interval
+// interval 的精度为微秒，与直接以 time.Duration 作为参数时一样，d 含不足 1 微秒的部分时返回错误，需要时请先 d.Round(time.Microsecond)
+func NewIntervalFromDuration(d time.Duration) (Interval, error) {
+	if d%time.Microsecond != 0 {
+		return Interval{}, fmt.Errorf("pg: duration %s is finer than the microsecond precision of interval, round it first", d)
+	}
+	return Interval{Microseconds: d.Microseconds()}, nil
+}
+
+// ParseInterval 解析 interval 的文本格式，支持 IntervalStyle 为 postgres、postgres_verbose、sql_standard、iso_8601 时的输出
+func ParseInterval(str string) (i Interval, err error) {
+	i.Months, i.Days, i.Microseconds, err = codec.ParseInterval(str)
+	return
+}
+
+// Duration 转为 time.Duration，仅当不含月和天且未溢出时才是精确的，否则返回错误
+func (i Interval) Duration() (time.Duration, error) {
+	if i.Months != 0 || i.Days != 0 {
+		return 0, fmt.Errorf("pg: interval %s contains months or days and cannot be converted to time.Duration exactly", i)
+	}
+	if i.Microseconds > math.MaxInt64/1000 || i.Microseconds < math.MinInt64/1000 {
+		return 0, fmt.Errorf("pg: interval %s overflows time.Duration", i)
+	}
+	return time.Duration(i.Microseconds) * time.Microsecond, nil
+}
+
+// String 按 ISO 8601 格式输出，如 P1Y2M3DT4H5M6.5S
+func (i Interval) String() string {
+	return codec.FormatInterval(i.Months, i.Days, i.Microseconds)
+}
+
+func (i *Interval) Scan(src interface{}) (err error) {
+	switch v := src.(type) {
+	case string:
+		*i, err = ParseInterval(v)
+	case []byte:
+		*i, err = ParseInterval(string(v))
+	case nil:
+		err = errors.New("pg: cannot scan NULL into Interval, use *Interval instead")
+	default:
+		err = fmt.Errorf("pg: cannot scan %T into Interval", src)
+	}
+	return
+}
+
+func (i Interval) Value() (driver.Value, error) {
+	return i.String(), nil
+}
+
+var _ sql.Scanner = new(Interval)
+var _ driver.Valuer = new(Interval)
@@ -0,0 +1,24 @@
+package pg
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewIntervalFromDuration(t *testing.T) {
+	i, err := NewIntervalFromDuration(90*time.Minute + 1500*time.Microsecond)
+	if err != nil || i != (Interval{Microseconds: 5400001500}) {
+		t.Errorf("NewIntervalFromDuration = %+v, %v", i, err)
+	}
+	if d, err := i.Duration(); err != nil || d != 90*time.Minute+1500*time.Microsecond {
+		t.Errorf("Duration() = %s, %v", d, err)
+	}
+	// 与以 time.Duration 直接作为参数时的规则一致
+	if _, err = NewIntervalFromDuration(1500 * time.Nanosecond); err == nil || !strings.Contains(err.Error(), "finer than the microsecond") {
+		t.Errorf("expected a precision error, got %v", err)
+	}
+	if i, err = NewIntervalFromDuration((1500 * time.Nanosecond).Round(time.Microsecond)); err != nil || i.Microseconds != 2 {
+		t.Errorf("rounded duration = %+v, %v", i, err)
+	}
+}
@@ -18,14 +18,13 @@ func NewListener(ctx context.Context, dsnString string) (Listener, error) {
 		return nil, err
 	}
 	if err = c.Connect(ctx, dsn); err != nil {
-		_ = c.CloseConn()
-		return nil, err
+		return nil, dsn.WrapConnectError(err)
 	}
-	if err = c.AutoSSL(); err != nil {
+	if err = c.AutoSSL(ctx); err != nil {
 		_ = c.CloseConn()
-		return nil, err
+		return nil, dsn.WrapConnectError(err)
 	}
-	if err = c.Startup(); err != nil {
+	if err = c.Startup(ctx); err != nil {
 		_ = c.CloseConn()
 		return nil, err
 	}
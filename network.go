@@ -0,0 +1,91 @@
+package pg
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/blusewang/pg/v2/internal/codec"
+	"net"
+	"net/netip"
+)
+
+// Addr 用于把 inet 列扫描到 netip.Addr，列值带有网络掩码时返回错误
+// inet、cidr 列本身解码为 netip.Prefix，macaddr、macaddr8 列解码为 net.HardwareAddr，可直接 Scan 到这两种类型
+//
+//	var ip netip.Addr
+//	err := db.QueryRow("select ip from host where id=$1", id).Scan(pg.Addr(&ip))
+func Addr(a *netip.Addr) interface {
+	sql.Scanner
+	driver.Valuer
+} {
+	return addrArg{a}
+}
+
+type addrArg struct {
+	a *netip.Addr
+}
+
+func (a addrArg) Scan(src interface{}) error {
+	p, err := scanPrefix(src, "netip.Addr")
+	if err != nil {
+		return err
+	}
+	if !p.IsSingleIP() {
+		return fmt.Errorf("pg: cannot scan network %s into netip.Addr", p)
+	}
+	*a.a = p.Addr()
+	return nil
+}
+
+func (a addrArg) Value() (driver.Value, error) {
+	if a.a == nil || !a.a.IsValid() {
+		return nil, nil
+	}
+	return a.a.String(), nil
+}
+
+// IPNet 用于把 inet、cidr 列扫描到 net.IPNet，IP 保留主机位
+func IPNet(n *net.IPNet) interface {
+	sql.Scanner
+	driver.Valuer
+} {
+	return ipNetArg{n}
+}
+
+type ipNetArg struct {
+	n *net.IPNet
+}
+
+func (a ipNetArg) Scan(src interface{}) error {
+	p, err := scanPrefix(src, "net.IPNet")
+	if err != nil {
+		return err
+	}
+	*a.n = net.IPNet{
+		IP:   p.Addr().AsSlice(),
+		Mask: net.CIDRMask(p.Bits(), p.Addr().BitLen()),
+	}
+	return nil
+}
+
+func (a ipNetArg) Value() (driver.Value, error) {
+	if a.n == nil || a.n.IP == nil {
+		return nil, nil
+	}
+	return a.n.String(), nil
+}
+
+func scanPrefix(src interface{}, name string) (netip.Prefix, error) {
+	switch v := src.(type) {
+	case netip.Prefix:
+		return v, nil
+	case string:
+		return codec.ParseInet([]byte(v))
+	case []byte:
+		return codec.ParseInet(v)
+	case nil:
+		return netip.Prefix{}, errors.New("pg: cannot scan NULL into " + name)
+	}
+	return netip.Prefix{}, fmt.Errorf("pg: cannot scan %T into %s", src, name)
+}
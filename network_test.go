@@ -0,0 +1,57 @@
+package pg
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+)
+
+func TestAddr(t *testing.T) {
+	var ip netip.Addr
+	for _, src := range []interface{}{netip.MustParsePrefix("10.0.0.1/32"), "10.0.0.1", []byte("10.0.0.1/32")} {
+		if err := Addr(&ip).Scan(src); err != nil || ip != netip.MustParseAddr("10.0.0.1") {
+			t.Errorf("Scan(%v) = %s, %v", src, ip, err)
+		}
+	}
+	if err := Addr(&ip).Scan(netip.MustParsePrefix("2001:db8::1/128")); err != nil || ip.String() != "2001:db8::1" {
+		t.Errorf("Scan(IPv6) = %s, %v", ip, err)
+	}
+	if err := Addr(&ip).Scan("10.0.0.1/24"); err == nil {
+		t.Error("a network with a netmask should not scan into netip.Addr")
+	}
+	if err := Addr(&ip).Scan(nil); err == nil {
+		t.Error("Scan(nil) should fail")
+	}
+	if v, err := Addr(&ip).Value(); err != nil || v != "2001:db8::1" {
+		t.Errorf("Value() = %v, %v", v, err)
+	}
+	if v, err := Addr(&netip.Addr{}).Value(); err != nil || v != nil {
+		t.Errorf("Value() of an invalid address = %v, %v", v, err)
+	}
+}
+
+func TestIPNet(t *testing.T) {
+	var n net.IPNet
+	if err := IPNet(&n).Scan(netip.MustParsePrefix("192.168.1.5/24")); err != nil {
+		t.Fatal(err)
+	}
+	// 与 net.ParseCIDR 不同，IP 保留主机位
+	if n.IP.String() != "192.168.1.5" || n.String() != "192.168.1.5/24" {
+		t.Errorf("Scan = %s (IP %s)", n.String(), n.IP)
+	}
+	if err := IPNet(&n).Scan("2001:db8::/32"); err != nil || n.String() != "2001:db8::/32" || len(n.IP) != 16 {
+		t.Errorf("Scan(IPv6) = %s, %v", n.String(), err)
+	}
+	if err := IPNet(&n).Scan("10.0.0.1"); err != nil || n.String() != "10.0.0.1/32" {
+		t.Errorf("Scan(host) = %s, %v", n.String(), err)
+	}
+	if v, err := IPNet(&n).Value(); err != nil || v != "10.0.0.1/32" {
+		t.Errorf("Value() = %v, %v", v, err)
+	}
+	if v, err := IPNet(&net.IPNet{}).Value(); err != nil || v != nil {
+		t.Errorf("Value() of an empty network = %v, %v", v, err)
+	}
+	if err := IPNet(&n).Scan(42); err == nil {
+		t.Error("Scan(int) should fail")
+	}
+}
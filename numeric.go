@@ -0,0 +1,220 @@
+package pg
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"errors"
+	"fmt"
+	"github.com/blusewang/pg/v2/internal/codec"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// Numeric 无损对应 PostgreSQL 的 numeric 类型，值为 Int / 10^Scale，Int 为 nil 时视为 0
+// 与 Interval、Range 等值类型一致，NULL 不能扫描到 Numeric，可以的列请使用 *Numeric
+type Numeric struct {
+	Int      *big.Int
+	Scale    int32
+	NaN      bool
+	Infinity int8 // 1 表示 Infinity，-1 表示 -Infinity
+}
+
+// ParseNumeric 解析 numeric 的文本格式，支持 NaN、Infinity、-Infinity
+func ParseNumeric(str string) (n Numeric, err error) {
+	switch str {
+	case "NaN":
+		return Numeric{NaN: true}, nil
+	case "Infinity", "+Infinity", "inf", "+inf":
+		return Numeric{Infinity: 1}, nil
+	case "-Infinity", "-inf":
+		return Numeric{Infinity: -1}, nil
+	}
+	n.Int, n.Scale, err = codec.ParseDecimal(str)
+	return
+}
+
+// NewNumericFromRat 把有理数转为 Numeric，无法用有限位小数表示时返回错误
+func NewNumericFromRat(r *big.Rat) (n Numeric, err error) {
+	n.Int, n.Scale, err = codec.RatToDecimal(r)
+	return
+}
+
+func (n Numeric) String() string {
+	switch {
+	case n.NaN:
+		return "NaN"
+	case n.Infinity > 0:
+		return "Infinity"
+	case n.Infinity < 0:
+		return "-Infinity"
+	}
+	return codec.FormatDecimal(n.Int, n.Scale)
+}
+
+// IsFinite 是否为有限值，NaN、±Infinity 返回 false
+func (n Numeric) IsFinite() bool {
+	return !n.NaN && n.Infinity == 0
+}
+
+// Rat 转为有理数，仅有限值可以转换
+func (n Numeric) Rat() (*big.Rat, error) {
+	if !n.IsFinite() {
+		return nil, fmt.Errorf("pg: cannot convert numeric %s to *big.Rat", n)
+	}
+	r := new(big.Rat)
+	if n.Int != nil {
+		r.SetInt(n.Int)
+	}
+	if n.Scale > 0 {
+		r.Quo(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n.Scale)), nil)))
+	} else if n.Scale < 0 {
+		r.Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(-n.Scale)), nil)))
+	}
+	return r, nil
+}
+
+// BigInt 转为整数，有非零小数部分时返回错误
+func (n Numeric) BigInt() (*big.Int, error) {
+	r, err := n.Rat()
+	if err != nil {
+		return nil, err
+	}
+	if !r.IsInt() {
+		return nil, fmt.Errorf("pg: numeric %s is not an integer", n)
+	}
+	return new(big.Int).Set(r.Num()), nil
+}
+
+// Float64 转为浮点数，可能丢失精度
+func (n Numeric) Float64() (float64, error) {
+	switch {
+	case n.NaN:
+		return math.NaN(), nil
+	case n.Infinity != 0:
+		return math.Inf(int(n.Infinity)), nil
+	}
+	return strconv.ParseFloat(n.String(), 64)
+}
+
+func (n *Numeric) Scan(src interface{}) (err error) {
+	switch v := src.(type) {
+	case string:
+		*n, err = ParseNumeric(v)
+	case []byte:
+		*n, err = ParseNumeric(string(v))
+	case int64:
+		*n = Numeric{Int: big.NewInt(v)}
+	case float64:
+		*n, err = ParseNumeric(strconv.FormatFloat(v, 'f', -1, 64))
+	case nil:
+		err = errors.New("pg: cannot scan NULL into Numeric, use *Numeric instead")
+	default:
+		err = fmt.Errorf("pg: cannot scan %T into Numeric", src)
+	}
+	return
+}
+
+func (n Numeric) Value() (driver.Value, error) {
+	return n.String(), nil
+}
+
+// MarshalBinary 输出 numeric 的二进制格式
+func (n Numeric) MarshalBinary() ([]byte, error) {
+	switch {
+	case n.NaN:
+		return codec.FormatNumericSpecialBinary(0), nil
+	case n.Infinity != 0:
+		return codec.FormatNumericSpecialBinary(n.Infinity), nil
+	case n.Int == nil:
+		return codec.FormatNumericBinary(new(big.Int), n.Scale), nil
+	}
+	return codec.FormatNumericBinary(n.Int, n.Scale), nil
+}
+
+// UnmarshalBinary 解析 numeric 的二进制格式
+func (n *Numeric) UnmarshalBinary(src []byte) error {
+	str, err := codec.ParseNumericBinary(src)
+	if err != nil {
+		return err
+	}
+	*n, err = ParseNumeric(str)
+	return err
+}
+
+// Rat 用于把 numeric 列扫描到 *big.Rat，或把 *big.Rat 作为参数传入
+//
+//	var r big.Rat
+//	err := db.QueryRow("select price from goods where id=$1", id).Scan(pg.Rat(&r))
+func Rat(r *big.Rat) interface {
+	sql.Scanner
+	driver.Valuer
+} {
+	return ratArg{r}
+}
+
+type ratArg struct {
+	r *big.Rat
+}
+
+func (a ratArg) Scan(src interface{}) error {
+	var n Numeric
+	if err := n.Scan(src); err != nil {
+		return err
+	}
+	r, err := n.Rat()
+	if err != nil {
+		return err
+	}
+	a.r.Set(r)
+	return nil
+}
+
+func (a ratArg) Value() (driver.Value, error) {
+	if a.r == nil {
+		return nil, nil
+	}
+	n, err := NewNumericFromRat(a.r)
+	if err != nil {
+		return nil, err
+	}
+	return n.String(), nil
+}
+
+// BigInt 用于把 numeric 列扫描到 *big.Int，或把 *big.Int 作为参数传入
+func BigInt(i *big.Int) interface {
+	sql.Scanner
+	driver.Valuer
+} {
+	return bigIntArg{i}
+}
+
+type bigIntArg struct {
+	i *big.Int
+}
+
+func (a bigIntArg) Scan(src interface{}) error {
+	var n Numeric
+	if err := n.Scan(src); err != nil {
+		return err
+	}
+	i, err := n.BigInt()
+	if err != nil {
+		return err
+	}
+	a.i.Set(i)
+	return nil
+}
+
+func (a bigIntArg) Value() (driver.Value, error) {
+	if a.i == nil {
+		return nil, nil
+	}
+	return a.i.String(), nil
+}
+
+var _ sql.Scanner = new(Numeric)
+var _ driver.Valuer = new(Numeric)
+var _ encoding.BinaryMarshaler = new(Numeric)
+var _ encoding.BinaryUnmarshaler = new(Numeric)
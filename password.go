@@ -0,0 +1,58 @@
+// Copyright 2026 YBCZ, Inc. All rights reserved.
+//
+// Use of this source code is governed by a MIT license
+// that can be found in the LICENSE file in the root of the source
+// tree.
+
+package pg
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"github.com/blusewang/pg/v2/internal/client/scram"
+	"strings"
+)
+
+// DefaultSCRAMIterations 与服务器 scram_iterations 参数的默认值一致
+const DefaultSCRAMIterations = 4096
+
+// EncryptPassword 在本地生成口令的 SCRAM-SHA-256 校验值，作用同 libpq 的 PQencryptPasswordConn
+// 把结果作为 ALTER ROLE ... PASSWORD 的值时，服务器只保存校验值，明文不会出现在日志及 pg_stat_activity 中
+// iterations 不大于 0 时使用 DefaultSCRAMIterations
+func EncryptPassword(password string, iterations int) (string, error) {
+	if iterations <= 0 {
+		iterations = DefaultSCRAMIterations
+	}
+	var salt = make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("pg: cannot generate SCRAM salt: %w", err)
+	}
+	return scram.Verifier(password, salt, iterations), nil
+}
+
+// Execer *sql.DB、*sql.Conn、*sql.Tx 均满足该接口
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// AlterRolePassword 在本地生成校验值后执行 ALTER ROLE role PASSWORD '<校验值>'
+//
+//	err := pg.AlterRolePassword(ctx, db, "app", newPassword)
+func AlterRolePassword(ctx context.Context, db Execer, role, password string) error {
+	verifier, err := EncryptPassword(password, DefaultSCRAMIterations)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, "ALTER ROLE "+quoteIdentifier(role)+" PASSWORD "+quoteLiteral(verifier))
+	return err
+}
+
+func quoteIdentifier(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
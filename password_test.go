@@ -0,0 +1,51 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+	var cases = map[string]string{
+		"app":         `"app"`,
+		`we"ird`:      `"we""ird"`,
+		`""`:          `""""""`,
+		"Mixed Case":  `"Mixed Case"`,
+		`x"; drop --`: `"x""; drop --"`,
+	}
+	for in, want := range cases {
+		if out := quoteIdentifier(in); out != want {
+			t.Errorf("quoteIdentifier(%s) = %s, want %s", in, out, want)
+		}
+	}
+	if out := quoteLiteral("it's"); out != `'it''s'` {
+		t.Errorf("quoteLiteral = %s", out)
+	}
+}
+
+type recordingExecer struct {
+	query string
+}
+
+func (e *recordingExecer) ExecContext(_ context.Context, query string, _ ...interface{}) (sql.Result, error) {
+	e.query = query
+	return nil, nil
+}
+
+func TestAlterRolePassword(t *testing.T) {
+	var e recordingExecer
+	if err := AlterRolePassword(context.Background(), &e, `o"brien`, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	var re = regexp.MustCompile(`^ALTER ROLE "o""brien" PASSWORD 'SCRAM-SHA-256\$4096:[A-Za-z0-9+/=]{24}\$[A-Za-z0-9+/=]{44}:[A-Za-z0-9+/=]{44}'$`)
+	if !re.MatchString(e.query) {
+		t.Errorf("query = %s", e.query)
+	}
+	v1, _ := EncryptPassword("secret", 0)
+	v2, _ := EncryptPassword("secret", 0)
+	if v1 == v2 {
+		t.Error("each verifier should use a fresh salt")
+	}
+}
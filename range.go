@@ -0,0 +1,216 @@
+package pg
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"errors"
+	"fmt"
+	"github.com/blusewang/pg/v2/internal/codec"
+)
+
+// Range 对应 PostgreSQL 的范围类型，T 为元素类型
+// int4range、int8range 可用 int32、int64，numrange 可用 Numeric，tsrange、tstzrange、daterange 可用 time.Time
+//
+//	var r pg.Range[time.Time]
+//	err := db.QueryRow("select during from booking where id=$1", id).Scan(&r)
+//
+// 无界的一端忽略 Lower 或 Upper 的值，且总是开区间；Empty 为 true 时表示空范围，其余字段均被忽略
+type Range[T any] struct {
+	Lower          T
+	Upper          T
+	LowerInclusive bool
+	UpperInclusive bool
+	LowerUnbounded bool
+	UpperUnbounded bool
+	Empty          bool
+}
+
+// NewRange 与 PostgreSQL 的范围构造函数一致，bounds 为 "[)"、"[]"、"(]"、"()" 之一，缺省为 "[)"
+func NewRange[T any](lower, upper T, bounds ...string) Range[T] {
+	var b = "[)"
+	if len(bounds) > 0 && len(bounds[0]) == 2 {
+		b = bounds[0]
+	}
+	return Range[T]{Lower: lower, Upper: upper, LowerInclusive: b[0] == '[', UpperInclusive: b[1] == ']'}
+}
+
+func (r *Range[T]) fromCodec(cr codec.Range, decode func(dst interface{}, src []byte) error) (err error) {
+	*r = Range[T]{Empty: cr.Empty}
+	if cr.Empty {
+		return nil
+	}
+	r.LowerInclusive, r.UpperInclusive = cr.LowerInclusive, cr.UpperInclusive
+	if r.LowerUnbounded = cr.Lower == nil; !r.LowerUnbounded {
+		if err = decode(&r.Lower, cr.Lower); err != nil {
+			return
+		}
+	}
+	if r.UpperUnbounded = cr.Upper == nil; !r.UpperUnbounded {
+		err = decode(&r.Upper, cr.Upper)
+	}
+	return
+}
+
+// fromValue 由驱动解码后的范围赋值，边界已是 int64、string、time.Time 等 Go 的值
+func (r *Range[T]) fromValue(v codec.RangeValue) (err error) {
+	*r = Range[T]{Empty: v.Empty}
+	if v.Empty {
+		return nil
+	}
+	r.LowerInclusive, r.UpperInclusive = v.LowerInclusive, v.UpperInclusive
+	if r.LowerUnbounded = v.Lower == nil; !r.LowerUnbounded {
+		if err = assignElement(&r.Lower, v.Lower); err != nil {
+			return
+		}
+	}
+	if r.UpperUnbounded = v.Upper == nil; !r.UpperUnbounded {
+		err = assignElement(&r.Upper, v.Upper)
+	}
+	return
+}
+
+func (r Range[T]) toCodec(encode func(v interface{}) ([]byte, error)) (cr codec.Range, err error) {
+	if r.Empty {
+		return codec.Range{Empty: true}, nil
+	}
+	cr.LowerInclusive, cr.UpperInclusive = r.LowerInclusive, r.UpperInclusive
+	if !r.LowerUnbounded {
+		if cr.Lower, err = encode(r.Lower); err != nil {
+			return
+		}
+	}
+	if !r.UpperUnbounded {
+		cr.Upper, err = encode(r.Upper)
+	}
+	return
+}
+
+func scanTextElement(dst interface{}, src []byte) error {
+	return scanElement(dst, string(src))
+}
+
+func formatTextElement(v interface{}) ([]byte, error) {
+	s, err := formatElement(v)
+	return []byte(s), err
+}
+
+func (r *Range[T]) Scan(src interface{}) error {
+	var raw []byte
+	switch v := src.(type) {
+	case codec.RangeValue:
+		return r.fromValue(v)
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	case nil:
+		return errors.New("pg: cannot scan NULL into Range, use *Range instead")
+	default:
+		return fmt.Errorf("pg: cannot scan %T into Range", src)
+	}
+	cr, err := codec.ParseRange(raw)
+	if err != nil {
+		return err
+	}
+	return r.fromCodec(cr, scanTextElement)
+}
+
+func (r Range[T]) Value() (driver.Value, error) {
+	cr, err := r.toCodec(formatTextElement)
+	if err != nil {
+		return nil, err
+	}
+	return string(codec.FormatRange(cr)), nil
+}
+
+// UnmarshalBinary 解析范围的二进制格式，time.Time 类型的边界按长度区分 date 与 timestamp，均为 UTC 时间
+// 作为参数时以文本格式发送，由服务器按参数的类型解析，因此没有对应的 MarshalBinary
+func (r *Range[T]) UnmarshalBinary(src []byte) error {
+	cr, err := codec.ParseRangeBinary(src)
+	if err != nil {
+		return err
+	}
+	return r.fromCodec(cr, unmarshalElement)
+}
+
+// Multirange 对应 PostgreSQL 14 起的多范围类型，如 int4multirange、tstzmultirange
+// nil 表示 NULL，长度为 0 的切片表示空的多范围 {}
+type Multirange[T any] []Range[T]
+
+func (m *Multirange[T]) Scan(src interface{}) error {
+	var raw []byte
+	switch v := src.(type) {
+	case []codec.RangeValue:
+		var result = make(Multirange[T], len(v))
+		for i := range v {
+			if err := result[i].fromValue(v[i]); err != nil {
+				return err
+			}
+		}
+		*m = result
+		return nil
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	case nil:
+		*m = nil
+		return nil
+	default:
+		return fmt.Errorf("pg: cannot scan %T into Multirange", src)
+	}
+	ranges, err := codec.ParseMultirange(raw)
+	if err != nil {
+		return err
+	}
+	return m.fromCodec(ranges, scanTextElement)
+}
+
+func (m Multirange[T]) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+	ranges, err := m.toCodec(formatTextElement)
+	if err != nil {
+		return nil, err
+	}
+	return string(codec.FormatMultirange(ranges)), nil
+}
+
+// UnmarshalBinary 解析多范围的二进制格式
+func (m *Multirange[T]) UnmarshalBinary(src []byte) error {
+	ranges, err := codec.ParseMultirangeBinary(src)
+	if err != nil {
+		return err
+	}
+	return m.fromCodec(ranges, unmarshalElement)
+}
+
+func (m *Multirange[T]) fromCodec(ranges []codec.Range, decode func(dst interface{}, src []byte) error) error {
+	var result = make(Multirange[T], len(ranges))
+	for i, cr := range ranges {
+		if err := result[i].fromCodec(cr, decode); err != nil {
+			return err
+		}
+	}
+	*m = result
+	return nil
+}
+
+func (m Multirange[T]) toCodec(encode func(v interface{}) ([]byte, error)) ([]codec.Range, error) {
+	var ranges = make([]codec.Range, len(m))
+	for i, r := range m {
+		var err error
+		if ranges[i], err = r.toCodec(encode); err != nil {
+			return nil, err
+		}
+	}
+	return ranges, nil
+}
+
+var _ sql.Scanner = new(Range[int64])
+var _ driver.Valuer = new(Range[int64])
+var _ encoding.BinaryUnmarshaler = new(Range[int64])
+var _ sql.Scanner = new(Multirange[int64])
+var _ driver.Valuer = new(Multirange[int64])
@@ -0,0 +1,97 @@
+package pg
+
+import (
+	"github.com/blusewang/pg/v2/internal/codec"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRangeScanValue(t *testing.T) {
+	var ir Range[int32]
+	if err := ir.Scan(codec.RangeValue{Lower: int64(1), Upper: int64(10), LowerInclusive: true}); err != nil {
+		t.Fatal(err)
+	}
+	if ir != NewRange[int32](1, 10) {
+		t.Errorf("Scan = %+v", ir)
+	}
+	if v, err := ir.Value(); err != nil || v != "[1,10)" {
+		t.Errorf("Value() = %v, %v", v, err)
+	}
+	if err := ir.Scan(codec.RangeValue{Lower: int64(math.MaxInt32 + 1)}); err == nil {
+		t.Error("an int8 bound out of the int32 range should fail")
+	}
+
+	var loc = time.FixedZone("CST", 8*3600)
+	var lower = time.Date(2024, 1, 2, 0, 0, 0, 0, loc)
+	var tr Range[time.Time]
+	if err := tr.Scan(codec.RangeValue{Lower: lower, LowerInclusive: true}); err != nil {
+		t.Fatal(err)
+	}
+	if !tr.Lower.Equal(lower) || tr.Lower.Location() != loc || !tr.UpperUnbounded || tr.LowerUnbounded {
+		t.Errorf("Scan = %+v", tr)
+	}
+
+	var nr Range[Numeric]
+	if err := nr.Scan(codec.RangeValue{Lower: "1.50", Upper: "Infinity"}); err != nil {
+		t.Fatal(err)
+	}
+	if nr.Lower.String() != "1.50" || nr.Upper.Infinity != 1 {
+		t.Errorf("Scan = %+v", nr)
+	}
+
+	var sr Range[string]
+	if err := sr.Scan(codec.RangeValue{Lower: int64(3), Upper: lower}); err != nil || sr.Lower != "3" || sr.Upper != "2024-01-02 00:00:00+08:00" {
+		t.Errorf("Scan = %+v, %v", sr, err)
+	}
+
+	if err := ir.Scan(codec.RangeValue{Empty: true}); err != nil || !ir.Empty {
+		t.Errorf("Scan(empty) = %+v, %v", ir, err)
+	}
+	if err := ir.Scan(nil); err == nil {
+		t.Error("Scan(nil) should fail")
+	}
+}
+
+func TestRangeText(t *testing.T) {
+	var r Range[int64]
+	for _, s := range []string{"[1,10)", "(,5]", "empty", "(1,)"} {
+		if err := r.Scan(s); err != nil {
+			t.Errorf("Scan(%s): %v", s, err)
+			continue
+		}
+		if v, err := r.Value(); err != nil || v != s {
+			t.Errorf("Value() of %s = %v, %v", s, v, err)
+		}
+	}
+}
+
+func TestRangeUnmarshalBinary(t *testing.T) {
+	var day = time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	var ts = time.Date(2024, 1, 2, 8, 30, 0, 0, time.UTC)
+	var dates = codec.FormatRangeBinary(codec.Range{Lower: codec.FormatDateBinary(day), LowerInclusive: true})
+	var stamps = codec.FormatRangeBinary(codec.Range{Lower: codec.FormatTimestampBinary(ts), LowerInclusive: true})
+	var r Range[time.Time]
+	if err := r.UnmarshalBinary(dates); err != nil || !r.Lower.Equal(day) {
+		t.Errorf("UnmarshalBinary(daterange) = %+v, %v", r, err)
+	}
+	if err := r.UnmarshalBinary(stamps); err != nil || !r.Lower.Equal(ts) {
+		t.Errorf("UnmarshalBinary(tsrange) = %+v, %v", r, err)
+	}
+}
+
+func TestMultirangeScanValue(t *testing.T) {
+	var m Multirange[int64]
+	if err := m.Scan([]codec.RangeValue{{Lower: int64(1), Upper: int64(3), LowerInclusive: true}, {Lower: int64(5), Upper: int64(7), LowerInclusive: true}}); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := m.Value(); err != nil || v != "{[1,3),[5,7)}" {
+		t.Errorf("Value() = %v, %v", v, err)
+	}
+	if err := m.Scan(nil); err != nil || m != nil {
+		t.Errorf("Scan(nil) = %v, %v", m, err)
+	}
+	if v, err := m.Value(); err != nil || v != nil {
+		t.Errorf("Value() of NULL = %v, %v", v, err)
+	}
+}
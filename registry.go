@@ -0,0 +1,26 @@
+package pg
+
+import (
+	"github.com/blusewang/pg/v2/internal/app"
+)
+
+// TypeCodec 为枚举、域、扩展类型及用户定义类型提供编解码方法，各字段均可为空
+//
+//	ScanType     解码后的 Go 类型，为空时为 interface{}
+//	DecodeText   解码文本格式的值，为空时按字符串返回
+//	DecodeBinary 解码二进制格式的值，不为空时该类型的列以二进制格式接收
+//	Encode       把参数编码为文本格式，返回 nil 表示 NULL，返回 driver.ErrSkip 时按普通参数的规则转换；
+//	             参数的 Go 类型不是驱动本身支持的类型时，需把 ScanType 设为该类型，或先 Prepare 语句，否则会被提前拒绝
+type TypeCodec = app.TypeCodec
+
+// RegisterType 按类型名注册编解码方法，name 与 pg_type.typname 一致，可以带模式名，如 public.mood
+// 连接遇到未知的 OID 时会从 pg_type 中查询并缓存其名称，再按名称查找注册的方法
+// 域未注册时使用其基础类型的解码方式；枚举及其余未注册的类型按字符串返回；内置类型不受注册影响
+//
+//	err := pg.RegisterType("citext", pg.TypeCodec{
+//		ScanType:   reflect.TypeOf(""),
+//		DecodeText: func(src []byte) (interface{}, error) { return strings.ToLower(string(src)), nil },
+//	})
+func RegisterType(name string, tc TypeCodec) error {
+	return app.RegisterType(name, tc)
+}
@@ -0,0 +1,85 @@
+package pg
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"fmt"
+	"github.com/blusewang/pg/v2/internal/codec"
+	"strings"
+)
+
+// Vector 对应 pgvector 的 vector、halfvec，Vector 本身为 nil 时表示 NULL
+// 连接建立后按 search_path 从 pg_type 查询 pgvector 各类型的 OID，之后这些列以二进制格式接收并直接解码为 []float32；
+// pgvector 不在 search_path 中时，在 Parse 遇到这些类型时才按名称识别；
+// 参数类型为 vector、halfvec、sparsevec 时，Vector 及 []float32 均以二进制格式发送
+type Vector []float32
+
+// SparseVector 对应 pgvector 的 sparsevec，查询结果中的 sparsevec 列直接解码为该类型
+type SparseVector = codec.SparseVector
+
+// NewSparseVector 由稠密向量构造 SparseVector，只保留非零值
+func NewSparseVector(v []float32) SparseVector {
+	return codec.NewSparseVector(v)
+}
+
+func (v Vector) String() string {
+	return string(codec.FormatVector(v))
+}
+
+// Scan 支持 vector、halfvec 及 sparsevec，sparsevec 转为稠密向量
+func (v *Vector) Scan(src interface{}) (err error) {
+	switch x := src.(type) {
+	case nil:
+		*v = nil
+	case []float32:
+		*v = x
+	case SparseVector:
+		*v = x.Dense()
+	case string:
+		*v, err = parseVectorText(x)
+	case []byte:
+		*v, err = parseVectorText(string(x))
+	default:
+		err = fmt.Errorf("pg: cannot scan %T into Vector", src)
+	}
+	return
+}
+
+func parseVectorText(s string) (Vector, error) {
+	if strings.HasPrefix(strings.TrimSpace(s), "{") {
+		sv, err := codec.ParseSparseVector([]byte(s))
+		if err != nil {
+			return nil, err
+		}
+		return sv.Dense(), nil
+	}
+	return codec.ParseVector([]byte(s))
+}
+
+// Value 输出文本格式，参数类型为 text 等非 pgvector 类型时同样可用
+func (v Vector) Value() (driver.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return v.String(), nil
+}
+
+// MarshalBinary 输出 vector 的二进制格式
+func (v Vector) MarshalBinary() ([]byte, error) {
+	return codec.FormatVectorBinary(v), nil
+}
+
+// UnmarshalBinary 解析 vector 的二进制格式
+func (v *Vector) UnmarshalBinary(src []byte) error {
+	f, err := codec.ParseVectorBinary(src)
+	if err == nil {
+		*v = f
+	}
+	return err
+}
+
+var _ sql.Scanner = new(Vector)
+var _ driver.Valuer = new(Vector)
+var _ encoding.BinaryMarshaler = new(Vector)
+var _ encoding.BinaryUnmarshaler = new(Vector)
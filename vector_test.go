@@ -0,0 +1,34 @@
+package pg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVectorScanValue(t *testing.T) {
+	var v Vector
+	for _, src := range []interface{}{[]float32{1, 0, 2}, "[1,0,2]", []byte("[1, 0, 2]"), "{1:1,3:2}/3", NewSparseVector([]float32{1, 0, 2})} {
+		if err := v.Scan(src); err != nil || !reflect.DeepEqual(v, Vector{1, 0, 2}) {
+			t.Errorf("Scan(%v) = %v, %v", src, v, err)
+		}
+	}
+	if err := v.Scan(nil); err != nil || v != nil {
+		t.Errorf("Scan(nil) = %v, %v", v, err)
+	}
+	for _, src := range []interface{}{"1,2", "{4:1}/3", 1} {
+		if err := v.Scan(src); err == nil {
+			t.Errorf("Scan(%v) should fail", src)
+		}
+	}
+	if out, err := (Vector{1, 2.5}).Value(); err != nil || out != "[1,2.5]" {
+		t.Errorf("Value() = %v, %v", out, err)
+	}
+	if out, err := Vector(nil).Value(); err != nil || out != nil {
+		t.Errorf("nil Value() = %v, %v", out, err)
+	}
+	var back Vector
+	raw, _ := Vector{1, -2}.MarshalBinary()
+	if err := back.UnmarshalBinary(raw); err != nil || !reflect.DeepEqual(back, Vector{1, -2}) {
+		t.Errorf("binary round trip = %v, %v", back, err)
+	}
+}